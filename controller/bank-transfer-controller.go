@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BankTransferController interface {
+	GetBankTransferFormatList() fiber.Handler
+	ExportBankTransfer() fiber.Handler
+	ExportClaimTransfer() fiber.Handler
+}
+
+type bankTransferController struct {
+	service services.BankTransferService
+}
+
+func NewBankTransferController(service services.BankTransferService) BankTransferController {
+	return &bankTransferController{
+		service: service,
+	}
+}
+
+func (controller *bankTransferController) GetBankTransferFormatList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		utils.BuildResponse(c, fiber.StatusOK, "success", controller.service.GetBankTransferFormatList())
+		return nil
+	}
+}
+
+// Bulk transfer file of a run of a period, e.g.
+// ?period=2024-01&run_type=thr&format=bca&transfer_date=2024-01-25
+func (controller *bankTransferController) ExportBankTransfer() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		file, invalid, err := controller.service.ExportBankTransfer(c.Context(), c.Query("period"), c.Query("run_type"), c.Query("format", "generic"), c.Query("transfer_date"))
+		if len(invalid) > 0 {
+			utils.BuildResponse(c, fiber.StatusUnprocessableEntity, err.Error(), invalid)
+			return err
+		}
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, file.ContentType)
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+file.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(file.Content)
+	}
+}
+
+// Bulk transfer file of the approved claims paid apart from the payroll,
+// e.g. ?format=bca&transfer_date=2024-01-25
+func (controller *bankTransferController) ExportClaimTransfer() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		file, invalid, err := controller.service.ExportClaimTransfer(c.Context(), c.Query("format", "generic"), c.Query("transfer_date"))
+		if len(invalid) > 0 {
+			utils.BuildResponse(c, fiber.StatusUnprocessableEntity, err.Error(), invalid)
+			return err
+		}
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, file.ContentType)
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+file.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(file.Content)
+	}
+}
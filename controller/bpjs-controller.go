@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BpjsController interface {
+	//Read Operation
+	GetBpjsProgramList() fiber.Handler
+	//Update Operation
+	UpdateBpjsProgram() fiber.Handler
+}
+
+type bpjsController struct {
+	service services.BpjsService
+}
+
+func NewBpjsController(service services.BpjsService) BpjsController {
+	return &bpjsController{
+		service: service,
+	}
+}
+
+func (controller *bpjsController) GetBpjsProgramList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := controller.service.GetBpjsProgramList(c.Context())
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *bpjsController) UpdateBpjsProgram() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		code := c.Params("code")
+
+		var program model.UpdateBpjsProgramModel
+		err := c.BodyParser(&program)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		updated, err := controller.service.UpdateBpjsProgram(c.Context(), code, program)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", updated)
+		return err
+	}
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ClaimController interface {
+	//Create Operation
+	CreateClaimCategory() fiber.Handler
+	CreateClaim() fiber.Handler
+	CreateClaimReceipt() fiber.Handler
+	//Read Operation
+	GetClaimCategoryList() fiber.Handler
+	GetClaimList() fiber.Handler
+	GetClaimDetail() fiber.Handler
+	GetClaimReceipt() fiber.Handler
+	//Update Operation
+	UpdateClaimStatus() fiber.Handler
+}
+
+type claimController struct {
+	service services.ClaimService
+}
+
+func NewClaimController(service services.ClaimService) ClaimController {
+	return &claimController{
+		service: service,
+	}
+}
+
+func (controller *claimController) GetClaimCategoryList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := controller.service.GetClaimCategoryList(c.Context())
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *claimController) CreateClaimCategory() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var category model.CreateClaimCategoryModel
+		err := c.BodyParser(&category)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		code, err := controller.service.CreateClaimCategory(c.Context(), category)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "new claim category created", code)
+		return err
+	}
+}
+
+func (controller *claimController) CreateClaim() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var claim model.CreateClaimModel
+		err := c.BodyParser(&claim)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		id, err := controller.service.CreateClaim(c.Context(), claim)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "new claim created", id)
+		return err
+	}
+}
+
+// Query: an optional user_id and status
+func (controller *claimController) GetClaimList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, err := queryNullUUID(c, "user_id")
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		list, err := controller.service.GetClaimList(c.Context(), userId, c.Query("status"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *claimController) GetClaimDetail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		detail, err := controller.service.GetClaimDetail(c.Context(), id)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", detail)
+		return err
+	}
+}
+
+func (controller *claimController) UpdateClaimStatus() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		var status model.UpdateClaimStatusModel
+		err = c.BodyParser(&status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		updated, err := controller.service.UpdateClaimStatus(c.Context(), id, status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", updated)
+		return err
+	}
+}
+
+// Multipart upload of a receipt in the "file" field
+func (controller *claimController) CreateClaimReceipt() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		file, err := header.Open()
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		receiptId, err := controller.service.CreateClaimReceipt(c.Context(), id, filepath.Base(header.Filename), content)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "receipt attached", receiptId)
+		return err
+	}
+}
+
+func (controller *claimController) GetClaimReceipt() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		receiptId, err := uuid.Parse(c.Params("receipt_id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		receipt, err := controller.service.GetClaimReceipt(c.Context(), id, receiptId)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, receipt.Content_type)
+		c.Set(fiber.HeaderContentDisposition, `inline; filename="`+receipt.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(receipt.Content)
+	}
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CompensationController interface {
+	//Create Operation
+	CreateCompensation() fiber.Handler
+	//Read Operation
+	GetCompensationList() fiber.Handler
+}
+
+type compensationController struct {
+	service services.CompensationService
+}
+
+func NewCompensationController(service services.CompensationService) CompensationController {
+	return &compensationController{
+		service: service,
+	}
+}
+
+func (controller *compensationController) CreateCompensation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		var compensation model.CreateCompensationModel
+		err = c.BodyParser(&compensation)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		result, err := controller.service.CreateCompensation(c.Context(), userId, compensation)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "success", result)
+		return err
+	}
+}
+
+func (controller *compensationController) GetCompensationList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		list, err := controller.service.GetCompensationList(c.Context(), userId)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
@@ -78,6 +78,14 @@ func (c *leaveRecordController) CreateLeaveRecord() fiber.Handler {
 		// Forwarding data to service
 		req_id, err := c.leaveRecordService.CreateLeaveRecord(ctx.Context(), createLeaveRecord)
 		if err != nil {
+			if errors.Is(err, services.ErrPayrollPeriodClosed) {
+				utils.BuildErrorResponse(ctx, http.StatusConflict, err.Error())
+				return err
+			}
+			if errors.Is(err, services.ErrInvalidLeaveRecord) {
+				utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
+				return err
+			}
 			errMsg := errors.New("internal Server Error").Error()
 			utils.BuildErrorResponse(ctx, http.StatusInternalServerError, errMsg)
 			return err
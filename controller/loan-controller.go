@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type LoanController interface {
+	//Create Operation
+	CreateLoan() fiber.Handler
+	//Read Operation
+	GetLoanList() fiber.Handler
+	GetLoanDetail() fiber.Handler
+	//Update Operation
+	UpdateLoanStatus() fiber.Handler
+}
+
+type loanController struct {
+	service services.LoanService
+}
+
+func NewLoanController(service services.LoanService) LoanController {
+	return &loanController{
+		service: service,
+	}
+}
+
+func (controller *loanController) CreateLoan() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var loan model.CreateLoanModel
+		err := c.BodyParser(&loan)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		id, err := controller.service.CreateLoan(c.Context(), loan)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "new loan request created", id)
+		return err
+	}
+}
+
+// Query: an optional user_id and status
+func (controller *loanController) GetLoanList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, err := queryNullUUID(c, "user_id")
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		list, err := controller.service.GetLoanList(c.Context(), userId, c.Query("status"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *loanController) GetLoanDetail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		detail, err := controller.service.GetLoanDetail(c.Context(), id)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", detail)
+		return err
+	}
+}
+
+func (controller *loanController) UpdateLoanStatus() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		var status model.UpdateLoanStatusModel
+		err = c.BodyParser(&status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		updated, err := controller.service.UpdateLoanStatus(c.Context(), id, status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", updated)
+		return err
+	}
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type OvertimeController interface {
+	//Create Operation
+	CreateOvertimeRecord() fiber.Handler
+	//Read Operation
+	GetOvertimeRecordList() fiber.Handler
+	GetOvertimeRecordDetail() fiber.Handler
+	//Update Operation
+	UpdateOvertimeStatus() fiber.Handler
+}
+
+type overtimeController struct {
+	service services.OvertimeService
+}
+
+func NewOvertimeController(service services.OvertimeService) OvertimeController {
+	return &overtimeController{
+		service: service,
+	}
+}
+
+func (controller *overtimeController) CreateOvertimeRecord() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var record model.CreateOvertimeRecordModel
+		err := c.BodyParser(&record)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		id, err := controller.service.CreateOvertimeRecord(c.Context(), record)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "new overtime request created", id)
+		return err
+	}
+}
+
+// Query: period=YYYY-MM and an optional user_id
+func (controller *overtimeController) GetOvertimeRecordList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var userId uuid.NullUUID
+		if v := c.Query("user_id"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+				return err
+			}
+			userId = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		list, err := controller.service.GetOvertimeRecordList(c.Context(), userId, c.Query("period"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *overtimeController) GetOvertimeRecordDetail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		detail, err := controller.service.GetOvertimeRecordDetail(c.Context(), id)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", detail)
+		return err
+	}
+}
+
+func (controller *overtimeController) UpdateOvertimeStatus() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		var status model.UpdateOvertimeStatusModel
+		err = c.BodyParser(&status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		updated, err := controller.service.UpdateOvertimeStatus(c.Context(), id, status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", updated)
+		return err
+	}
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PayrollComponentController interface {
+	//Read Operation
+	GetPayrollComponentList() fiber.Handler
+	//Create Operation
+	CreatePayrollComponent() fiber.Handler
+}
+
+type payrollComponentController struct {
+	service services.PayrollComponentService
+}
+
+func NewPayrollComponentController(service services.PayrollComponentService) PayrollComponentController {
+	return &payrollComponentController{
+		service: service,
+	}
+}
+
+func (controller *payrollComponentController) GetPayrollComponentList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := controller.service.GetPayrollComponentList(c.Context())
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *payrollComponentController) CreatePayrollComponent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var component model.PayrollComponent
+		err := c.BodyParser(&component)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		created, err := controller.service.CreatePayrollComponent(c.Context(), component)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "success created", created)
+		return err
+	}
+}
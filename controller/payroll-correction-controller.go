@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PayrollCorrectionController interface {
+	//Create Operation
+	CreatePayrollCorrection() fiber.Handler
+	//Read Operation
+	GetPayrollCorrectionList() fiber.Handler
+}
+
+type payrollCorrectionController struct {
+	service services.PayrollCorrectionService
+}
+
+func NewPayrollCorrectionController(service services.PayrollCorrectionService) PayrollCorrectionController {
+	return &payrollCorrectionController{
+		service: service,
+	}
+}
+
+func (controller *payrollCorrectionController) CreatePayrollCorrection() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		var correction model.CreatePayrollCorrectionModel
+		err = c.BodyParser(&correction)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		result, err := controller.service.CreatePayrollCorrection(c.Context(), id, correction)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "success", result)
+		return err
+	}
+}
+
+func (controller *payrollCorrectionController) GetPayrollCorrectionList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		list, err := controller.service.GetPayrollCorrectionList(c.Context(), id)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PayrollImportController interface {
+	//Create Operation
+	ImportPayrollInput() fiber.Handler
+}
+
+type payrollImportController struct {
+	service services.PayrollImportService
+}
+
+func NewPayrollImportController(service services.PayrollImportService) PayrollImportController {
+	return &payrollImportController{
+		service: service,
+	}
+}
+
+// Multipart upload of a CSV or XLSX sheet in the "file" field. Query:
+// payment_period, an optional payment_date and dry_run=true to preview the
+// import without saving it.
+func (controller *payrollImportController) ImportPayrollInput() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header, err := c.FormFile("file")
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		file, err := header.Open()
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		result, err := controller.service.ImportPayrollInput(c.Context(), model.PayrollImportModel{
+			Payment_period: c.Query("payment_period"),
+			Payment_date:   c.Query("payment_date"),
+			Filename:       filepath.Base(header.Filename),
+		}, content, c.QueryBool("dry_run"))
+		switch {
+		case len(result.Errors) > 0:
+			// The preview says which rows failed, nothing was saved
+			utils.BuildResponse(c, fiber.StatusUnprocessableEntity, "no payroll records imported, fix the failed rows", result)
+			return err
+		case err != nil:
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		case result.Dry_run:
+			utils.BuildResponse(c, fiber.StatusOK, "success validated", result)
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "payroll records imported", result)
+		return err
+	}
+}
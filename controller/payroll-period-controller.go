@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/dafiqarba/be-payroll/middleware"
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PayrollPeriodController interface {
+	//Create Operation
+	CreatePayrollPeriod() fiber.Handler
+	//Read Operation
+	GetPayrollPeriodList() fiber.Handler
+	GetPayrollPeriodDetail() fiber.Handler
+	//Update Operation
+	ClosePayrollPeriod() fiber.Handler
+	ReopenPayrollPeriod() fiber.Handler
+}
+
+type payrollPeriodController struct {
+	service services.PayrollPeriodService
+}
+
+func NewPayrollPeriodController(service services.PayrollPeriodService) PayrollPeriodController {
+	return &payrollPeriodController{
+		service: service,
+	}
+}
+
+func (controller *payrollPeriodController) CreatePayrollPeriod() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var period model.CreatePayrollPeriodModel
+		err := c.BodyParser(&period)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		created, err := controller.service.CreatePayrollPeriod(c.Context(), period)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "new payroll period created", created)
+		return err
+	}
+}
+
+func (controller *payrollPeriodController) GetPayrollPeriodList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := controller.service.GetPayrollPeriodList(c.Context())
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *payrollPeriodController) GetPayrollPeriodDetail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		detail, err := controller.service.GetPayrollPeriodDetail(c.Context(), c.Params("period"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", detail)
+		return err
+	}
+}
+
+// The user closing the period is the one of the token, never the body
+func (controller *payrollPeriodController) ClosePayrollPeriod() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var status model.UpdatePayrollPeriodStatusModel
+		err := c.BodyParser(&status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		userID, ok := c.Locals(middleware.LocalsUserID).(uuid.UUID)
+		if !ok {
+			err = errors.New("no token provided")
+			utils.BuildErrorResponse(c, fiber.StatusUnauthorized, err.Error())
+			return err
+		}
+		status.User_id = userID
+
+		updated, err := controller.service.ClosePayrollPeriod(c.Context(), c.Params("period"), status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "payroll period closed", updated)
+		return err
+	}
+}
+
+// The user reopening the period is the one of the token, never the body
+func (controller *payrollPeriodController) ReopenPayrollPeriod() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var status model.UpdatePayrollPeriodStatusModel
+		err := c.BodyParser(&status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		userID, ok := c.Locals(middleware.LocalsUserID).(uuid.UUID)
+		if !ok {
+			err = errors.New("no token provided")
+			utils.BuildErrorResponse(c, fiber.StatusUnauthorized, err.Error())
+			return err
+		}
+		status.User_id = userID
+
+		updated, err := controller.service.ReopenPayrollPeriod(c.Context(), c.Params("period"), status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "payroll period reopened", updated)
+		return err
+	}
+}
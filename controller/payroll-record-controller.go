@@ -29,19 +29,39 @@ func NewPayrollRecordController(payrollRecordServ services.PayrollRecordService)
 	}
 }
 
+// Query: period_from, period_to, user_id, position_id, status,
+// payment_date_from, payment_date_to, search (employee name), sort, order
+// (asc or desc), page and page_size, all optional
 func (c *payrollRecordController) GetPayrollRecordList() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
+		filter := model.PayrollRecordFilter{
+			Period_from:       ctx.Query("period_from"),
+			Period_to:         ctx.Query("period_to"),
+			Status:            ctx.Query("status"),
+			Payment_date_from: ctx.Query("payment_date_from"),
+			Payment_date_to:   ctx.Query("payment_date_to"),
+			Search:            ctx.Query("search"),
+			Sort:              ctx.Query("sort"),
+			Order:             ctx.Query("order"),
+			Page:              ctx.QueryInt("page"),
+			Page_size:         ctx.QueryInt("page_size"),
+		}
+		var err error
+		if filter.User_id, err = queryNullUUID(ctx, "user_id"); err != nil {
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return err
+		}
+		if filter.Position_id, err = queryNullUUID(ctx, "position_id"); err != nil {
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return err
+		}
 
-		// v := request.URL.Query()
-		// id, _ := strconv.Atoi(v.Get("user_id"))
-		// year := v.Get("year")
-
-		payrollRecordList, err := c.payrollRecordService.GetPayrollRecordList(ctx.Context())
+		payrollRecordPage, err := c.payrollRecordService.GetPayrollRecordList(ctx.Context(), filter)
 		if err != nil {
-			utils.BuildErrorResponse(ctx, http.StatusNotFound, err.Error())
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
 			return err
 		}
-		utils.BuildResponse(ctx, http.StatusOK, "success", payrollRecordList)
+		utils.BuildResponse(ctx, http.StatusOK, "success", payrollRecordPage)
 		return err
 	}
 }
@@ -74,8 +94,7 @@ func (c *payrollRecordController) GetPayrollRecordDetail() fiber.Handler {
 
 func (c *payrollRecordController) CreatePayrollRecord() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		var payrollRecord model.PayrollRecord
-		// var payrollRecord model.CreatePayrollRecordModel
+		var payrollRecord model.CreatePayrollRecordModel
 		err := ctx.BodyParser(&payrollRecord)
 		if err != nil {
 			utils.BuildErrorResponse(ctx, http.StatusInternalServerError, err.Error())
@@ -84,8 +103,7 @@ func (c *payrollRecordController) CreatePayrollRecord() fiber.Handler {
 
 		newPayrollRecord, err := c.payrollRecordService.CreatePayrollRecord(ctx.Context(), payrollRecord)
 		if err != nil {
-			errMsg := errors.New("internal Server Error").Error()
-			utils.BuildErrorResponse(ctx, http.StatusInternalServerError, errMsg)
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
 			return err
 		}
 		utils.BuildResponse(ctx, http.StatusCreated, "success created", newPayrollRecord)
@@ -93,23 +111,30 @@ func (c *payrollRecordController) CreatePayrollRecord() fiber.Handler {
 	}
 }
 
-// Go routine to create payroll record list
+// Query: dry_run=true validates and computes the batch without saving it
 func (c *payrollRecordController) CreatePayrollRecordList() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		var payrollRecordList []model.PayrollRecord
+		var payrollRecordList []model.CreatePayrollRecordModel
 		err := ctx.BodyParser(&payrollRecordList)
 		if err != nil {
-			utils.BuildErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
 			return err
 		}
 
-		newPayrollRecordList, err := c.payrollRecordService.CreatePayrollRecordList(ctx.Context(), payrollRecordList)
-		if err != nil {
-			errMsg := errors.New("internal Server Error").Error()
-			utils.BuildErrorResponse(ctx, http.StatusInternalServerError, errMsg)
+		result, err := c.payrollRecordService.CreatePayrollRecordList(ctx.Context(), payrollRecordList, ctx.QueryBool("dry_run"))
+		switch {
+		case len(result.Errors) > 0:
+			// The report says which rows failed, nothing was saved
+			utils.BuildResponse(ctx, http.StatusUnprocessableEntity, "no payroll records created, fix the failed rows", result)
+			return err
+		case err != nil:
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return err
+		case result.Dry_run:
+			utils.BuildResponse(ctx, http.StatusOK, "success validated", result)
 			return err
 		}
-		utils.BuildResponse(ctx, http.StatusCreated, "success created", newPayrollRecordList)
+		utils.BuildResponse(ctx, http.StatusCreated, "success created", result)
 		return err
 	}
 }
@@ -120,7 +145,7 @@ func (c *payrollRecordController) UpdatePayrollRecord() fiber.Handler {
 		params_id := ctx.Params("id")
 		id := uuid.MustParse(params_id)
 
-		var payrollRecord model.PayrollRecord
+		var payrollRecord model.UpdatePayrollRecordModel
 		err := ctx.BodyParser(&payrollRecord)
 		if err != nil {
 			utils.BuildErrorResponse(ctx, http.StatusNotFound, err.Error())
@@ -129,11 +154,23 @@ func (c *payrollRecordController) UpdatePayrollRecord() fiber.Handler {
 
 		updatedPayrollRecord, err := c.payrollRecordService.UpdatePayrollRecord(ctx.Context(), id, payrollRecord)
 		if err != nil {
-			errMsg := errors.New("internal Server Error").Error()
-			utils.BuildErrorResponse(ctx, http.StatusNotFound, errMsg)
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
 			return err
 		}
 		utils.BuildResponse(ctx, http.StatusOK, "success updated", updatedPayrollRecord)
 		return err
 	}
 }
+
+// Optional uuid query parameter, invalid when set to anything but an id
+func queryNullUUID(ctx *fiber.Ctx, name string) (uuid.NullUUID, error) {
+	v := ctx.Query(name)
+	if v == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return uuid.NullUUID{}, errors.New(name + " is not a valid id")
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
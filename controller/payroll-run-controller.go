@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PayrollRunController interface {
+	//Create Operation
+	CreatePayrollRun() fiber.Handler
+	//Read Operation
+	GetPayrollRunList() fiber.Handler
+	GetPayrollRunDetail() fiber.Handler
+	//Update Operation
+	UpdatePayrollRunStatus() fiber.Handler
+}
+
+type payrollRunController struct {
+	service services.PayrollRunService
+}
+
+func NewPayrollRunController(service services.PayrollRunService) PayrollRunController {
+	return &payrollRunController{
+		service: service,
+	}
+}
+
+func (controller *payrollRunController) CreatePayrollRun() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var run model.CreatePayrollRunModel
+		err := c.BodyParser(&run)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		result, err := controller.service.CreatePayrollRun(c.Context(), run)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "success", result)
+		return err
+	}
+}
+
+func (controller *payrollRunController) GetPayrollRunList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := controller.service.GetPayrollRunList(c.Context())
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *payrollRunController) GetPayrollRunDetail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		detail, err := controller.service.GetPayrollRunDetail(c.Context(), id)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", detail)
+		return err
+	}
+}
+
+func (controller *payrollRunController) UpdatePayrollRunStatus() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		var status model.UpdatePayrollRunStatusModel
+		err = c.BodyParser(&status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		updated, err := controller.service.UpdatePayrollRunStatus(c.Context(), id, status)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", updated)
+		return err
+	}
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PayrollSimulationController interface {
+	//Read Operation
+	SimulatePayroll() fiber.Handler
+}
+
+type payrollSimulationController struct {
+	service services.PayrollCalculationService
+}
+
+func NewPayrollSimulationController(service services.PayrollCalculationService) PayrollSimulationController {
+	return &payrollSimulationController{
+		service: service,
+	}
+}
+
+// Computes the breakdown and employer cost of a hypothetical salary, nothing
+// is saved
+func (controller *payrollSimulationController) SimulatePayroll() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var simulation model.PayrollSimulationModel
+		err := c.BodyParser(&simulation)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		result, err := controller.service.Simulate(c.Context(), simulation)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", result)
+		return err
+	}
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PayslipController interface {
+	GetPayslip() fiber.Handler
+	GetPayslipArchive() fiber.Handler
+	DistributePayslips() fiber.Handler
+	GetPayslipDeliveryList() fiber.Handler
+}
+
+type payslipController struct {
+	service             services.PayslipService
+	distributionService services.PayslipDistributionService
+}
+
+func NewPayslipController(service services.PayslipService, distributionService services.PayslipDistributionService) PayslipController {
+	return &payslipController{
+		service:             service,
+		distributionService: distributionService,
+	}
+}
+
+func (controller *payslipController) GetPayslip() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		payslip, err := controller.service.GetPayslip(c.Context(), id)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, `inline; filename="`+payslip.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(payslip.Content)
+	}
+}
+
+// Every payslip of the period given in the period query, e.g. ?period=2024-01
+func (controller *payslipController) GetPayslipArchive() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		archive, err := controller.service.GetPayslipArchive(c.Context(), c.Query("period"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "application/zip")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+archive.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(archive.Content)
+	}
+}
+
+// Starts emailing the payslips of a period, progress is in the delivery list
+func (controller *payslipController) DistributePayslips() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var distribute model.DistributePayslipModel
+		err := c.BodyParser(&distribute)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		list, err := controller.distributionService.DistributePayslips(c.Context(), distribute)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusAccepted, "success", list)
+		return err
+	}
+}
+
+func (controller *payslipController) GetPayslipDeliveryList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := controller.distributionService.GetPayslipDeliveryList(c.Context(), c.Query("period"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportController interface {
+	//Read Operation
+	GetPayrollCostSummary() fiber.Handler
+	ExportPayrollCostSummary() fiber.Handler
+	GetPayrollVariance() fiber.Handler
+}
+
+type reportController struct {
+	service services.ReportService
+}
+
+func NewReportController(service services.ReportService) ReportController {
+	return &reportController{
+		service: service,
+	}
+}
+
+// Query: period_from=YYYY-MM, an optional period_to and group_by, e.g.
+// ?period_from=2024-01&period_to=2024-03&group_by=position
+func (controller *reportController) GetPayrollCostSummary() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		summary, err := controller.service.GetPayrollCostSummary(c.Context(), c.Query("period_from"), c.Query("period_to"), c.Query("group_by"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", summary)
+		return err
+	}
+}
+
+func (controller *reportController) ExportPayrollCostSummary() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		file, err := controller.service.ExportPayrollCostSummary(c.Context(), c.Query("period_from"), c.Query("period_to"), c.Query("group_by"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+file.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(file.Content)
+	}
+}
+
+// Query: period=YYYY-MM and optionally compare_to=YYYY-MM and threshold in
+// percent, e.g. ?period=2024-03&threshold=5
+func (controller *reportController) GetPayrollVariance() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report, err := controller.service.GetPayrollVariance(c.Context(), c.Query("period"), c.Query("compare_to"), c.Query("threshold"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", report)
+		return err
+	}
+}
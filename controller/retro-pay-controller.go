@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RetroPayController interface {
+	//Create Operation
+	CreateRetroPay() fiber.Handler
+	//Read Operation
+	GetRetroPayList() fiber.Handler
+}
+
+type retroPayController struct {
+	service services.RetroPayService
+}
+
+func NewRetroPayController(service services.RetroPayService) RetroPayController {
+	return &retroPayController{
+		service: service,
+	}
+}
+
+func (controller *retroPayController) CreateRetroPay() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var retro model.CreateRetroPayModel
+		err := c.BodyParser(&retro)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		result, err := controller.service.CreateRetroPay(c.Context(), retro)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "success", result)
+		return err
+	}
+}
+
+// Query: period=YYYY-MM and an optional user_id
+func (controller *retroPayController) GetRetroPayList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var userId uuid.NullUUID
+		if v := c.Query("user_id"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+				return err
+			}
+			userId = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		list, err := controller.service.GetRetroPayList(c.Context(), userId, c.Query("period"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TaxCertificateController interface {
+	GetTaxCertificate() fiber.Handler
+	GetTaxCertificateList() fiber.Handler
+	GetTaxCertificatePDF() fiber.Handler
+	GetTaxCertificateArchive() fiber.Handler
+}
+
+type taxCertificateController struct {
+	service services.TaxCertificateService
+}
+
+func NewTaxCertificateController(service services.TaxCertificateService) TaxCertificateController {
+	return &taxCertificateController{
+		service: service,
+	}
+}
+
+// Form data of a single user, e.g. /tax/1721-a1/:user_id?year=2024
+func (controller *taxCertificateController) GetTaxCertificate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, err := uuid.Parse(c.Params("user_id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		year, err := strconv.Atoi(c.Query("year"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, "year must be a four digit tax year")
+			return err
+		}
+
+		certificate, err := controller.service.GetTaxCertificate(c.Context(), userId, year)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", certificate)
+		return err
+	}
+}
+
+// Form data of every user paid in the year
+func (controller *taxCertificateController) GetTaxCertificateList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		year, err := strconv.Atoi(c.Query("year"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, "year must be a four digit tax year")
+			return err
+		}
+
+		list, err := controller.service.GetTaxCertificateList(c.Context(), year)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
+
+func (controller *taxCertificateController) GetTaxCertificatePDF() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, err := uuid.Parse(c.Params("user_id"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		year, err := strconv.Atoi(c.Query("year"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, "year must be a four digit tax year")
+			return err
+		}
+
+		file, err := controller.service.GetTaxCertificatePDF(c.Context(), userId, year)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusNotFound, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, `inline; filename="`+file.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(file.Content)
+	}
+}
+
+// Every certificate of the year as PDFs in a zip
+func (controller *taxCertificateController) GetTaxCertificateArchive() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		year, err := strconv.Atoi(c.Query("year"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, "year must be a four digit tax year")
+			return err
+		}
+
+		archive, err := controller.service.GetTaxCertificateArchive(c.Context(), year)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "application/zip")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+archive.Filename+`"`)
+		return c.Status(fiber.StatusOK).Send(archive.Content)
+	}
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ThrController interface {
+	//Create Operation
+	CreateThrRun() fiber.Handler
+	//Read Operation
+	GetThrEntitlementList() fiber.Handler
+}
+
+type thrController struct {
+	service services.ThrService
+}
+
+func NewThrController(service services.ThrService) ThrController {
+	return &thrController{
+		service: service,
+	}
+}
+
+func (controller *thrController) CreateThrRun() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var run model.CreateThrRunModel
+		err := c.BodyParser(&run)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+
+		result, err := controller.service.CreateThrRun(c.Context(), run)
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusCreated, "success", result)
+		return err
+	}
+}
+
+// Query: holiday_date=YYYY-MM-DD
+func (controller *thrController) GetThrEntitlementList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := controller.service.GetThrEntitlementList(c.Context(), c.Query("holiday_date"))
+		if err != nil {
+			utils.BuildErrorResponse(c, fiber.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(c, fiber.StatusOK, "success", list)
+		return err
+	}
+}
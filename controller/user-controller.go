@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/dafiqarba/be-payroll/model"
 	"github.com/dafiqarba/be-payroll/services"
 	"github.com/dafiqarba/be-payroll/utils"
 	"github.com/gofiber/fiber/v2"
@@ -14,6 +15,8 @@ type UserController interface {
 	//Read Operation
 	GetUserList() fiber.Handler
 	GetUserDetail() fiber.Handler
+	//Update Operation
+	UpdateUserEmployment() fiber.Handler
 }
 
 type userController struct {
@@ -55,3 +58,28 @@ func (c *userController) GetUserDetail() fiber.Handler {
 		return err
 	}
 }
+
+func (c *userController) UpdateUserEmployment() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := uuid.Parse(ctx.Params("id"))
+		if err != nil {
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return err
+		}
+
+		var employment model.UpdateUserEmploymentModel
+		err = ctx.BodyParser(&employment)
+		if err != nil {
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return err
+		}
+
+		updated, err := c.userService.UpdateUserEmployment(ctx.Context(), id, employment)
+		if err != nil {
+			utils.BuildErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return err
+		}
+		utils.BuildResponse(ctx, http.StatusOK, "success", updated)
+		return err
+	}
+}
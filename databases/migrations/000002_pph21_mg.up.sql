@@ -0,0 +1,10 @@
+begin;
+
+alter table if exists public.users
+  add column if not exists ptkp_status varchar(10) not null default 'TK/0';
+
+alter table if exists public.payroll_records
+  add column if not exists tax_method varchar(20) not null default 'gross',
+  add column if not exists tax_detail jsonb;
+
+commit;
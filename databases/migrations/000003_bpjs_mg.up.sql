@@ -0,0 +1,52 @@
+begin;
+
+create table if not exists public.bpjs_programs (
+  program_code varchar(10) primary key,
+  name varchar(200) not null,
+  employer_rate numeric(6,4) not null default 0,
+  employee_rate numeric(6,4) not null default 0,
+  wage_cap bigint not null default 0,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp
+);
+
+insert into public.bpjs_programs (program_code, name, employer_rate, employee_rate, wage_cap) values
+  ('JHT', 'Jaminan Hari Tua', 0.0370, 0.0200, 0),
+  ('JP', 'Jaminan Pensiun', 0.0200, 0.0100, 10042300),
+  ('JKK', 'Jaminan Kecelakaan Kerja', 0, 0, 0),
+  ('JKM', 'Jaminan Kematian', 0.0030, 0, 0),
+  ('KES', 'BPJS Kesehatan', 0.0400, 0.0100, 12000000)
+on conflict (program_code) do nothing;
+
+create table if not exists public.bpjs_jkk_rates (
+  risk_class int primary key,
+  rate numeric(6,4) not null
+);
+
+insert into public.bpjs_jkk_rates (risk_class, rate) values
+  (1, 0.0024),
+  (2, 0.0054),
+  (3, 0.0089),
+  (4, 0.0127),
+  (5, 0.0174)
+on conflict (risk_class) do nothing;
+
+alter table if exists public.positions
+  add column if not exists jkk_risk_class int not null default 1;
+
+create table if not exists public.payroll_bpjs_items (
+  item_id uuid primary key default uuid_generate_v4(),
+  payroll_id uuid not null,
+  program_code varchar(10) not null,
+  base_wage bigint not null default 0,
+  employer_rate numeric(6,4) not null default 0,
+  employee_rate numeric(6,4) not null default 0,
+  employer_amount bigint not null default 0,
+  employee_amount bigint not null default 0,
+  created_at timestamp default current_timestamp,
+
+  constraint fk_payroll_id foreign key (payroll_id) references public.payroll_records (payroll_id) match simple on update cascade on delete cascade,
+  constraint fk_program_code foreign key (program_code) references public.bpjs_programs (program_code) match simple on update cascade on delete restrict
+);
+
+commit;
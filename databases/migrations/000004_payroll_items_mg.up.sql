@@ -0,0 +1,49 @@
+begin;
+
+create table if not exists public.payroll_components (
+  component_code varchar(50) primary key,
+  name varchar(200) not null,
+  component_type varchar(20) not null,
+  is_taxable boolean not null default true,
+  is_bpjs_base boolean not null default false,
+  is_one_off boolean not null default false,
+  is_system boolean not null default false,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint component_type_check check (component_type in ('earning', 'deduction'))
+);
+
+insert into public.payroll_components (component_code, name, component_type, is_taxable, is_bpjs_base, is_one_off, is_system) values
+  ('BASIC', 'Gaji Pokok', 'earning', true, true, false, true),
+  ('FIXED_ALLOWANCE', 'Tunjangan Tetap', 'earning', true, true, false, false),
+  ('TRANSPORT', 'Tunjangan Transport', 'earning', true, false, false, false),
+  ('MEAL', 'Tunjangan Makan', 'earning', true, false, false, false),
+  ('OVERTIME', 'Lembur', 'earning', true, false, true, false),
+  ('BONUS', 'Bonus', 'earning', true, false, true, false),
+  ('LOAN_INSTALLMENT', 'Cicilan Pinjaman', 'deduction', false, false, false, false),
+  ('UNPAID_LEAVE', 'Potongan Cuti Tidak Dibayar', 'deduction', true, true, true, false),
+  ('OTHER_DEDUCTION', 'Potongan Lain', 'deduction', false, false, true, false),
+  ('TAX_ALLOWANCE', 'Tunjangan PPh 21', 'earning', true, false, false, true),
+  ('BPJS_EMPLOYEE', 'Iuran BPJS Karyawan', 'deduction', false, false, false, true),
+  ('PPH21', 'PPh 21', 'deduction', false, false, false, true)
+on conflict (component_code) do nothing;
+
+create table if not exists public.payroll_items (
+  item_id uuid primary key default uuid_generate_v4(),
+  payroll_id uuid not null,
+  component_code varchar(50) not null,
+  component_type varchar(20) not null,
+  amount bigint not null,
+  is_taxable boolean not null default true,
+  is_bpjs_base boolean not null default false,
+  is_one_off boolean not null default false,
+  note varchar(200) not null default '',
+  created_at timestamp default current_timestamp,
+
+  constraint fk_payroll_id foreign key (payroll_id) references public.payroll_records (payroll_id) match simple on update cascade on delete cascade,
+  constraint fk_component_code foreign key (component_code) references public.payroll_components (component_code) match simple on update cascade on delete restrict
+);
+
+commit;
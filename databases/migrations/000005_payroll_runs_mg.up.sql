@@ -0,0 +1,36 @@
+begin;
+
+create table if not exists public.payroll_runs (
+  run_id uuid primary key default uuid_generate_v4(),
+  payment_period varchar(200) not null,
+  payment_date timestamp not null,
+  run_type varchar(20) not null default 'regular',
+  status varchar(20) not null default 'draft',
+  reviewed_at timestamp,
+  approved_at timestamp,
+  paid_at timestamp,
+  closed_at timestamp,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint payroll_run_status_check check (status in ('draft', 'reviewed', 'approved', 'paid', 'closed'))
+);
+
+create unique index if not exists payroll_runs_period_type_unique
+  on public.payroll_runs (payment_period, run_type) where is_delete = false;
+
+alter table if exists public.payroll_records
+  add column if not exists run_id uuid,
+  add constraint fk_run_id foreign key (run_id) references public.payroll_runs (run_id) match simple on update cascade on delete restrict;
+
+-- payroll records mirror the status of their run
+insert into public.status (name) values
+  ('draft'),
+  ('reviewed'),
+  ('approved'),
+  ('paid'),
+  ('closed')
+on conflict (name) do nothing;
+
+commit;
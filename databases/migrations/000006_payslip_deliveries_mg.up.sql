@@ -0,0 +1,26 @@
+begin;
+
+-- birth date is the default password of emailed payslips
+alter table if exists public.users
+  add column if not exists birth_date date;
+
+create table if not exists public.payslip_deliveries (
+  delivery_id uuid primary key default uuid_generate_v4(),
+  payroll_id uuid not null,
+  user_id uuid not null,
+  payment_period varchar(200) not null,
+  email varchar(200) not null,
+  status varchar(20) not null default 'pending',
+  attempts int not null default 0,
+  last_error text,
+  sent_at timestamp,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+
+  constraint payslip_delivery_payroll_unique unique (payroll_id),
+  constraint payslip_delivery_status_check check (status in ('pending', 'sent', 'failed')),
+  constraint fk_payroll_id foreign key (payroll_id) references public.payroll_records (payroll_id) match simple on update cascade on delete cascade,
+  constraint fk_user_id foreign key (user_id) references public.users (user_id) match simple on update cascade on delete restrict
+);
+
+commit;
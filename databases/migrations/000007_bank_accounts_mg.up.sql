@@ -0,0 +1,9 @@
+begin;
+
+-- salary account of the employee, used by the bank transfer export
+alter table if exists public.users
+  add column if not exists bank_code varchar(10),
+  add column if not exists bank_account_number varchar(30),
+  add column if not exists bank_account_name varchar(200);
+
+commit;
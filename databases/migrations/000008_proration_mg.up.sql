@@ -0,0 +1,33 @@
+begin;
+
+-- employment window used to prorate salaries of joiners and leavers
+alter table if exists public.users
+  add column if not exists join_date date,
+  add column if not exists termination_date date;
+
+create table if not exists public.leave_types (
+  leave_id int primary key,
+  leave_name varchar(200) not null,
+  is_paid boolean not null default true,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false
+);
+
+insert into public.leave_types (leave_id, leave_name, is_paid) values
+  (1, 'Cuti Tahunan', true),
+  (2, 'Izin', true),
+  (3, 'Sakit', true),
+  (4, 'Cuti Tanpa Upah', false)
+on conflict (leave_id) do nothing;
+
+-- leave records carry the type of leave, not a balance row
+alter table if exists public.leave_records
+  drop constraint if exists fk_leave_id;
+alter table if exists public.leave_records
+  add constraint fk_leave_type_id foreign key (leave_id) references public.leave_types (leave_id) match simple on update cascade on delete restrict not valid;
+
+alter table if exists public.payroll_records
+  add column if not exists proration jsonb;
+
+commit;
@@ -0,0 +1,17 @@
+begin;
+
+-- yearly quota in days, leave taken beyond it is deducted from the salary
+alter table if exists public.leave_types
+  add column if not exists quota int;
+
+update public.leave_types set quota = 3 where leave_id = 2 and quota is null;
+
+-- leave deductions are generated from leave_records by the calculation
+-- engine and point back to the request that caused them
+update public.payroll_components set is_system = true, updated_at = now()
+  where component_code = 'UNPAID_LEAVE';
+
+alter table if exists public.payroll_items
+  add column if not exists reference_id uuid;
+
+commit;
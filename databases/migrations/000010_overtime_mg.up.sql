@@ -0,0 +1,30 @@
+begin;
+
+create table if not exists public.overtime_records (
+  overtime_id uuid primary key default uuid_generate_v4(),
+  user_id uuid not null,
+  overtime_date date not null,
+  hours numeric(4,2) not null,
+  day_type varchar(20) not null,
+  reason text not null default '',
+  status varchar(20) not null default 'pending',
+  decided_at timestamp,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint overtime_hours_check check (hours > 0 and hours <= 24),
+  constraint overtime_day_type_check check (day_type in ('workday', 'rest_day', 'public_holiday')),
+  constraint overtime_status_check check (status in ('pending', 'approved', 'rejected')),
+  constraint fk_user_id foreign key (user_id) references public.users (user_id) match simple on update cascade on delete restrict
+);
+
+create index if not exists overtime_records_user_date_idx
+  on public.overtime_records (user_id, overtime_date);
+
+-- overtime pay is generated from approved overtime_records by the
+-- calculation engine
+update public.payroll_components set is_system = true, updated_at = now()
+  where component_code = 'OVERTIME';
+
+commit;
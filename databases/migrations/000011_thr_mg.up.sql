@@ -0,0 +1,12 @@
+begin;
+
+-- special runs such as THR are paid for a religious holiday
+alter table if exists public.payroll_runs
+  add column if not exists holiday_name varchar(200),
+  add column if not exists holiday_date date;
+
+insert into public.payroll_components (component_code, name, component_type, is_taxable, is_bpjs_base, is_one_off, is_system) values
+  ('THR', 'Tunjangan Hari Raya', 'earning', true, false, true, true)
+on conflict (component_code) do nothing;
+
+commit;
@@ -0,0 +1,16 @@
+begin;
+
+-- paid records are never edited, a correction reverses the record and books
+-- the corrected figures as an adjustment in the next open period
+alter table if exists public.payroll_records
+  add column if not exists record_type varchar(20) not null default 'regular',
+  add column if not exists corrects_payroll_id uuid,
+  add column if not exists correction_reason varchar(500) not null default '',
+  add constraint payroll_record_type_check check (record_type in ('regular', 'reversal', 'adjustment')),
+  add constraint fk_corrects_payroll_id foreign key (corrects_payroll_id) references public.payroll_records (payroll_id) match simple on update cascade on delete restrict;
+
+-- a record is reversed at most once
+create unique index if not exists payroll_records_reversal_unique
+  on public.payroll_records (corrects_payroll_id) where record_type = 'reversal' and is_delete = false;
+
+commit;
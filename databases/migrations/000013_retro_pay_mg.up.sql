@@ -0,0 +1,37 @@
+begin;
+
+-- differences of an earned period recomputed after a back-dated salary
+-- change, paid as rapel with the payroll of payment_period
+create table if not exists public.retro_pays (
+  retro_id uuid primary key default uuid_generate_v4(),
+  user_id uuid not null,
+  earned_period varchar(200) not null,
+  payment_period varchar(200) not null,
+  basic_salary_before bigint not null,
+  basic_salary_after bigint not null,
+  earning_difference bigint not null,
+  bpjs_employee_difference bigint not null,
+  bpjs_employer_difference bigint not null,
+  bpjs_items jsonb not null default '[]',
+  employer_premiums_difference bigint not null,
+  pension_difference bigint not null,
+  taxable_difference bigint not null,
+  tax_difference bigint not null,
+  employee_tax_difference bigint not null,
+  net_difference bigint not null,
+  reason text not null default '',
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint fk_user_id foreign key (user_id) references public.users (user_id) match simple on update cascade on delete restrict
+);
+
+create index if not exists retro_pays_user_payment_period_idx
+  on public.retro_pays (user_id, payment_period);
+
+insert into public.payroll_components (component_code, name, component_type, is_taxable, is_bpjs_base, is_one_off, is_system) values
+  ('RAPEL', 'Rapel Gaji', 'earning', true, false, true, true)
+on conflict (component_code) do nothing;
+
+commit;
@@ -0,0 +1,46 @@
+begin;
+
+-- effective dated salary of an employee, the payroll takes the basic salary
+-- and fixed allowances in force in a period from here
+create table if not exists public.employee_compensation (
+  compensation_id uuid primary key default uuid_generate_v4(),
+  user_id uuid not null,
+  effective_from date not null,
+  effective_to date,
+  basic_salary bigint not null,
+  allowances jsonb not null default '[]',
+  pay_grade varchar(50) not null default '',
+  reason text not null default '',
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint compensation_basic_salary_check check (basic_salary > 0),
+  constraint compensation_effective_check check (effective_to is null or effective_to >= effective_from),
+  constraint fk_user_id foreign key (user_id) references public.users (user_id) match simple on update cascade on delete restrict
+);
+
+create unique index if not exists employee_compensation_user_from_unique
+  on public.employee_compensation (user_id, effective_from) where is_delete = false;
+
+-- the latest regular payroll record of every employee starts the history
+insert into public.employee_compensation (user_id, effective_from, basic_salary, allowances, reason)
+select distinct on (p.user_id)
+  p.user_id,
+  coalesce(u.join_date, to_date(p.payment_period || '-01', 'YYYY-MM-DD')),
+  p.basic_salary,
+  coalesce((
+    select jsonb_agg(jsonb_build_object('component_code', i.component_code, 'amount', i.amount, 'note', i.note))
+    from public.payroll_items i
+      inner join public.payroll_components c on c.component_code = i.component_code
+    where i.payroll_id = p.payroll_id and i.component_type = 'earning' and i.is_one_off = false and c.is_system = false
+  ), '[]'),
+  'Carried over from the payroll record of ' || p.payment_period
+from public.payroll_records p
+  inner join public.users u on u.user_id = p.user_id
+  left join public.payroll_runs r on r.run_id = p.run_id
+where p.is_delete = false and p.record_type = 'regular' and coalesce(r.run_type, 'regular') = 'regular'
+order by p.user_id, p.payment_period desc, p.created_at desc
+on conflict do nothing;
+
+commit;
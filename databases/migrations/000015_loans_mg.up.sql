@@ -0,0 +1,36 @@
+begin;
+
+create table if not exists public.loans (
+  loan_id uuid primary key default uuid_generate_v4(),
+  user_id uuid not null,
+  loan_type varchar(20) not null default 'loan',
+  principal bigint not null,
+  installment_count int not null,
+  installment_amount bigint not null,
+  start_period varchar(7) not null,
+  reason text not null default '',
+  status varchar(20) not null default 'pending',
+  decided_at timestamp,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint loan_type_check check (loan_type in ('kasbon', 'loan')),
+  constraint loan_principal_check check (principal > 0),
+  constraint loan_installment_check check (installment_count > 0 and installment_amount > 0),
+  constraint loan_status_check check (status in ('pending', 'approved', 'rejected')),
+  constraint fk_user_id foreign key (user_id) references public.users (user_id) match simple on update cascade on delete restrict
+);
+
+create index if not exists loans_user_idx
+  on public.loans (user_id);
+
+-- installments are generated from approved loans by the calculation engine,
+-- the item points back to the loan so the repaid amount is the sum of them
+update public.payroll_components set is_system = true, updated_at = now()
+  where component_code = 'LOAN_INSTALLMENT';
+
+create index if not exists payroll_items_reference_idx
+  on public.payroll_items (reference_id);
+
+commit;
@@ -0,0 +1,76 @@
+begin;
+
+-- claims reuse the status table, they start pending and end rejected, or
+-- approved and paid once transferred
+insert into public.status (name) values
+  ('pending'),
+  ('rejected')
+on conflict (name) do nothing;
+
+create table if not exists public.claim_categories (
+  category_code varchar(50) primary key,
+  name varchar(200) not null,
+  yearly_limit bigint,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint claim_category_limit_check check (yearly_limit is null or yearly_limit > 0)
+);
+
+-- a null yearly_limit means the category has no plafon
+insert into public.claim_categories (category_code, name, yearly_limit) values
+  ('MEDICAL', 'Rawat Jalan', 5000000),
+  ('TRANSPORT', 'Transportasi', null),
+  ('BUSINESS_TRIP', 'Perjalanan Dinas', null)
+on conflict (category_code) do nothing;
+
+create table if not exists public.expense_claims (
+  claim_id uuid primary key default uuid_generate_v4(),
+  user_id uuid not null,
+  category_code varchar(50) not null,
+  claim_date date not null,
+  amount bigint not null,
+  description text not null default '',
+  payout varchar(20) not null default 'payroll',
+  status_id uuid not null,
+  payroll_period varchar(7),
+  decided_at timestamp,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint claim_amount_check check (amount > 0),
+  constraint claim_payout_check check (payout in ('payroll', 'transfer')),
+  constraint fk_user_id foreign key (user_id) references public.users (user_id) match simple on update cascade on delete restrict,
+  constraint fk_category_code foreign key (category_code) references public.claim_categories (category_code) match simple on update cascade on delete restrict,
+  constraint fk_status_id foreign key (status_id) references public.status (status_id) match simple on update cascade on delete restrict
+);
+
+create index if not exists expense_claims_user_date_idx
+  on public.expense_claims (user_id, claim_date);
+
+create index if not exists expense_claims_payroll_period_idx
+  on public.expense_claims (payroll_period);
+
+create table if not exists public.claim_receipts (
+  receipt_id uuid primary key default uuid_generate_v4(),
+  claim_id uuid not null,
+  filename varchar(255) not null,
+  content_type varchar(100) not null,
+  content bytea not null,
+  created_at timestamp default current_timestamp,
+
+  constraint fk_claim_id foreign key (claim_id) references public.expense_claims (claim_id) match simple on update cascade on delete cascade
+);
+
+create index if not exists claim_receipts_claim_idx
+  on public.claim_receipts (claim_id);
+
+-- approved claims paid through payroll are generated by the calculation
+-- engine, reimbursement is not income so it is neither taxed nor a BPJS base
+insert into public.payroll_components (component_code, name, component_type, is_taxable, is_bpjs_base, is_one_off, is_system) values
+  ('REIMBURSEMENT', 'Reimbursement', 'earning', false, false, true, true)
+on conflict (component_code) do nothing;
+
+commit;
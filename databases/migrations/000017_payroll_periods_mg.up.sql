@@ -0,0 +1,59 @@
+begin;
+
+create table if not exists public.payroll_periods (
+  period varchar(7) primary key,
+  start_date date not null,
+  end_date date not null,
+  cutoff_date date not null,
+  payment_date date not null,
+  status varchar(20) not null default 'open',
+  closed_at timestamp,
+  created_at timestamp default current_timestamp,
+  updated_at timestamp default current_timestamp,
+  is_delete boolean default false,
+
+  constraint payroll_period_format_check check (period ~ '^[0-9]{4}-[0-9]{2}$'),
+  constraint payroll_period_dates_check check (start_date <= end_date and cutoff_date between start_date and end_date),
+  constraint payroll_period_status_check check (status in ('open', 'closed'))
+);
+
+-- every close and reopen of a period, a closed period only takes writes
+-- again after a reopen by a privileged user is logged here
+create table if not exists public.payroll_period_logs (
+  log_id uuid primary key default uuid_generate_v4(),
+  period varchar(7) not null,
+  action varchar(20) not null,
+  user_id uuid,
+  reason text not null default '',
+  created_at timestamp default current_timestamp,
+
+  constraint payroll_period_log_action_check check (action in ('close', 'reopen')),
+  constraint fk_period foreign key (period) references public.payroll_periods (period) match simple on update cascade on delete restrict,
+  constraint fk_user_id foreign key (user_id) references public.users (user_id) match simple on update cascade on delete restrict
+);
+
+create index if not exists payroll_period_logs_period_idx
+  on public.payroll_period_logs (period, created_at);
+
+-- periods that already have payroll, those whose regular run is closed
+-- start closed
+insert into public.payroll_periods (period, start_date, end_date, cutoff_date, payment_date, status, closed_at)
+select
+  p.period,
+  to_date(p.period || '-01', 'YYYY-MM-DD'),
+  (to_date(p.period || '-01', 'YYYY-MM-DD') + interval '1 month - 1 day')::date,
+  (to_date(p.period || '-01', 'YYYY-MM-DD') + interval '1 month - 1 day')::date,
+  (to_date(p.period || '-01', 'YYYY-MM-DD') + interval '1 month - 1 day')::date,
+  case when r.status = 'closed' then 'closed' else 'open' end,
+  r.closed_at
+from (
+  select distinct payment_period as period from public.payroll_records
+    where payment_period ~ '^[0-9]{4}-[0-9]{2}$' and is_delete = false
+  union
+  select distinct payment_period from public.payroll_runs
+    where payment_period ~ '^[0-9]{4}-[0-9]{2}$' and is_delete = false
+) p
+  left join public.payroll_runs r on r.payment_period = p.period and r.run_type = 'regular' and r.is_delete = false
+on conflict (period) do nothing;
+
+commit;
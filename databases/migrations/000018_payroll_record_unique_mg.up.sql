@@ -0,0 +1,54 @@
+begin;
+
+-- duplicates left by the create endpoints before they checked for an
+-- existing record, the newest one of a user in a run is kept as long as the
+-- older ones were never approved
+update public.payroll_records p set is_delete = true, updated_at = now()
+from public.status s
+where s.status_id = p.status_id
+  and s.name in ('draft', 'reviewed')
+  and p.record_type = 'regular'
+  and p.is_delete = false
+  and exists (
+    select 1 from public.payroll_records n
+    where n.user_id = p.user_id
+      and n.payment_period = p.payment_period
+      and n.run_id is not distinct from p.run_id
+      and n.record_type = 'regular'
+      and n.is_delete = false
+      and n.created_at > p.created_at
+  );
+
+-- duplicates that were approved or paid are not deleted here, the money
+-- already went out. They have to be settled by hand, by a correction of the
+-- records paid twice, before deleting all but one of them and running the
+-- migration again.
+do $$
+declare
+  duplicates text;
+begin
+  select string_agg(format('user %s in %s, run %s: %s records', user_id, payment_period, coalesce(run_id::text, 'none'), total), E'\n')
+  into duplicates
+  from (
+    select user_id, payment_period, run_id, count(*) as total
+    from public.payroll_records
+    where record_type = 'regular' and is_delete = false
+    group by user_id, payment_period, run_id
+    having count(*) > 1
+  ) d;
+
+  if duplicates is not null then
+    raise exception E'payroll records paid more than once, settle them before migrating:\n%', duplicates;
+  end if;
+end $$;
+
+-- a user is paid once per run, and once per period outside of a run
+create unique index if not exists payroll_records_user_period_unique
+  on public.payroll_records (user_id, payment_period)
+  where record_type = 'regular' and is_delete = false and run_id is null;
+
+create unique index if not exists payroll_records_user_period_run_unique
+  on public.payroll_records (user_id, payment_period, run_id)
+  where record_type = 'regular' and is_delete = false and run_id is not null;
+
+commit;
@@ -0,0 +1,13 @@
+begin;
+
+-- overtime hours imported with a payroll input sheet are stored as approved
+-- overtime records of the sheet's payroll period, so a later recompute of
+-- the record still pays them and the next import of the period replaces them
+alter table public.overtime_records
+  add column if not exists import_period varchar(7);
+
+create index if not exists overtime_records_user_import_idx
+  on public.overtime_records (user_id, import_period)
+  where import_period is not null;
+
+commit;
@@ -0,0 +1,35 @@
+begin;
+
+-- period the salary of a record was earned in, the tax year it counts in.
+-- A reversal or adjustment is booked in a later period than the record it
+-- corrects but belongs to the same tax year.
+alter table public.payroll_records
+  add column if not exists earned_period varchar(7);
+
+update public.payroll_records set earned_period = payment_period
+where record_type = 'regular' and earned_period is null;
+
+-- corrections of corrections take the period from the end of the chain
+do $$
+begin
+  loop
+    update public.payroll_records p set earned_period = o.earned_period
+    from public.payroll_records o
+    where o.payroll_id = p.corrects_payroll_id
+      and o.earned_period is not null
+      and p.earned_period is null;
+    exit when not found;
+  end loop;
+end $$;
+
+update public.payroll_records set earned_period = payment_period
+where earned_period is null;
+
+alter table public.payroll_records
+  alter column earned_period set not null;
+
+create index if not exists payroll_records_user_earned_period_idx
+  on public.payroll_records (user_id, earned_period)
+  where is_delete = false;
+
+commit;
@@ -10,6 +10,7 @@ import (
 	"github.com/dafiqarba/be-payroll/repository"
 	"github.com/dafiqarba/be-payroll/router"
 	"github.com/dafiqarba/be-payroll/services"
+	"github.com/dafiqarba/be-payroll/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
 	"github.com/spf13/viper"
@@ -50,6 +51,17 @@ func main() {
 	secretKey := viper.GetString("JWT_SECRET")
 	customJwt := services.NewJWTService(secretKey)
 	timeoutCtx := time.Duration(viper.GetInt(`TIMEOUT_SECOND`)) * time.Second
+	companyName := viper.GetString(`COMPANY_NAME`)
+	mailer := utils.NewSMTPMailer(viper.GetString(`SMTP_HOST`), viper.GetInt(`SMTP_PORT`), viper.GetString(`SMTP_USERNAME`), viper.GetString(`SMTP_PASSWORD`), viper.GetString(`SMTP_FROM`))
+	payslipMaxAttempts := viper.GetInt(`PAYSLIP_MAX_ATTEMPTS`)
+	payslipRetryDelay := time.Duration(viper.GetInt(`PAYSLIP_RETRY_SECOND`)) * time.Second
+	companyCode := viper.GetString(`COMPANY_CODE`)
+	companyTin := viper.GetString(`COMPANY_NPWP`)
+	prorationMethod := viper.GetString(`PRORATION_METHOD`)
+	overtimeWorkDays := viper.GetInt(`OVERTIME_WORK_DAYS`)
+	companyBankAccount := viper.GetString(`COMPANY_BANK_ACCOUNT`)
+	varianceThreshold := viper.GetFloat64(`PAYROLL_VARIANCE_THRESHOLD`)
+	periodReopenRoles := viper.GetString(`PAYROLL_PERIOD_REOPEN_ROLES`)
 
 	repoLeaveBalance := repository.NewLeaveBalanceRepo(db)
 	repoLeaveRecord := repository.NewLeaveRecordRepo(db)
@@ -58,11 +70,42 @@ func main() {
 	repoPosition := repository.NewPositionRepo(db)
 	repoRole := repository.NewRoleRepo(db)
 	repoStatus := repository.NewStatusRepo(db)
+	repoBpjs := repository.NewBpjsRepo(db)
+	repoPayrollComponent := repository.NewPayrollComponentRepo(db)
+	repoPayrollItem := repository.NewPayrollItemRepo(db)
+	repoPayrollRun := repository.NewPayrollRunRepo(db)
+	repoPayrollPeriod := repository.NewPayrollPeriodRepo(db)
+	repoRetroPay := repository.NewRetroPayRepo(db)
+	repoCompensation := repository.NewCompensationRepo(db)
+	repoOvertime := repository.NewOvertimeRepo(db)
+	repoLoan := repository.NewLoanRepo(db)
+	repoClaim := repository.NewClaimRepo(db)
+	repoPayslipDelivery := repository.NewPayslipDeliveryRepo(db)
+	repoReport := repository.NewReportRepo(db)
 
 	serviceAuth := services.NewAuthService(repoUser, timeoutCtx, db)
 	serviceLeaveBalance := services.NewLeaveBalanceService(repoLeaveBalance, timeoutCtx, db)
-	serviceLeaveRecord := services.NewLeaveRecordService(repoLeaveRecord, timeoutCtx, db)
-	servicePayrollRecord := services.NewPayrollRecordService(repoPayrollRecord, timeoutCtx, db)
+	serviceLeaveRecord := services.NewLeaveRecordService(repoLeaveRecord, repoPayrollPeriod, timeoutCtx, db)
+	serviceOvertime := services.NewOvertimeService(repoOvertime, repoPayrollRun, overtimeWorkDays, timeoutCtx, db)
+	serviceLoan := services.NewLoanService(repoLoan, timeoutCtx, db)
+	serviceClaim := services.NewClaimService(repoClaim, repoStatus, repoPayrollRun, repoPayrollPeriod, timeoutCtx, db)
+	servicePph21 := services.NewPph21Service()
+	serviceBpjs := services.NewBpjsService(repoBpjs, timeoutCtx, db)
+	servicePayrollComponent := services.NewPayrollComponentService(repoPayrollComponent, timeoutCtx, db)
+	servicePayrollCalculation := services.NewPayrollCalculationService(repoUser, repoPayrollRecord, repoPayrollComponent, repoLeaveRecord, repoCompensation, repoOvertime, repoRetroPay, repoLoan, repoClaim, servicePph21, serviceBpjs, prorationMethod, overtimeWorkDays, timeoutCtx, db)
+	servicePayrollRecord := services.NewPayrollRecordService(repoPayrollRecord, repoBpjs, repoPayrollItem, repoPayrollRun, repoPayrollPeriod, repoStatus, repoOvertime, repoPayrollComponent, servicePayrollCalculation, timeoutCtx, db)
+	servicePayrollImport := services.NewPayrollImportService(servicePayrollRecord, repoUser, repoPayrollComponent, repoPayrollRun, repoPayrollPeriod, timeoutCtx)
+	servicePayrollPeriod := services.NewPayrollPeriodService(repoPayrollPeriod, repoUser, periodReopenRoles, timeoutCtx, db)
+	servicePayrollRun := services.NewPayrollRunService(repoPayrollRun, repoPayrollPeriod, repoPayrollRecord, repoPayrollItem, repoPayrollComponent, repoBpjs, repoUser, repoStatus, repoCompensation, servicePayrollCalculation, timeoutCtx, db)
+	servicePayslip := services.NewPayslipService(servicePayrollRecord, repoPayrollRecord, repoUser, companyName, timeoutCtx)
+	servicePayslipDistribution := services.NewPayslipDistributionService(repoPayslipDelivery, repoPayrollRecord, repoUser, servicePayslip, mailer, companyName, payslipMaxAttempts, payslipRetryDelay, timeoutCtx, db)
+	serviceTaxCertificate := services.NewTaxCertificateService(repoPayrollRecord, repoPayrollItem, repoUser, servicePph21, companyName, companyTin, timeoutCtx)
+	serviceReport := services.NewReportService(repoReport, varianceThreshold, timeoutCtx)
+	servicePayrollCorrection := services.NewPayrollCorrectionService(repoPayrollRecord, repoPayrollItem, repoPayrollRun, repoPayrollPeriod, repoBpjs, repoStatus, servicePayrollCalculation, timeoutCtx, db)
+	serviceRetroPay := services.NewRetroPayService(repoRetroPay, repoPayrollRecord, repoPayrollItem, repoPayrollRun, repoPayrollPeriod, repoPayrollComponent, repoBpjs, servicePayrollCalculation, timeoutCtx, db)
+	serviceCompensation := services.NewCompensationService(repoCompensation, repoPayrollComponent, repoPayrollRecord, serviceRetroPay, timeoutCtx, db)
+	serviceThr := services.NewThrService(repoPayrollRun, repoPayrollPeriod, repoPayrollRecord, repoPayrollItem, repoBpjs, repoUser, repoStatus, repoCompensation, servicePayrollCalculation, timeoutCtx, db)
+	serviceBankTransfer := services.NewBankTransferService(repoPayrollRecord, repoClaim, companyCode, companyBankAccount, timeoutCtx)
 	serviceUser := services.NewUserService(repoUser, timeoutCtx, db)
 	servicePosition := services.NewPositionService(repoPosition, timeoutCtx, db)
 	serviceRole := services.NewRoleService(repoRole, timeoutCtx, db)
@@ -71,11 +114,28 @@ func main() {
 	controllerAuth := controller.NewAuthController(serviceAuth, customJwt, serviceUser)
 	controllerLeaveBalance := controller.NewLeaveBalanceController(serviceLeaveBalance)
 	controllerLeaveRecord := controller.NewLeaveRecordController(serviceLeaveRecord)
+	controllerOvertime := controller.NewOvertimeController(serviceOvertime)
+	controllerLoan := controller.NewLoanController(serviceLoan)
+	controllerClaim := controller.NewClaimController(serviceClaim)
 	controllerPayrollRecord := controller.NewPayrollRecordController(servicePayrollRecord)
 	controllerUser := controller.NewUserController(serviceUser)
 	controllerPosition := controller.NewPositionController(servicePosition)
 	controllerRole := controller.NewRoleController(serviceRole)
 	controllerStatus := controller.NewStatusController(serviceStatus)
+	controllerBpjs := controller.NewBpjsController(serviceBpjs)
+	controllerPayrollImport := controller.NewPayrollImportController(servicePayrollImport)
+	controllerPayrollSimulation := controller.NewPayrollSimulationController(servicePayrollCalculation)
+	controllerPayrollComponent := controller.NewPayrollComponentController(servicePayrollComponent)
+	controllerPayrollRun := controller.NewPayrollRunController(servicePayrollRun)
+	controllerPayrollPeriod := controller.NewPayrollPeriodController(servicePayrollPeriod)
+	controllerPayrollCorrection := controller.NewPayrollCorrectionController(servicePayrollCorrection)
+	controllerRetroPay := controller.NewRetroPayController(serviceRetroPay)
+	controllerCompensation := controller.NewCompensationController(serviceCompensation)
+	controllerPayslip := controller.NewPayslipController(servicePayslip, servicePayslipDistribution)
+	controllerBankTransfer := controller.NewBankTransferController(serviceBankTransfer)
+	controllerThr := controller.NewThrController(serviceThr)
+	controllerTaxCertificate := controller.NewTaxCertificateController(serviceTaxCertificate)
+	controllerReport := controller.NewReportController(serviceReport)
 
 	mw := middleware.InitCustomMiddleware(customJwt)
 
@@ -91,6 +151,9 @@ func main() {
 
 	httpRouter.UserList(version, controllerUser)
 	httpRouter.UserDetail(version, controllerUser)
+	httpRouter.UserEmploymentUpdate(version, controllerUser)
+	httpRouter.CompensationList(version, controllerCompensation)
+	httpRouter.CompensationCreate(version, controllerCompensation)
 
 	httpRouter.Login(version, controllerAuth)
 	httpRouter.Register(version, controllerAuth)
@@ -101,11 +164,56 @@ func main() {
 	httpRouter.LeaveRecordDetail(version, controllerLeaveRecord)
 	httpRouter.LeaveRecordList(version, controllerLeaveRecord)
 
+	httpRouter.OvertimeList(version, controllerOvertime)
+	httpRouter.OvertimeCreate(version, controllerOvertime)
+	httpRouter.OvertimeDetail(version, controllerOvertime)
+	httpRouter.OvertimeStatusUpdate(version, controllerOvertime)
+
+	httpRouter.LoanList(version, controllerLoan)
+	httpRouter.LoanCreate(version, controllerLoan)
+	httpRouter.LoanDetail(version, controllerLoan)
+	httpRouter.LoanStatusUpdate(version, controllerLoan)
+
+	httpRouter.ClaimCategoryList(version, controllerClaim)
+	httpRouter.ClaimCategoryCreate(version, controllerClaim)
+	httpRouter.ClaimTransferExport(version, controllerBankTransfer)
+	httpRouter.ClaimList(version, controllerClaim)
+	httpRouter.ClaimCreate(version, controllerClaim)
+	httpRouter.ClaimDetail(version, controllerClaim)
+	httpRouter.ClaimStatusUpdate(version, controllerClaim)
+	httpRouter.ClaimReceiptCreate(version, controllerClaim)
+	httpRouter.ClaimReceiptDownload(version, controllerClaim)
+
 	httpRouter.PayrollCreate(version, controllerPayrollRecord)
 	httpRouter.PayrollCreateList(version, controllerPayrollRecord)
+	httpRouter.PayrollImport(version, controllerPayrollImport)
+	httpRouter.PayrollSimulate(version, controllerPayrollSimulation)
 	httpRouter.PayrollDetail(version, controllerPayrollRecord)
 	httpRouter.PayrollList(version, controllerPayrollRecord)
 	httpRouter.PayrollUpdate(version, controllerPayrollRecord)
+	httpRouter.PayrollComponentList(version, controllerPayrollComponent)
+	httpRouter.PayrollComponentCreate(version, controllerPayrollComponent)
+	httpRouter.PayrollRunList(version, controllerPayrollRun)
+	httpRouter.PayrollRunCreate(version, controllerPayrollRun)
+	httpRouter.PayrollRunDetail(version, controllerPayrollRun)
+	httpRouter.PayrollRunStatusUpdate(version, controllerPayrollRun)
+	httpRouter.PayrollPeriodList(version, controllerPayrollPeriod)
+	httpRouter.PayrollPeriodCreate(version, controllerPayrollPeriod)
+	httpRouter.PayrollPeriodDetail(version, controllerPayrollPeriod)
+	httpRouter.PayrollPeriodClose(version, mw.AuthorizeJWT(), controllerPayrollPeriod)
+	httpRouter.PayrollPeriodReopen(version, mw.AuthorizeJWT(), controllerPayrollPeriod)
+	httpRouter.PayrollCorrectionList(version, controllerPayrollCorrection)
+	httpRouter.PayrollCorrectionCreate(version, controllerPayrollCorrection)
+	httpRouter.RetroPayList(version, controllerRetroPay)
+	httpRouter.RetroPayCreate(version, controllerRetroPay)
+	httpRouter.PayslipArchive(version, controllerPayslip)
+	httpRouter.PayslipDownload(version, controllerPayslip)
+	httpRouter.PayslipDistribute(version, controllerPayslip)
+	httpRouter.PayslipDeliveryList(version, controllerPayslip)
+	httpRouter.BankTransferFormatList(version, controllerBankTransfer)
+	httpRouter.BankTransferExport(version, controllerBankTransfer)
+	httpRouter.ThrEntitlementList(version, controllerThr)
+	httpRouter.ThrRunCreate(version, controllerThr)
 
 	httpRouter.PositionList(version, controllerPosition)
 	httpRouter.PositionCreate(version, controllerPosition)
@@ -125,6 +233,18 @@ func main() {
 	httpRouter.StatusDelete(version, controllerStatus)
 	httpRouter.StatusDetail(version, controllerStatus)
 
+	httpRouter.BpjsProgramList(version, controllerBpjs)
+	httpRouter.BpjsProgramUpdate(version, controllerBpjs)
+
+	httpRouter.TaxCertificateList(version, controllerTaxCertificate)
+	httpRouter.TaxCertificateArchive(version, controllerTaxCertificate)
+	httpRouter.TaxCertificateDetail(version, controllerTaxCertificate)
+	httpRouter.TaxCertificatePDF(version, controllerTaxCertificate)
+
+	httpRouter.PayrollCostSummary(version, controllerReport)
+	httpRouter.PayrollCostExport(version, controllerReport)
+	httpRouter.PayrollVariance(version, controllerReport)
+
 	// data, _ := json.MarshalIndent(httpRouter.App().GetRoutes(true), "", "  ")
 	// log.Println("routes: ", string(data))
 	// log.Println("port: ", appPort)
@@ -12,8 +12,12 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
+// Key of the authenticated user's id in the request locals, set by AuthorizeJWT
+const LocalsUserID = "user_id"
+
 type CustomMiddleware interface {
 	LoggerMiddleware() fiber.Handler
 	CORSMiddleware() fiber.Handler
@@ -74,7 +78,7 @@ func (m *customMiddleware) CORSMiddleware() fiber.Handler {
 			fiber.MethodPatch,
 		}, ","),
 		AllowOrigins:     "*",
-		AllowHeaders:     "Content-Type,Content-Length",
+		AllowHeaders:     "Content-Type,Content-Length,Authorization",
 		AllowCredentials: false,
 	})
 	// c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
@@ -91,7 +95,8 @@ func (m *customMiddleware) CORSMiddleware() fiber.Handler {
 
 }
 
-// AuthorizeJWT function validate the token user given, return 401 if not valid
+// AuthorizeJWT function validate the token user given, return 401 if not valid.
+// The user id of a valid token is passed on in the locals under LocalsUserID.
 func (m *customMiddleware) AuthorizeJWT() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
@@ -113,7 +118,18 @@ func (m *customMiddleware) AuthorizeJWT() fiber.Handler {
 		}
 		claims := token.Claims.(jwt.MapClaims)
 		log.Println("| claims: ", claims)
-		return nil
+		userID, ok := claims["user_id"].(string)
+		if !ok {
+			utils.BuildErrorResponse(c, http.StatusUnauthorized, "invalid token")
+			return nil
+		}
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			utils.BuildErrorResponse(c, http.StatusUnauthorized, "invalid token")
+			return nil
+		}
+		c.Locals(LocalsUserID, id)
+		return c.Next()
 	}
 }
 
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Salary account of an employee
+type BankAccount struct {
+	Bank_code           string `json:"bank_code"`
+	Bank_account_number string `json:"bank_account_number"`
+	Bank_account_name   string `json:"bank_account_name"`
+}
+
+// One credit line of a bulk transfer, the net salary of a payroll record
+type BankTransferLine struct {
+	Payroll_id uuid.UUID `json:"payroll_id"`
+	User_id    uuid.UUID `json:"user_id"`
+	Name       string    `json:"name"`
+	Nik        string    `json:"nik"`
+	Email      string    `json:"email"`
+	BankAccount
+	Amount int `json:"amount"`
+}
+
+// A bulk transfer of a period debited from the company account
+type BankTransferBatch struct {
+	Company_code   string
+	Debit_account  string
+	Payment_period string
+	Transfer_date  time.Time
+	Remark         string
+	Lines          []BankTransferLine
+	Total_amount   int
+}
+
+// Employee left out of an export because of invalid bank data
+type BankTransferError struct {
+	Payroll_id uuid.UUID `json:"payroll_id"`
+	User_id    uuid.UUID `json:"user_id"`
+	Name       string    `json:"name"`
+	Nik        string    `json:"nik"`
+	Message    string    `json:"message"`
+}
+
+type BankTransferFormatModel struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BPJS program codes
+const (
+	BpjsJht       = "JHT"
+	BpjsJp        = "JP"
+	BpjsJkk       = "JKK"
+	BpjsJkm       = "JKM"
+	BpjsKesehatan = "KES"
+)
+
+// Represents bpjs_programs table, the contribution rates of a program.
+// JKK employer rate comes from bpjs_jkk_rates by the position's risk class.
+type BpjsProgram struct {
+	Program_code  string    `json:"program_code"`
+	Name          string    `json:"name"`
+	Employer_rate float64   `json:"employer_rate"`
+	Employee_rate float64   `json:"employee_rate"`
+	Wage_cap      int       `json:"wage_cap"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Represents bpjs_jkk_rates table
+type BpjsJkkRate struct {
+	Risk_class int     `json:"risk_class"`
+	Rate       float64 `json:"rate"`
+}
+
+// Represents payroll_bpjs_items table, one line per program of a payroll record
+type BpjsItem struct {
+	Item_id         uuid.UUID `json:"item_id"`
+	Payroll_id      uuid.UUID `json:"payroll_id"`
+	Program_code    string    `json:"program_code"`
+	Base_wage       int       `json:"base_wage"`
+	Employer_rate   float64   `json:"employer_rate"`
+	Employee_rate   float64   `json:"employee_rate"`
+	Employer_amount int       `json:"employer_amount"`
+	Employee_amount int       `json:"employee_amount"`
+}
+
+// Result of the BPJS calculator for a single month
+type BpjsBreakdown struct {
+	Items          []BpjsItem `json:"items"`
+	Employer_total int        `json:"employer_total"`
+	Employee_total int        `json:"employee_total"`
+	// Employer paid JKK, JKM and Kesehatan premiums, taxable for PPh 21
+	Employer_taxable int `json:"employer_taxable"`
+	// Employee paid JHT and JP, deductible for PPh 21
+	Employee_pension int `json:"employee_pension"`
+}
+
+type UpdateBpjsProgramModel struct {
+	Employer_rate float64 `json:"employer_rate"`
+	Employee_rate float64 `json:"employee_rate"`
+	Wage_cap      int     `json:"wage_cap"`
+}
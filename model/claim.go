@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How an approved claim is paid out
+const (
+	ClaimPayoutPayroll  = "payroll"
+	ClaimPayoutTransfer = "transfer"
+)
+
+// Claim statuses, names of the status table
+const (
+	ClaimPending  = "pending"
+	ClaimApproved = "approved"
+	ClaimRejected = "rejected"
+	ClaimPaid     = "paid"
+)
+
+// Represents claim_categories table. A nil Yearly_limit means the category
+// has no plafon.
+type ClaimCategory struct {
+	Category_code string    `json:"category_code"`
+	Name          string    `json:"name"`
+	Yearly_limit  *int      `json:"yearly_limit"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CreateClaimCategoryModel struct {
+	Category_code string `json:"category_code"`
+	Name          string `json:"name"`
+	Yearly_limit  *int   `json:"yearly_limit"`
+}
+
+// View model for expense_claims, claim_categories and status table.
+// Payroll_period is the run an approved payroll claim is paid with.
+type Claim struct {
+	Claim_id       uuid.UUID      `json:"claim_id"`
+	User_id        uuid.UUID      `json:"user_id"`
+	Name           string         `json:"name"`
+	Category_code  string         `json:"category_code"`
+	Category_name  string         `json:"category_name"`
+	Claim_date     time.Time      `json:"claim_date"`
+	Amount         int            `json:"amount"`
+	Description    string         `json:"description"`
+	Payout         string         `json:"payout"`
+	Status_id      uuid.UUID      `json:"status_id"`
+	Status         string         `json:"status"`
+	Payroll_period string         `json:"payroll_period"`
+	Decided_at     *time.Time     `json:"decided_at"`
+	Receipts       []ClaimReceipt `json:"receipts,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Represents claim_receipts table, the content is only loaded on download
+type ClaimReceipt struct {
+	Receipt_id   uuid.UUID `json:"receipt_id"`
+	Claim_id     uuid.UUID `json:"claim_id"`
+	Filename     string    `json:"filename"`
+	Content_type string    `json:"content_type"`
+	Size         int       `json:"size"`
+	Content      []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Payout defaults to payroll
+type CreateClaimModel struct {
+	User_id       uuid.UUID `json:"user_id"`
+	Category_code string    `json:"category_code"`
+	Claim_date    string    `json:"claim_date"`
+	Amount        int       `json:"amount"`
+	Description   string    `json:"description"`
+	Payout        string    `json:"payout"`
+}
+
+type UpdateClaimStatusModel struct {
+	Status string `json:"status"`
+}
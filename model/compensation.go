@@ -0,0 +1,67 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Represents employee_compensation table, the salary of a user from
+// Effective_from until the next change. Effective_to is nil on the change
+// in force today.
+type Compensation struct {
+	Compensation_id uuid.UUID         `json:"compensation_id"`
+	User_id         uuid.UUID         `json:"user_id"`
+	Effective_from  time.Time         `json:"effective_from"`
+	Effective_to    *time.Time        `json:"effective_to"`
+	Basic_salary    int               `json:"basic_salary"`
+	Allowances      CompensationItems `json:"allowances"`
+	Pay_grade       string            `json:"pay_grade"`
+	Reason          string            `json:"reason"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// Fixed monthly allowances of a compensation, stored as jsonb
+type CompensationItems []PayrollItemInput
+
+// Value stores the allowances as jsonb
+func (items CompensationItems) Value() (driver.Value, error) {
+	if items == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]PayrollItemInput(items))
+}
+
+// Scan reads the allowances from a jsonb column
+func (items *CompensationItems) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]PayrollItemInput)(items))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]PayrollItemInput)(items))
+	}
+	return errors.New("compensation items: unsupported scan type")
+}
+
+type CreateCompensationModel struct {
+	Effective_from string             `json:"effective_from"`
+	Basic_salary   int                `json:"basic_salary"`
+	Allowances     []PayrollItemInput `json:"allowances"`
+	Pay_grade      string             `json:"pay_grade"`
+	Reason         string             `json:"reason"`
+}
+
+type CompensationResult struct {
+	Compensation_id uuid.UUID `json:"compensation_id"`
+	// Rapel of the paid periods the change reaches back into
+	Retro *RetroPayResult `json:"retro,omitempty"`
+	// Set when the rapel could not be booked yet, it can be retried through
+	// the retro pay endpoint
+	Retro_error string `json:"retro_error,omitempty"`
+}
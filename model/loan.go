@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Loan types. A kasbon is a cash advance on the salary, usually repaid in
+// one installment.
+const (
+	LoanKasbon = "kasbon"
+	LoanLoan   = "loan"
+)
+
+// Loan request statuses
+const (
+	LoanPending  = "pending"
+	LoanApproved = "approved"
+	LoanRejected = "rejected"
+)
+
+// Represents loans table. Repaid sums the installments of approved, paid
+// and closed payroll records.
+type Loan struct {
+	Loan_id            uuid.UUID  `json:"loan_id"`
+	User_id            uuid.UUID  `json:"user_id"`
+	Name               string     `json:"name"`
+	Loan_type          string     `json:"loan_type"`
+	Principal          int        `json:"principal"`
+	Installment_count  int        `json:"installment_count"`
+	Installment_amount int        `json:"installment_amount"`
+	Start_period       string     `json:"start_period"`
+	Reason             string     `json:"reason"`
+	Status             string     `json:"status"`
+	Repaid             int        `json:"repaid"`
+	Remaining_balance  int        `json:"remaining_balance"`
+	Decided_at         *time.Time `json:"decided_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// Installment_count defaults to 1 for a kasbon. Start_period is the first
+// payroll period the installment is deducted in.
+type CreateLoanModel struct {
+	User_id           uuid.UUID `json:"user_id"`
+	Loan_type         string    `json:"loan_type"`
+	Principal         int       `json:"principal"`
+	Installment_count int       `json:"installment_count"`
+	Start_period      string    `json:"start_period"`
+	Reason            string    `json:"reason"`
+}
+
+type UpdateLoanStatusModel struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Overtime day types, they pick the Kepmenaker 102/2004 multiplier scale
+const (
+	OvertimeWorkday       = "workday"
+	OvertimeRestDay       = "rest_day"
+	OvertimePublicHoliday = "public_holiday"
+)
+
+// Overtime request statuses
+const (
+	OvertimePending  = "pending"
+	OvertimeApproved = "approved"
+	OvertimeRejected = "rejected"
+)
+
+// Represents overtime_records table
+type OvertimeRecord struct {
+	Overtime_id   uuid.UUID  `json:"overtime_id"`
+	User_id       uuid.UUID  `json:"user_id"`
+	Name          string     `json:"name"`
+	Overtime_date time.Time  `json:"overtime_date"`
+	Hours         float64    `json:"hours"`
+	Day_type      string     `json:"day_type"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	Decided_at    *time.Time `json:"decided_at"`
+	// Payroll period of the input sheet the hours were imported with, empty
+	// for overtime requests
+	Import_period string    `json:"import_period"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Day_type may be left empty, weekends then count as rest days. Public
+// holidays have to be given explicitly.
+type CreateOvertimeRecordModel struct {
+	User_id       uuid.UUID `json:"user_id"`
+	Overtime_date string    `json:"overtime_date"`
+	Hours         float64   `json:"hours"`
+	Day_type      string    `json:"day_type"`
+	Reason        string    `json:"reason"`
+}
+
+type UpdateOvertimeStatusModel struct {
+	Status string `json:"status"`
+}
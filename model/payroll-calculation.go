@@ -0,0 +1,51 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Input of the payroll calculation engine
+type PayrollCalculationInput struct {
+	User_id        uuid.UUID          `json:"user_id"`
+	Payment_period string             `json:"payment_period"`
+	Items          []PayrollItemInput `json:"items"`
+	Tax_method     string             `json:"tax_method"`
+	// Rapel being booked in the period that is not stored yet
+	Retro_pays []RetroPay `json:"-"`
+	// Overtime of a payroll input sheet that is not stored yet. When set it
+	// replaces the overtime imported with an earlier sheet of the period.
+	Overtime []OvertimeRecord `json:"-"`
+}
+
+// Input of an irregular payment such as THR, paid in its own run
+type PayrollIrregularInput struct {
+	User_id        uuid.UUID `json:"user_id"`
+	Payment_period string    `json:"payment_period"`
+	Component_code string    `json:"component_code"`
+	Amount         int       `json:"amount"`
+	Note           string    `json:"note"`
+	Tax_method     string    `json:"tax_method"`
+	// Estimate of the month's regular taxable income, used when the regular
+	// record of the period does not exist yet
+	Regular_income int `json:"regular_income"`
+}
+
+// Result of the payroll calculation engine, every amount is computed server-side
+type PayrollCalculationResult struct {
+	User_id        uuid.UUID      `json:"user_id"`
+	Payment_period string         `json:"payment_period"`
+	Basic_salary   int            `json:"basic_salary"`
+	Proration      Proration      `json:"proration"`
+	Allowance      int            `json:"allowance"`
+	Gross_salary   int            `json:"gross_salary"`
+	Bpjs           int            `json:"bpjs"`
+	Bpjs_employer  int            `json:"bpjs_employer"`
+	Bpjs_detail    BpjsBreakdown  `json:"bpjs_detail"`
+	Tax_allowance  int            `json:"tax_allowance"`
+	Tax            int            `json:"tax"`
+	Tax_method     string         `json:"tax_method"`
+	Tax_detail     Pph21Breakdown `json:"tax_detail"`
+	Deduction      int            `json:"deduction"`
+	Total_salary   int            `json:"total_salary"`
+	Items          []PayrollItem  `json:"items"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import "github.com/google/uuid"
+
+// Spreadsheet of monthly payroll inputs, one row per employee keyed by NIK.
+// Payment_date defaults to the payment date of the payroll period.
+type PayrollImportModel struct {
+	Payment_period string `json:"payment_period"`
+	Payment_date   string `json:"payment_date"`
+	Filename       string `json:"filename"`
+}
+
+// Preview or outcome of an import. Rows and Errors refer to the spreadsheet
+// row numbers, the header being row 1. Nothing is saved while Errors is not
+// empty.
+type PayrollImportResult struct {
+	Dry_run        bool               `json:"dry_run"`
+	Payment_period string             `json:"payment_period"`
+	Payment_date   string             `json:"payment_date"`
+	Columns        []string           `json:"columns"`
+	Rows           []PayrollImportRow `json:"rows"`
+	Errors         []PayrollImportErr `json:"errors"`
+}
+
+type PayrollImportRow struct {
+	Row        int                `json:"row"`
+	Nik        string             `json:"nik"`
+	Name       string             `json:"name"`
+	User_id    uuid.UUID          `json:"user_id"`
+	Items      []PayrollItemInput `json:"items"`
+	Tax_method string             `json:"tax_method"`
+	// Paid as workday overtime when the sheet has an overtime_hours column
+	Overtime_hours float64    `json:"overtime_hours"`
+	Total_salary   int        `json:"total_salary"`
+	Payroll_id     *uuid.UUID `json:"payroll_id"`
+	// Record already in the period, recomputed with the sheet's inputs
+	Updated bool `json:"updated"`
+}
+
+type PayrollImportErr struct {
+	Row    int    `json:"row"`
+	Nik    string `json:"nik"`
+	Column string `json:"column"`
+	Error  string `json:"error"`
+}
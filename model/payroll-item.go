@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payroll component types
+const (
+	ComponentEarning   = "earning"
+	ComponentDeduction = "deduction"
+)
+
+// Payroll component codes seeded by the migrations
+const (
+	ComponentBasicSalary    = "BASIC"
+	ComponentFixedAllowance = "FIXED_ALLOWANCE"
+	ComponentTransport      = "TRANSPORT"
+	ComponentMeal           = "MEAL"
+	ComponentBonus          = "BONUS"
+	ComponentOtherDeduction = "OTHER_DEDUCTION"
+	// System components are generated by the calculation engine only
+	ComponentOvertime        = "OVERTIME"
+	ComponentUnpaidLeave     = "UNPAID_LEAVE"
+	ComponentLoanInstallment = "LOAN_INSTALLMENT"
+	ComponentThr             = "THR"
+	ComponentRapel           = "RAPEL"
+	ComponentReimbursement   = "REIMBURSEMENT"
+	ComponentTaxAllowance    = "TAX_ALLOWANCE"
+	ComponentBpjsEmployee    = "BPJS_EMPLOYEE"
+	ComponentPph21           = "PPH21"
+)
+
+// Represents payroll_components table
+type PayrollComponent struct {
+	Component_code string    `json:"component_code"`
+	Name           string    `json:"name"`
+	Component_type string    `json:"component_type"`
+	Is_taxable     bool      `json:"is_taxable"`
+	Is_bpjs_base   bool      `json:"is_bpjs_base"`
+	Is_one_off     bool      `json:"is_one_off"`
+	Is_system      bool      `json:"is_system"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Is_delete      bool      `json:"is_delete"`
+}
+
+// Represents payroll_items table, the component flags are copied at
+// calculation time so later component changes do not rewrite history
+type PayrollItem struct {
+	Item_id        uuid.UUID `json:"item_id"`
+	Payroll_id     uuid.UUID `json:"payroll_id"`
+	Component_code string    `json:"component_code"`
+	Name           string    `json:"name"`
+	Component_type string    `json:"component_type"`
+	Amount         int       `json:"amount"`
+	Is_taxable     bool      `json:"is_taxable"`
+	Is_bpjs_base   bool      `json:"is_bpjs_base"`
+	Is_one_off     bool      `json:"is_one_off"`
+	Note           string    `json:"note"`
+	// Source of a generated item, e.g. the leave request of a leave deduction
+	Reference_id uuid.NullUUID `json:"reference_id"`
+}
+
+// Client input for a single earning or deduction
+type PayrollItemInput struct {
+	Component_code string `json:"component_code"`
+	Amount         int    `json:"amount"`
+	Note           string `json:"note"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payroll period statuses, records and leave dated in a closed period can
+// no longer be written
+const (
+	PayrollPeriodOpen   = "open"
+	PayrollPeriodClosed = "closed"
+)
+
+// Actions logged on a payroll period
+const (
+	PayrollPeriodActionClose  = "close"
+	PayrollPeriodActionReopen = "reopen"
+)
+
+// Represents payroll_periods table. Cutoff_date is the last day whose
+// attendance, leave and overtime are paid in the period.
+type PayrollPeriod struct {
+	Period       string     `json:"period"`
+	Start_date   time.Time  `json:"start_date"`
+	End_date     time.Time  `json:"end_date"`
+	Cutoff_date  time.Time  `json:"cutoff_date"`
+	Payment_date time.Time  `json:"payment_date"`
+	Status       string     `json:"status"`
+	Closed_at    *time.Time `json:"closed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Represents payroll_period_logs table
+type PayrollPeriodLog struct {
+	Log_id    uuid.UUID     `json:"log_id"`
+	Period    string        `json:"period"`
+	Action    string        `json:"action"`
+	User_id   uuid.NullUUID `json:"user_id"`
+	Name      string        `json:"name"`
+	Reason    string        `json:"reason"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type PayrollPeriodDetailModel struct {
+	PayrollPeriod
+	Logs []PayrollPeriodLog `json:"logs"`
+}
+
+// Dates default to the calendar month, paid on its last day
+type CreatePayrollPeriodModel struct {
+	Period       string `json:"period"`
+	Start_date   string `json:"start_date"`
+	End_date     string `json:"end_date"`
+	Cutoff_date  string `json:"cutoff_date"`
+	Payment_date string `json:"payment_date"`
+}
+
+// User_id is the user closing or reopening the period, taken from the
+// caller's token. A reopen needs a privileged role and a reason.
+type UpdatePayrollPeriodStatusModel struct {
+	User_id uuid.UUID `json:"user_id"`
+	Reason  string    `json:"reason"`
+}
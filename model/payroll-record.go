@@ -6,31 +6,64 @@ import (
 	"github.com/google/uuid"
 )
 
+// Payroll record types. A locked record is corrected by a reversal that
+// cancels it and an adjustment with the corrected figures, both booked in
+// the next open period.
+const (
+	PayrollRecordRegular    = "regular"
+	PayrollRecordReversal   = "reversal"
+	PayrollRecordAdjustment = "adjustment"
+)
+
 type PayrollRecord struct {
-	Payroll_id     uuid.UUID `json:"payroll_id"`
-	Payment_period string    `json:"payment_period"`
-	Payment_date   time.Time `json:"payment_date"`
-	Basic_salary   int       `json:"basic_salary"`
-	Bpjs           int       `json:"bpjs"`
-	Tax            int       `json:"tax"`
-	Total_salary   int       `json:"total_salary"`
-	Status_id      uuid.UUID `json:"status_id"`
-	User_id        uuid.UUID `json:"user_id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Is_delete      bool      `json:"is_delete"`
+	Payroll_id     uuid.UUID      `json:"payroll_id"`
+	Payment_period string         `json:"payment_period"`
+	Payment_date   time.Time      `json:"payment_date"`
+	Basic_salary   int            `json:"basic_salary"`
+	Proration      Proration      `json:"proration"`
+	Allowance      int            `json:"allowance"`
+	Bpjs           int            `json:"bpjs"`
+	Tax            int            `json:"tax"`
+	Tax_method     string         `json:"tax_method"`
+	Tax_detail     Pph21Breakdown `json:"tax_detail"`
+	Total_salary   int            `json:"total_salary"`
+	Status_id      uuid.UUID      `json:"status_id"`
+	User_id        uuid.UUID      `json:"user_id"`
+	Run_id         uuid.NullUUID  `json:"run_id"`
+	Record_type    string         `json:"record_type"`
+	// Record reversed or superseded by a reversal or adjustment
+	Corrects_payroll_id uuid.NullUUID `json:"corrects_payroll_id"`
+	Correction_reason   string        `json:"correction_reason"`
+	// Period the salary was earned in, the tax year the record counts in.
+	// It is the payment period but for corrections booked later.
+	Earned_period string    `json:"earned_period"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Is_delete     bool      `json:"is_delete"`
 }
 
 type PayrollRecordDetailModel struct {
-	Payroll_id     uuid.UUID `json:"payroll_id"`
-	Name           string    `json:"name"`
-	Payment_period string    `json:"payment_period"`
-	Payment_date   time.Time `json:"payment_date"`
-	Basic_salary   int       `json:"basic_salary"`
-	Bpjs           int       `json:"bpjs"`
-	Tax            int       `json:"tax"`
-	Total_salary   int       `json:"total_salary"`
-	Status_name    string    `json:"status_name"`
+	Payroll_id     uuid.UUID      `json:"payroll_id"`
+	User_id        uuid.UUID      `json:"user_id"`
+	Name           string         `json:"name"`
+	Payment_period string         `json:"payment_period"`
+	Payment_date   time.Time      `json:"payment_date"`
+	Basic_salary   int            `json:"basic_salary"`
+	Proration      Proration      `json:"proration"`
+	Allowance      int            `json:"allowance"`
+	Bpjs           int            `json:"bpjs"`
+	Tax            int            `json:"tax"`
+	Tax_method     string         `json:"tax_method"`
+	Tax_detail     Pph21Breakdown `json:"tax_detail"`
+	Total_salary   int            `json:"total_salary"`
+	Status_name    string         `json:"status_name"`
+	Run_id         uuid.NullUUID  `json:"run_id"`
+	Record_type    string         `json:"record_type"`
+	// Record reversed or superseded by a reversal or adjustment
+	Corrects_payroll_id uuid.NullUUID `json:"corrects_payroll_id"`
+	Correction_reason   string        `json:"correction_reason"`
+	Bpjs_items          []BpjsItem    `json:"bpjs_items"`
+	Items               []PayrollItem `json:"items"`
 }
 
 type PayrollRecordListModel struct {
@@ -39,26 +72,109 @@ type PayrollRecordListModel struct {
 	Payment_period string    `json:"payment_period"`
 	Payment_date   string    `json:"payment_date"`
 	Status_name    string    `json:"status"`
+	Record_type    string    `json:"record_type"`
 }
 
+// Reversal or adjustment shown on the payslip it is paid with.
+// Corrected_period is the period of the record it corrects.
+type PayslipCorrection struct {
+	Payroll_id        uuid.UUID `json:"payroll_id"`
+	Record_type       string    `json:"record_type"`
+	Corrected_period  string    `json:"corrected_period"`
+	Correction_reason string    `json:"correction_reason"`
+	Total_salary      int       `json:"total_salary"`
+}
+
+// Query of the payroll list. Every filter is optional, periods are YYYY-MM
+// and dates YYYY-MM-DD, both ranges inclusive.
+type PayrollRecordFilter struct {
+	Period_from       string
+	Period_to         string
+	User_id           uuid.NullUUID
+	Position_id       uuid.NullUUID
+	Status            string
+	Payment_date_from string
+	Payment_date_to   string
+	Search            string
+	Sort              string
+	Order             string
+	Page              int
+	Page_size         int
+}
+
+type PayrollRecordPage struct {
+	Items       []PayrollRecordListModel `json:"items"`
+	Page        int                      `json:"page"`
+	Page_size   int                      `json:"page_size"`
+	Total       int                      `json:"total"`
+	Total_pages int                      `json:"total_pages"`
+}
+
+// Client input for a payroll record, salary figures are computed server-side
+// and the basic salary comes from the compensation in force. A new record is
+// always a draft, Status_id is only there to refuse clients still sending it.
 type CreatePayrollRecordModel struct {
-	Payment_period string    `json:"payment_period"`
-	Payment_date   string    `json:"payment_date"`
-	Basic_salary   int       `json:"basic_salary"`
-	Bpjs           int       `json:"bpjs"`
-	Tax            int       `json:"tax"`
-	Total_salary   int       `json:"total_salary"`
-	Status_id      uuid.UUID `json:"status_id"`
-	User_id        uuid.UUID `json:"user_id"`
+	Payment_period string             `json:"payment_period"`
+	Payment_date   string             `json:"payment_date"`
+	Items          []PayrollItemInput `json:"items"`
+	Tax_method     string             `json:"tax_method"`
+	Status_id      uuid.UUID          `json:"status_id"`
+	User_id        uuid.UUID          `json:"user_id"`
+	// Overtime imported with a payroll input sheet, nil keeps the overtime
+	// of an earlier import of the period
+	Overtime []OvertimeRecord `json:"-"`
+}
+
+// Outcome of a bulk payroll creation. The batch is saved only when every
+// row is valid, otherwise Errors names each failed row by its index in the
+// request and nothing is written. A dry run validates and computes without
+// saving.
+type PayrollRecordBatchResult struct {
+	Dry_run bool                    `json:"dry_run"`
+	Rows    []PayrollRecordBatchRow `json:"rows"`
+	Errors  []PayrollRecordBatchErr `json:"errors"`
+}
+
+type PayrollRecordBatchRow struct {
+	Index          int        `json:"index"`
+	User_id        uuid.UUID  `json:"user_id"`
+	Payment_period string     `json:"payment_period"`
+	Total_salary   int        `json:"total_salary"`
+	Payroll_id     *uuid.UUID `json:"payroll_id"`
+	// Existing record of the period recomputed rather than a new one
+	Updated bool `json:"updated"`
+}
+
+type PayrollRecordBatchErr struct {
+	Index   int       `json:"index"`
+	User_id uuid.UUID `json:"user_id"`
+	Error   string    `json:"error"`
 }
 
+// Status_id is ignored, a record's status follows its payroll run
 type UpdatePayrollRecordModel struct {
+	Payment_period string             `json:"payment_period"`
+	Payment_date   string             `json:"payment_date"`
+	Items          []PayrollItemInput `json:"items"`
+	Tax_method     string             `json:"tax_method"`
+	Status_id      uuid.UUID          `json:"status_id"`
+	User_id        uuid.UUID          `json:"user_id"`
+}
+
+// Corrected figures of a locked payroll record, computed for the period the
+// record was earned in with the compensation in force then
+type CreatePayrollCorrectionModel struct {
+	Items        []PayrollItemInput `json:"items"`
+	Tax_method   string             `json:"tax_method"`
+	Payment_date string             `json:"payment_date"`
+	Reason       string             `json:"reason"`
+}
+
+type PayrollCorrectionResult struct {
+	Payroll_id     uuid.UUID `json:"payroll_id"`
+	Reversal_id    uuid.UUID `json:"reversal_id"`
+	Adjustment_id  uuid.UUID `json:"adjustment_id"`
 	Payment_period string    `json:"payment_period"`
-	Payment_date   string    `json:"payment_date"`
-	Basic_salary   int       `json:"basic_salary"`
-	Bpjs           int       `json:"bpjs"`
-	Tax            int       `json:"tax"`
-	Total_salary   int       `json:"total_salary"`
-	Status_id      uuid.UUID `json:"status_id"`
-	User_id        uuid.UUID `json:"user_id"`
+	// Net salary still owed to the employee, negative when overpaid
+	Difference int `json:"difference"`
 }
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payroll run statuses, a run only moves along the transitions of the
+// payroll run state machine
+const (
+	PayrollRunDraft    = "draft"
+	PayrollRunReviewed = "reviewed"
+	PayrollRunApproved = "approved"
+	PayrollRunPaid     = "paid"
+	PayrollRunClosed   = "closed"
+)
+
+// Payroll run types
+const (
+	PayrollRunRegular = "regular"
+	// Religious holiday allowance, paid outside the regular payroll
+	PayrollRunThr = "thr"
+)
+
+// Represents payroll_runs table, a batch of payroll records for a period
+type PayrollRun struct {
+	Run_id         uuid.UUID  `json:"run_id"`
+	Payment_period string     `json:"payment_period"`
+	Payment_date   time.Time  `json:"payment_date"`
+	Run_type       string     `json:"run_type"`
+	Status         string     `json:"status"`
+	Holiday_name   string     `json:"holiday_name,omitempty"`
+	Holiday_date   *time.Time `json:"holiday_date,omitempty"`
+	Reviewed_at    *time.Time `json:"reviewed_at"`
+	Approved_at    *time.Time `json:"approved_at"`
+	Paid_at        *time.Time `json:"paid_at"`
+	Closed_at      *time.Time `json:"closed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Is_delete      bool       `json:"is_delete"`
+}
+
+type PayrollRunDetailModel struct {
+	PayrollRun
+	Records []PayrollRecordListModel `json:"records"`
+}
+
+type CreatePayrollRunModel struct {
+	Payment_period string `json:"payment_period"`
+	Payment_date   string `json:"payment_date"`
+	Tax_method     string `json:"tax_method"`
+}
+
+type UpdatePayrollRunStatusModel struct {
+	Status string `json:"status"`
+}
+
+// Employee left out of a generated run and why
+type PayrollRunSkippedUser struct {
+	User_id uuid.UUID `json:"user_id"`
+	Name    string    `json:"name"`
+	Reason  string    `json:"reason"`
+}
+
+// Adopted lists the records created for the period before the run existed,
+// they were moved into the run and recomputed
+type PayrollRunGenerateResult struct {
+	Run_id  uuid.UUID               `json:"run_id"`
+	Created []uuid.UUID             `json:"created"`
+	Adopted []uuid.UUID             `json:"adopted"`
+	Skipped []PayrollRunSkippedUser `json:"skipped"`
+}
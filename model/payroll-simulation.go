@@ -0,0 +1,36 @@
+package model
+
+// What the target amount of a simulation is
+const (
+	// Monthly basic salary plus the earning allowances
+	SimulationTargetGross = "gross"
+	// Take home pay after BPJS and PPh 21
+	SimulationTargetNet = "net"
+)
+
+// What-if input of the payroll calculation, nothing is read from or written
+// to an employee. The basic salary is whatever is left to reach the target
+// once the allowances are paid. Bpjs_programs lists the programs the
+// employee takes part in, all of them when it is left out; an empty list
+// means none. Payment_period defaults to the current month, a December
+// simulation assumes the same pay for the whole year.
+type PayrollSimulationModel struct {
+	Target_type    string             `json:"target_type"`
+	Target_amount  int                `json:"target_amount"`
+	Ptkp_status    string             `json:"ptkp_status"`
+	Tax_method     string             `json:"tax_method"`
+	Payment_period string             `json:"payment_period"`
+	Allowances     []PayrollItemInput `json:"allowances"`
+	Bpjs_programs  []string           `json:"bpjs_programs"`
+	Jkk_risk_class int                `json:"jkk_risk_class"`
+}
+
+// Breakdown of a simulated month. Employer_cost is the gross pay, tax
+// allowance included, plus the employer BPJS premiums and the PPh 21 the
+// employer bears under the net method.
+type PayrollSimulationResult struct {
+	Target_type   string `json:"target_type"`
+	Target_amount int    `json:"target_amount"`
+	PayrollCalculationResult
+	Employer_cost int `json:"employer_cost"`
+}
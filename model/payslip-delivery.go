@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payslip delivery statuses
+const (
+	PayslipDeliveryPending = "pending"
+	PayslipDeliverySent    = "sent"
+	PayslipDeliveryFailed  = "failed"
+)
+
+// Represents payslip_deliveries table, one row per emailed payslip
+type PayslipDelivery struct {
+	Delivery_id    uuid.UUID  `json:"delivery_id"`
+	Payroll_id     uuid.UUID  `json:"payroll_id"`
+	User_id        uuid.UUID  `json:"user_id"`
+	Name           string     `json:"name"`
+	Payment_period string     `json:"payment_period"`
+	Email          string     `json:"email"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	Last_error     string     `json:"last_error"`
+	Sent_at        *time.Time `json:"sent_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type DistributePayslipModel struct {
+	Payment_period string `json:"payment_period"`
+}
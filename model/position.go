@@ -7,9 +7,10 @@ import (
 )
 
 type Position struct {
-	Position_id uuid.UUID `json:"position_id"`
-	Name        string    `json:"name"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Is_delete   bool      `json:"is_delete"`
+	Position_id    uuid.UUID `json:"position_id"`
+	Name           string    `json:"name"`
+	Jkk_risk_class int       `json:"jkk_risk_class"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Is_delete      bool      `json:"is_delete"`
 }
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PTKP (Penghasilan Tidak Kena Pajak) statuses
+const (
+	PtkpTK0 = "TK/0"
+	PtkpTK1 = "TK/1"
+	PtkpTK2 = "TK/2"
+	PtkpTK3 = "TK/3"
+	PtkpK0  = "K/0"
+	PtkpK1  = "K/1"
+	PtkpK2  = "K/2"
+	PtkpK3  = "K/3"
+)
+
+// PPh 21 withholding methods
+const (
+	// Employee bears the tax, it is deducted from take home pay
+	TaxMethodGross = "gross"
+	// Employer grants a tax allowance equal to the tax
+	TaxMethodGrossUp = "gross_up"
+	// Employer bears the tax outside of the employee's income
+	TaxMethodNet = "net"
+)
+
+// PPh 21 schemes
+const (
+	// Monthly TER (tarif efektif rata-rata), January to November
+	TaxSchemeTer = "ter"
+	// December annual true-up using the Pasal 17 brackets
+	TaxSchemeAnnual = "annual"
+	// Irregular income such as THR, taxed as the extra tax it causes on top
+	// of the regular income of the month
+	TaxSchemeIrregular = "irregular"
+)
+
+// Input of the PPh 21 calculator for a single month
+type Pph21Input struct {
+	Ptkp_status       string    `json:"ptkp_status"`
+	Method            string    `json:"method"`
+	Period            time.Time `json:"period"`
+	Gross_income      int       `json:"gross_income"`
+	Employer_premiums int       `json:"employer_premiums"`
+	Pension_deduction int       `json:"pension_deduction"`
+	// Year to date figures before Period, used by the December true-up
+	Ytd_gross_income      int `json:"ytd_gross_income"`
+	Ytd_pension_deduction int `json:"ytd_pension_deduction"`
+	Ytd_tax               int `json:"ytd_tax"`
+	Ytd_months            int `json:"ytd_months"`
+	// Regular taxable income of the month, only used for irregular income
+	Regular_income int `json:"regular_income"`
+}
+
+// Itemized PPh 21 breakdown, stored as tax_detail on payroll_records
+type Pph21Breakdown struct {
+	Ptkp_status       string  `json:"ptkp_status"`
+	Method            string  `json:"method"`
+	Scheme            string  `json:"scheme"`
+	Ter_category      string  `json:"ter_category,omitempty"`
+	Ter_rate          float64 `json:"ter_rate"`
+	Gross_income      int     `json:"gross_income"`
+	Employer_premiums int     `json:"employer_premiums"`
+	Tax_allowance     int     `json:"tax_allowance"`
+	Taxable_income    int     `json:"taxable_income"`
+	Pension_deduction int     `json:"pension_deduction"`
+	Regular_income    int     `json:"regular_income,omitempty"`
+	// Annual true-up figures, only filled for the annual scheme
+	Annual_gross_income      int `json:"annual_gross_income,omitempty"`
+	Annual_biaya_jabatan     int `json:"annual_biaya_jabatan,omitempty"`
+	Annual_pension_deduction int `json:"annual_pension_deduction,omitempty"`
+	Annual_net_income        int `json:"annual_net_income,omitempty"`
+	Ptkp                     int `json:"ptkp,omitempty"`
+	Pkp                      int `json:"pkp,omitempty"`
+	Annual_tax               int `json:"annual_tax,omitempty"`
+	Withheld_before          int `json:"withheld_before,omitempty"`
+	// Tax due for the month, negative on a December overpayment
+	Tax          int `json:"tax"`
+	Employee_tax int `json:"employee_tax"`
+	Employer_tax int `json:"employer_tax"`
+}
+
+// Value stores the breakdown as jsonb
+func (b Pph21Breakdown) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan reads the breakdown from a jsonb column
+func (b *Pph21Breakdown) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, b)
+	case string:
+		return json.Unmarshal([]byte(v), b)
+	}
+	return errors.New("pph21 breakdown: unsupported scan type")
+}
+
+// Year to date PPh 21 figures of an employee
+type Pph21YearToDate struct {
+	Gross_income      int `json:"gross_income"`
+	Pension_deduction int `json:"pension_deduction"`
+	Tax               int `json:"tax"`
+	Months            int `json:"months"`
+}
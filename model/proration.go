@@ -0,0 +1,65 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Proration methods, selected per company configuration
+const (
+	// Every day of the month counts
+	ProrationCalendarDay = "calendar"
+	// Only Monday to Friday count
+	ProrationWorkingDay = "working"
+)
+
+// Share of the monthly salary earned in a period, stored as proration on
+// payroll_records. Factor covers the employment window only, unpaid days
+// are deducted through UNPAID_LEAVE items.
+type Proration struct {
+	Method        string  `json:"method"`
+	Period_days   int     `json:"period_days"`
+	Employed_days int     `json:"employed_days"`
+	Unpaid_days   int     `json:"unpaid_days"`
+	Paid_days     int     `json:"paid_days"`
+	Factor        float64 `json:"factor"`
+}
+
+// Value stores the proration as jsonb
+func (p Proration) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan reads the proration from a jsonb column
+func (p *Proration) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	}
+	return errors.New("proration: unsupported scan type")
+}
+
+// Approved leave that is unpaid or limited by a yearly quota, the source
+// of leave deductions
+type UnpaidLeave struct {
+	Request_id uuid.UUID `json:"request_id"`
+	Leave_id   int       `json:"leave_id"`
+	Leave_name string    `json:"leave_name"`
+	Is_paid    bool      `json:"is_paid"`
+	Quota      *int      `json:"quota"`
+	From_date  time.Time `json:"from_date"`
+	To_date    time.Time `json:"to_date"`
+}
+
+type UpdateUserEmploymentModel struct {
+	Join_date        string `json:"join_date"`
+	Termination_date string `json:"termination_date"`
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payroll cost of a period, or of a position and role within it when the
+// summary is grouped by them. Reversals count negative so a corrected
+// record is only counted once.
+type PayrollCostRow struct {
+	Payment_period string `json:"payment_period"`
+	Position       string `json:"position,omitempty"`
+	Role           string `json:"role,omitempty"`
+	Headcount      int    `json:"headcount"`
+	Record_count   int    `json:"record_count"`
+	// Every earning, the tax allowance included
+	Gross_pay     int `json:"gross_pay"`
+	Employee_bpjs int `json:"employee_bpjs"`
+	Employer_bpjs int `json:"employer_bpjs"`
+	Tax_withheld  int `json:"tax_withheld"`
+	// PPh 21 borne by the employer on top of the gross pay, net method only
+	Employer_tax int `json:"employer_tax"`
+	Net_pay      int `json:"net_pay"`
+	// Gross pay plus the employer's BPJS and tax
+	Total_cost int `json:"total_cost"`
+}
+
+type PayrollCostSummary struct {
+	Period_from string           `json:"period_from"`
+	Period_to   string           `json:"period_to"`
+	Group_by    []string         `json:"group_by"`
+	Rows        []PayrollCostRow `json:"rows"`
+	// Totals of every row, Headcount counts each employee once
+	Total PayrollCostRow `json:"total"`
+}
+
+// Net pay of a user in a period, summed over the regular records of the
+// period so corrections booked in it are netted in
+type PayrollPeriodUserTotal struct {
+	User_id          uuid.UUID
+	Name             string
+	Nik              string
+	Termination_date *time.Time
+	// Monthly basic salary of the compensation in force at the end of the
+	// period, before proration
+	Basic_salary int
+	Net_pay      int
+}
+
+type PayrollPeriodItemTotal struct {
+	User_id        uuid.UUID
+	Component_code string
+	Name           string
+	Component_type string
+	Amount         int
+}
+
+const (
+	VarianceNewEmployee = "new_employee"
+	VarianceTerminated  = "terminated"
+	VarianceSalary      = "salary_change"
+	VarianceNetPay      = "net_pay_change"
+)
+
+// Changes of a payroll period against an earlier one, for review before
+// the period is approved
+type PayrollVarianceReport struct {
+	Period            string                    `json:"period"`
+	Compare_to        string                    `json:"compare_to"`
+	Threshold_percent float64                   `json:"threshold_percent"`
+	Summary           PayrollVarianceSummary    `json:"summary"`
+	Employees         []PayrollVarianceEmployee `json:"employees"`
+}
+
+type PayrollVarianceSummary struct {
+	Headcount          int `json:"headcount"`
+	Previous_headcount int `json:"previous_headcount"`
+	New_employees      int `json:"new_employees"`
+	Terminated         int `json:"terminated"`
+	Salary_changes     int `json:"salary_changes"`
+	Net_pay_anomalies  int `json:"net_pay_anomalies"`
+	Unchanged          int `json:"unchanged"`
+	Net_pay            int `json:"net_pay"`
+	Previous_net_pay   int `json:"previous_net_pay"`
+}
+
+// A user whose pay differs between the periods. Flags lists the anomalies,
+// an employee with only component differences has none.
+type PayrollVarianceEmployee struct {
+	User_id               uuid.UUID                  `json:"user_id"`
+	Name                  string                     `json:"name"`
+	Nik                   string                     `json:"nik"`
+	Flags                 []string                   `json:"flags"`
+	Previous_basic_salary int                        `json:"previous_basic_salary"`
+	Basic_salary          int                        `json:"basic_salary"`
+	Previous_net_pay      int                        `json:"previous_net_pay"`
+	Net_pay               int                        `json:"net_pay"`
+	Net_pay_difference    int                        `json:"net_pay_difference"`
+	Net_pay_change        *float64                   `json:"net_pay_change_percent"`
+	Components            []PayrollVarianceComponent `json:"components"`
+}
+
+type PayrollVarianceComponent struct {
+	Component_code string `json:"component_code"`
+	Name           string `json:"name"`
+	Component_type string `json:"component_type"`
+	Previous       int    `json:"previous"`
+	Current        int    `json:"current"`
+	Difference     int    `json:"difference"`
+}
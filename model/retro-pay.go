@@ -0,0 +1,76 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Represents retro_pays table, what a closed period should have paid after
+// a back-dated salary change minus what it did pay. Every difference is
+// negative when the salary went down.
+type RetroPay struct {
+	Retro_id            uuid.UUID `json:"retro_id"`
+	User_id             uuid.UUID `json:"user_id"`
+	Earned_period       string    `json:"earned_period"`
+	Payment_period      string    `json:"payment_period"`
+	Basic_salary_before int       `json:"basic_salary_before"`
+	Basic_salary_after  int       `json:"basic_salary_after"`
+	// Earnings less non statutory deductions, the RAPEL line
+	Earning_difference       int            `json:"earning_difference"`
+	Bpjs_employee_difference int            `json:"bpjs_employee_difference"`
+	Bpjs_employer_difference int            `json:"bpjs_employer_difference"`
+	Bpjs_items               RetroBpjsItems `json:"bpjs_items"`
+	// PPh 21 figures of the earned period
+	Employer_premiums_difference int       `json:"employer_premiums_difference"`
+	Pension_difference           int       `json:"pension_difference"`
+	Taxable_difference           int       `json:"taxable_difference"`
+	Tax_difference               int       `json:"tax_difference"`
+	Employee_tax_difference      int       `json:"employee_tax_difference"`
+	Net_difference               int       `json:"net_difference"`
+	Reason                       string    `json:"reason"`
+	CreatedAt                    time.Time `json:"created_at"`
+}
+
+// BPJS differences per program, stored as jsonb on retro_pays
+type RetroBpjsItems []BpjsItem
+
+// Value stores the items as jsonb
+func (items RetroBpjsItems) Value() (driver.Value, error) {
+	if items == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]BpjsItem(items))
+}
+
+// Scan reads the items from a jsonb column
+func (items *RetroBpjsItems) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]BpjsItem)(items))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]BpjsItem)(items))
+	}
+	return errors.New("retro bpjs items: unsupported scan type")
+}
+
+// Recomputes the paid periods from Effective_period on with the
+// compensation history as it stands now
+type CreateRetroPayModel struct {
+	User_id          uuid.UUID `json:"user_id"`
+	Effective_period string    `json:"effective_period"`
+	Reason           string    `json:"reason"`
+}
+
+type RetroPayResult struct {
+	User_id        uuid.UUID  `json:"user_id"`
+	Payment_period string     `json:"payment_period"`
+	Payroll_id     uuid.UUID  `json:"payroll_id"`
+	Retro_pays     []RetroPay `json:"retro_pays"`
+	Net_difference int        `json:"net_difference"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Form 1721-A1, the yearly PPh 21 withholding certificate of a permanent
+// employee. Line numbers follow the form.
+type TaxCertificate struct {
+	Number       string    `json:"number"`
+	Tax_year     int       `json:"tax_year"`
+	Period_start int       `json:"period_start"`
+	Period_end   int       `json:"period_end"`
+	User_id      uuid.UUID `json:"user_id"`
+	Nik          string    `json:"nik"`
+	Name         string    `json:"name"`
+	Position     string    `json:"position"`
+	Ptkp_status  string    `json:"ptkp_status"`
+	Employer     string    `json:"employer"`
+	Employer_tin string    `json:"employer_tin"`
+	// Gross income
+	Salary               int `json:"salary"`               // 1
+	Tax_allowance        int `json:"tax_allowance"`        // 2
+	Other_allowances     int `json:"other_allowances"`     // 3
+	Honorarium           int `json:"honorarium"`           // 4
+	Insurance_premiums   int `json:"insurance_premiums"`   // 5
+	Benefits_in_kind     int `json:"benefits_in_kind"`     // 6
+	Bonus_and_thr        int `json:"bonus_and_thr"`        // 7
+	Gross_income         int `json:"gross_income"`         // 8
+	Biaya_jabatan        int `json:"biaya_jabatan"`        // 9
+	Pension_contribution int `json:"pension_contribution"` // 10
+	Total_deductions     int `json:"total_deductions"`     // 11
+	Net_income           int `json:"net_income"`           // 12
+	Previous_net_income  int `json:"previous_net_income"`  // 13
+	Annual_net_income    int `json:"annual_net_income"`    // 14
+	Ptkp                 int `json:"ptkp"`                 // 15
+	Pkp                  int `json:"pkp"`                  // 16
+	Annual_tax           int `json:"annual_tax"`           // 17
+	Previous_tax         int `json:"previous_tax"`         // 18
+	Tax_due              int `json:"tax_due"`              // 19
+	Tax_withheld         int `json:"tax_withheld"`         // 20
+	Underpaid_tax        int `json:"underpaid_tax"`        // 21, negative when overpaid
+	Payroll_record_count int `json:"payroll_record_count"`
+}
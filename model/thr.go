@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// THR entitlement of a user as of a religious holiday
+type ThrEntitlement struct {
+	User_id        uuid.UUID  `json:"user_id"`
+	Name           string     `json:"name"`
+	Nik            string     `json:"nik"`
+	Join_date      *time.Time `json:"join_date"`
+	Service_months int        `json:"service_months"`
+	Monthly_wage   int        `json:"monthly_wage"`
+	Amount         int        `json:"amount"`
+	Eligible       bool       `json:"eligible"`
+	Reason         string     `json:"reason,omitempty"`
+	Tax_method     string     `json:"-"`
+	Regular_income int        `json:"-"`
+}
+
+type CreateThrRunModel struct {
+	Holiday_name string `json:"holiday_name"`
+	Holiday_date string `json:"holiday_date"`
+	Payment_date string `json:"payment_date"`
+	Tax_method   string `json:"tax_method"`
+}
@@ -8,29 +8,40 @@ import (
 
 // User represents users table in the database
 type User struct {
-	User_id     uuid.UUID `json:"user_id"`
-	Username    string    `json:"username"`
-	Name        string    `json:"name"`
-	Password    string    `json:"password"`
-	Email       string    `json:"email"`
-	Nik         string    `json:"nik"`
-	Role_id     uuid.UUID `json:"role_id"`
-	Position_id uuid.UUID `json:"position"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Is_delete   bool      `json:"is_delete"`
+	User_id     uuid.UUID  `json:"user_id"`
+	Username    string     `json:"username"`
+	Name        string     `json:"name"`
+	Password    string     `json:"password"`
+	Email       string     `json:"email"`
+	Nik         string     `json:"nik"`
+	Role_id     uuid.UUID  `json:"role_id"`
+	Position_id uuid.UUID  `json:"position"`
+	Ptkp_status string     `json:"ptkp_status"`
+	Birth_date  *time.Time `json:"birth_date"`
+	BankAccount
+	Join_date        *time.Time `json:"join_date"`
+	Termination_date *time.Time `json:"termination_date"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Is_delete        bool       `json:"is_delete"`
 }
 
 type UserDetailModel struct {
-	User_id       uuid.UUID `json:"user_id"`
-	Username      string    `json:"username"`
-	Name          string    `json:"name"`
-	Email         string    `json:"email"`
-	Position_id   uuid.UUID `json:"position_id"`
-	Nik           string    `json:"nik"`
-	Role_id       uuid.UUID `json:"role_id"`
-	Role_name     string    `json:"role"`
-	Position_name string    `json:"position"`
+	User_id        uuid.UUID  `json:"user_id"`
+	Username       string     `json:"username"`
+	Name           string     `json:"name"`
+	Email          string     `json:"email"`
+	Position_id    uuid.UUID  `json:"position_id"`
+	Nik            string     `json:"nik"`
+	Role_id        uuid.UUID  `json:"role_id"`
+	Role_name      string     `json:"role"`
+	Position_name  string     `json:"position"`
+	Ptkp_status    string     `json:"ptkp_status"`
+	Jkk_risk_class int        `json:"jkk_risk_class"`
+	Birth_date     *time.Time `json:"birth_date"`
+	BankAccount
+	Join_date        *time.Time `json:"join_date"`
+	Termination_date *time.Time `json:"termination_date"`
 }
 
 type UserResponse struct {
@@ -57,4 +68,8 @@ type RegisterUser struct {
 	Nik         string    `json:"nik"`
 	Role_id     uuid.UUID `json:"role_id"`
 	Position_id uuid.UUID `json:"position_id"`
+	Ptkp_status string    `json:"ptkp_status"`
+	Birth_date  string    `json:"birth_date"`
+	BankAccount
+	Join_date string `json:"join_date"`
 }
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type BpjsRepo interface {
+	//Read
+	GetBpjsProgramList(ctx context.Context) ([]model.BpjsProgram, error)
+	GetJkkRate(ctx context.Context, riskClass int) (model.BpjsJkkRate, error)
+	GetBpjsItemList(ctx context.Context, payrollId uuid.UUID) ([]model.BpjsItem, error)
+	//Create
+	CreateBpjsItem(ctx context.Context, tx *sqlx.Tx, item model.BpjsItem) (uuid.UUID, error)
+	//Update
+	UpdateBpjsProgram(ctx context.Context, tx *sqlx.Tx, p model.BpjsProgram) (string, error)
+	//Delete
+	DeleteBpjsItems(ctx context.Context, tx *sqlx.Tx, payrollId uuid.UUID) error
+}
+
+type bpjsRepository struct {
+	db *sqlx.DB
+}
+
+func NewBpjsRepo(dbConn *sqlx.DB) BpjsRepo {
+	return &bpjsRepository{
+		db: dbConn,
+	}
+}
+
+func (r *bpjsRepository) GetBpjsProgramList(ctx context.Context) ([]model.BpjsProgram, error) {
+	list := make([]model.BpjsProgram, 0)
+
+	query := `
+		SELECT
+			b.program_code,
+			b.name,
+			b.employer_rate,
+			b.employee_rate,
+			b.wage_cap,
+			b.created_at,
+			b.updated_at
+		FROM
+			bpjs_programs b
+		ORDER BY b.program_code ASC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query)
+	if err != nil {
+		utils.LogError("Repo", "func GetBpjsProgramList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var program model.BpjsProgram
+		err = rows.Scan(
+			&program.Program_code,
+			&program.Name,
+			&program.Employer_rate,
+			&program.Employee_rate,
+			&program.Wage_cap,
+			&program.CreatedAt,
+			&program.UpdatedAt,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetBpjsProgramList scan data", err)
+			return list, err
+		}
+		list = append(list, program)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (r *bpjsRepository) GetJkkRate(ctx context.Context, riskClass int) (model.BpjsJkkRate, error) {
+	var (
+		rate model.BpjsJkkRate
+	)
+
+	query := `
+		SELECT
+			j.risk_class,
+			j.rate
+		FROM
+			bpjs_jkk_rates j
+		WHERE j.risk_class = $1;
+		`
+
+	err := r.db.QueryRowxContext(
+		ctx,
+		query,
+		riskClass,
+	).Scan(
+		&rate.Risk_class,
+		&rate.Rate,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func GetJkkRate", err)
+		return rate, err
+	}
+
+	return rate, err
+}
+
+func (r *bpjsRepository) GetBpjsItemList(ctx context.Context, payrollId uuid.UUID) ([]model.BpjsItem, error) {
+	list := make([]model.BpjsItem, 0)
+
+	query := `
+		SELECT
+			i.item_id,
+			i.payroll_id,
+			i.program_code,
+			i.base_wage,
+			i.employer_rate,
+			i.employee_rate,
+			i.employer_amount,
+			i.employee_amount
+		FROM
+			payroll_bpjs_items i
+		WHERE i.payroll_id = $1
+		ORDER BY i.program_code ASC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query, payrollId)
+	if err != nil {
+		utils.LogError("Repo", "func GetBpjsItemList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item model.BpjsItem
+		err = rows.Scan(
+			&item.Item_id,
+			&item.Payroll_id,
+			&item.Program_code,
+			&item.Base_wage,
+			&item.Employer_rate,
+			&item.Employee_rate,
+			&item.Employer_amount,
+			&item.Employee_amount,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetBpjsItemList scan data", err)
+			return list, err
+		}
+		list = append(list, item)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (r *bpjsRepository) CreateBpjsItem(ctx context.Context, tx *sqlx.Tx, item model.BpjsItem) (uuid.UUID, error) {
+	var (
+		item_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO
+			payroll_bpjs_items (payroll_id, program_code, base_wage, employer_rate, employee_rate, employer_amount, employee_amount)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)
+		RETURNING item_id
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		item.Payroll_id,
+		item.Program_code,
+		item.Base_wage,
+		item.Employer_rate,
+		item.Employee_rate,
+		item.Employer_amount,
+		item.Employee_amount,
+	).Scan(
+		&item_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreateBpjsItem", err)
+		return item_id, err
+	}
+
+	return item_id, err
+}
+
+func (r *bpjsRepository) UpdateBpjsProgram(ctx context.Context, tx *sqlx.Tx, p model.BpjsProgram) (string, error) {
+	var (
+		program_code string
+	)
+
+	query := `
+		UPDATE
+			bpjs_programs
+		SET
+			employer_rate = $1,
+			employee_rate = $2,
+			wage_cap = $3,
+			updated_at = now()
+		WHERE
+			program_code = $4
+		RETURNING program_code
+			;
+	`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		p.Employer_rate,
+		p.Employee_rate,
+		p.Wage_cap,
+		p.Program_code,
+	).Scan(
+		&program_code,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func UpdateBpjsProgram", err)
+		return program_code, err
+	}
+
+	return program_code, err
+}
+
+func (r *bpjsRepository) DeleteBpjsItems(ctx context.Context, tx *sqlx.Tx, payrollId uuid.UUID) error {
+	query := `
+		DELETE FROM
+			payroll_bpjs_items
+		WHERE
+			payroll_id = $1;
+	`
+
+	_, err := tx.ExecContext(ctx, query, payrollId)
+	if err != nil {
+		utils.LogError("Repo", "func DeleteBpjsItems", err)
+		return err
+	}
+
+	return err
+}
@@ -0,0 +1,464 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ClaimRepo interface {
+	//Create
+	CreateClaimCategory(ctx context.Context, tx *sqlx.Tx, c model.ClaimCategory) (string, error)
+	CreateClaim(ctx context.Context, tx *sqlx.Tx, c model.Claim) (uuid.UUID, error)
+	CreateClaimReceipt(ctx context.Context, tx *sqlx.Tx, r model.ClaimReceipt) (uuid.UUID, error)
+	//Read
+	GetClaimCategoryList(ctx context.Context) ([]model.ClaimCategory, error)
+	GetClaimCategory(ctx context.Context, code string) (model.ClaimCategory, error)
+	GetClaimList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Claim, error)
+	GetClaimDetail(ctx context.Context, id uuid.UUID) (model.Claim, error)
+	GetClaimedAmount(ctx context.Context, userId uuid.UUID, category string, year int, includePending bool) (int, error)
+	GetPayrollClaimList(ctx context.Context, userId uuid.UUID, period string) ([]model.Claim, error)
+	GetClaimTransferList(ctx context.Context) ([]model.BankTransferLine, error)
+	GetClaimReceiptList(ctx context.Context, claimId uuid.UUID) ([]model.ClaimReceipt, error)
+	GetClaimReceipt(ctx context.Context, claimId uuid.UUID, id uuid.UUID) (model.ClaimReceipt, error)
+	//Update
+	UpdateClaimStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, statusId uuid.UUID, payrollPeriod string) (uuid.UUID, error)
+}
+
+type claimRepo struct {
+	db *sqlx.DB
+}
+
+func NewClaimRepo(dbConn *sqlx.DB) ClaimRepo {
+	return &claimRepo{
+		db: dbConn,
+	}
+}
+
+const claimColumns = `
+			c.claim_id, c.user_id, u.name, c.category_code, k.name, c.claim_date, c.amount, c.description,
+			c.payout, c.status_id, s.name, COALESCE(c.payroll_period, ''), c.decided_at, c.created_at, c.updated_at`
+
+const claimFrom = `
+		FROM
+			expense_claims c
+				INNER JOIN users u ON u.user_id = c.user_id
+				INNER JOIN claim_categories k ON k.category_code = c.category_code
+				INNER JOIN status s ON s.status_id = c.status_id`
+
+func scanClaim(row interface{ Scan(...interface{}) error }, c *model.Claim) error {
+	return row.Scan(
+		&c.Claim_id,
+		&c.User_id,
+		&c.Name,
+		&c.Category_code,
+		&c.Category_name,
+		&c.Claim_date,
+		&c.Amount,
+		&c.Description,
+		&c.Payout,
+		&c.Status_id,
+		&c.Status,
+		&c.Payroll_period,
+		&c.Decided_at,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+}
+
+func (db *claimRepo) GetClaimCategoryList(ctx context.Context) ([]model.ClaimCategory, error) {
+	list := make([]model.ClaimCategory, 0)
+
+	query := `
+		SELECT
+			k.category_code, k.name, k.yearly_limit, k.created_at, k.updated_at
+		FROM
+			claim_categories k
+		WHERE k.is_delete = false
+		ORDER BY k.category_code ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimCategoryList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var category model.ClaimCategory
+		err = rows.Scan(
+			&category.Category_code,
+			&category.Name,
+			&category.Yearly_limit,
+			&category.CreatedAt,
+			&category.UpdatedAt,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetClaimCategoryList scan data", err)
+			return list, err
+		}
+		list = append(list, category)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *claimRepo) GetClaimCategory(ctx context.Context, code string) (model.ClaimCategory, error) {
+	var category model.ClaimCategory
+
+	query := `
+		SELECT
+			k.category_code, k.name, k.yearly_limit, k.created_at, k.updated_at
+		FROM
+			claim_categories k
+		WHERE
+			k.category_code = $1 AND k.is_delete = false;`
+
+	err := db.db.QueryRowxContext(ctx, query, code).Scan(
+		&category.Category_code,
+		&category.Name,
+		&category.Yearly_limit,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimCategory", err)
+		return category, err
+	}
+	return category, err
+}
+
+func (db *claimRepo) CreateClaimCategory(ctx context.Context, tx *sqlx.Tx, c model.ClaimCategory) (string, error) {
+	var (
+		category_code string
+	)
+
+	query := `
+		INSERT INTO claim_categories(
+			category_code, name, yearly_limit
+		) VALUES(
+			$1, $2, $3
+		) RETURNING category_code;`
+
+	err := tx.QueryRowxContext(ctx, query, c.Category_code, c.Name, c.Yearly_limit).Scan(&category_code)
+	if err != nil {
+		utils.LogError("Repo", "func CreateClaimCategory", err)
+		return category_code, err
+	}
+
+	return category_code, err
+}
+
+// Claims of every user when userId is not set, of any status when status is
+// empty
+func (db *claimRepo) GetClaimList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Claim, error) {
+	list := make([]model.Claim, 0)
+
+	query := `
+		SELECT` + claimColumns + claimFrom + `
+		WHERE
+			c.is_delete = false
+			AND ($1::uuid IS NULL OR c.user_id = $1)
+			AND ($2 = '' OR s.name = $2)
+		ORDER BY c.claim_date DESC, c.created_at DESC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, userId, status)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var claim model.Claim
+		err = scanClaim(rows, &claim)
+		if err != nil {
+			utils.LogError("Repo", "GetClaimList scan data", err)
+			return list, err
+		}
+		list = append(list, claim)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *claimRepo) GetClaimDetail(ctx context.Context, id uuid.UUID) (model.Claim, error) {
+	var claim model.Claim
+
+	query := `
+		SELECT` + claimColumns + claimFrom + `
+		WHERE
+			c.claim_id = $1 AND c.is_delete = false;`
+
+	err := scanClaim(db.db.QueryRowxContext(ctx, query, id), &claim)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimDetail", err)
+		return claim, err
+	}
+	return claim, err
+}
+
+// Sum a user claimed in a category over a calendar year, the usage of the
+// yearly limit. Pending claims count when a new claim is filed so that
+// several open claims cannot exceed the limit together.
+func (db *claimRepo) GetClaimedAmount(ctx context.Context, userId uuid.UUID, category string, year int, includePending bool) (int, error) {
+	var amount int
+
+	query := `
+		SELECT
+			COALESCE(SUM(c.amount), 0)
+		FROM
+			expense_claims c
+				INNER JOIN status s ON s.status_id = c.status_id
+		WHERE
+			c.user_id = $1 AND c.category_code = $2 AND c.is_delete = false
+			AND EXTRACT(YEAR FROM c.claim_date) = $3
+			AND (s.name IN ('approved', 'paid') OR ($4 AND s.name = 'pending'));`
+
+	err := db.db.QueryRowxContext(ctx, query, userId, category, year, includePending).Scan(&amount)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimedAmount", err)
+		return amount, err
+	}
+	return amount, err
+}
+
+// Approved claims paid with the payroll of a period, the input of the
+// reimbursement lines of the calculation
+func (db *claimRepo) GetPayrollClaimList(ctx context.Context, userId uuid.UUID, period string) ([]model.Claim, error) {
+	list := make([]model.Claim, 0)
+
+	query := `
+		SELECT` + claimColumns + claimFrom + `
+		WHERE
+			c.user_id = $1 AND c.payroll_period = $2 AND c.payout = 'payroll'
+			AND s.name = 'approved' AND c.is_delete = false
+		ORDER BY c.claim_date ASC, c.created_at ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, userId, period)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollClaimList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var claim model.Claim
+		err = scanClaim(rows, &claim)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollClaimList scan data", err)
+			return list, err
+		}
+		list = append(list, claim)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Approved claims to be transferred apart from the payroll, one credit line
+// per user
+func (db *claimRepo) GetClaimTransferList(ctx context.Context) ([]model.BankTransferLine, error) {
+	list := make([]model.BankTransferLine, 0)
+
+	query := `
+		SELECT
+			u.user_id, u.name, u.nik, u.email,
+			COALESCE(u.bank_code, ''), COALESCE(u.bank_account_number, ''), COALESCE(u.bank_account_name, ''),
+			SUM(c.amount)
+		FROM
+			expense_claims c
+				INNER JOIN status s ON s.status_id = c.status_id
+				INNER JOIN users u ON u.user_id = c.user_id
+		WHERE
+			c.payout = 'transfer' AND s.name = 'approved' AND c.is_delete = false
+		GROUP BY u.user_id
+		ORDER BY u.name ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimTransferList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var line model.BankTransferLine
+		err = rows.Scan(
+			&line.User_id,
+			&line.Name,
+			&line.Nik,
+			&line.Email,
+			&line.Bank_code,
+			&line.Bank_account_number,
+			&line.Bank_account_name,
+			&line.Amount,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetClaimTransferList scan data", err)
+			return list, err
+		}
+		list = append(list, line)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *claimRepo) CreateClaim(ctx context.Context, tx *sqlx.Tx, c model.Claim) (uuid.UUID, error) {
+	var (
+		claim_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO expense_claims(
+			user_id, category_code, claim_date, amount, description, payout, status_id
+		) VALUES(
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING claim_id;`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		c.User_id,
+		c.Category_code,
+		c.Claim_date,
+		c.Amount,
+		c.Description,
+		c.Payout,
+		c.Status_id,
+	).Scan(
+		&claim_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreateClaim", err)
+		return claim_id, err
+	}
+
+	return claim_id, err
+}
+
+// Sets the status of a claim, payrollPeriod is kept when empty
+func (db *claimRepo) UpdateClaimStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, statusId uuid.UUID, payrollPeriod string) (uuid.UUID, error) {
+	var (
+		claim_id uuid.UUID
+	)
+
+	query := `
+		UPDATE
+			expense_claims
+		SET
+			status_id = $2,
+			payroll_period = COALESCE(NULLIF($3, ''), payroll_period),
+			decided_at = COALESCE(decided_at, now()),
+			updated_at = now()
+		WHERE
+			claim_id = $1
+		RETURNING claim_id;`
+
+	err := tx.QueryRowxContext(ctx, query, id, statusId, payrollPeriod).Scan(&claim_id)
+	if err != nil {
+		utils.LogError("Repo", "func UpdateClaimStatus", err)
+		return claim_id, err
+	}
+
+	return claim_id, err
+}
+
+func (db *claimRepo) GetClaimReceiptList(ctx context.Context, claimId uuid.UUID) ([]model.ClaimReceipt, error) {
+	list := make([]model.ClaimReceipt, 0)
+
+	query := `
+		SELECT
+			r.receipt_id, r.claim_id, r.filename, r.content_type, octet_length(r.content), r.created_at
+		FROM
+			claim_receipts r
+		WHERE r.claim_id = $1
+		ORDER BY r.created_at ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, claimId)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimReceiptList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var receipt model.ClaimReceipt
+		err = rows.Scan(
+			&receipt.Receipt_id,
+			&receipt.Claim_id,
+			&receipt.Filename,
+			&receipt.Content_type,
+			&receipt.Size,
+			&receipt.CreatedAt,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetClaimReceiptList scan data", err)
+			return list, err
+		}
+		list = append(list, receipt)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *claimRepo) GetClaimReceipt(ctx context.Context, claimId uuid.UUID, id uuid.UUID) (model.ClaimReceipt, error) {
+	var receipt model.ClaimReceipt
+
+	query := `
+		SELECT
+			r.receipt_id, r.claim_id, r.filename, r.content_type, octet_length(r.content), r.content, r.created_at
+		FROM
+			claim_receipts r
+		WHERE
+			r.claim_id = $1 AND r.receipt_id = $2;`
+
+	err := db.db.QueryRowxContext(ctx, query, claimId, id).Scan(
+		&receipt.Receipt_id,
+		&receipt.Claim_id,
+		&receipt.Filename,
+		&receipt.Content_type,
+		&receipt.Size,
+		&receipt.Content,
+		&receipt.CreatedAt,
+	)
+	if err != nil {
+		utils.LogError("Repo", "func GetClaimReceipt", err)
+		return receipt, err
+	}
+	return receipt, err
+}
+
+func (db *claimRepo) CreateClaimReceipt(ctx context.Context, tx *sqlx.Tx, r model.ClaimReceipt) (uuid.UUID, error) {
+	var (
+		receipt_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO claim_receipts(
+			claim_id, filename, content_type, content
+		) VALUES(
+			$1, $2, $3, $4
+		) RETURNING receipt_id;`
+
+	err := tx.QueryRowxContext(ctx, query, r.Claim_id, r.Filename, r.Content_type, r.Content).Scan(&receipt_id)
+	if err != nil {
+		utils.LogError("Repo", "func CreateClaimReceipt", err)
+		return receipt_id, err
+	}
+
+	return receipt_id, err
+}
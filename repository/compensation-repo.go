@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CompensationRepo interface {
+	//Create
+	CreateCompensation(ctx context.Context, tx *sqlx.Tx, c model.Compensation) (uuid.UUID, error)
+	//Read
+	GetCompensationList(ctx context.Context, userId uuid.UUID) ([]model.Compensation, error)
+	GetCompensationInForce(ctx context.Context, userId uuid.UUID, date time.Time) (model.Compensation, error)
+	//Update
+	UpdateCompensationEffectiveTo(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID) error
+}
+
+type compensationRepo struct {
+	db *sqlx.DB
+}
+
+func NewCompensationRepo(dbConn *sqlx.DB) CompensationRepo {
+	return &compensationRepo{
+		db: dbConn,
+	}
+}
+
+const compensationColumns = `
+			c.compensation_id, c.user_id, c.effective_from, c.effective_to, c.basic_salary, c.allowances,
+			c.pay_grade, c.reason, c.created_at, c.updated_at`
+
+func scanCompensation(row interface{ Scan(...interface{}) error }, c *model.Compensation) error {
+	return row.Scan(
+		&c.Compensation_id,
+		&c.User_id,
+		&c.Effective_from,
+		&c.Effective_to,
+		&c.Basic_salary,
+		&c.Allowances,
+		&c.Pay_grade,
+		&c.Reason,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+}
+
+// Salary history of a user, oldest change first
+func (db *compensationRepo) GetCompensationList(ctx context.Context, userId uuid.UUID) ([]model.Compensation, error) {
+	list := make([]model.Compensation, 0)
+
+	query := `
+		SELECT` + compensationColumns + `
+		FROM
+			employee_compensation c
+		WHERE
+			c.user_id = $1 AND c.is_delete = false
+		ORDER BY c.effective_from ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, userId)
+	if err != nil {
+		utils.LogError("Repo", "func GetCompensationList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var compensation model.Compensation
+		err = scanCompensation(rows, &compensation)
+		if err != nil {
+			utils.LogError("Repo", "GetCompensationList scan data", err)
+			return list, err
+		}
+		list = append(list, compensation)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Compensation of a user in force on a date
+func (db *compensationRepo) GetCompensationInForce(ctx context.Context, userId uuid.UUID, date time.Time) (model.Compensation, error) {
+	var compensation model.Compensation
+
+	query := `
+		SELECT` + compensationColumns + `
+		FROM
+			employee_compensation c
+		WHERE
+			c.user_id = $1 AND c.is_delete = false
+			AND c.effective_from <= $2
+			AND (c.effective_to IS NULL OR c.effective_to >= $2)
+		ORDER BY c.effective_from DESC
+		LIMIT 1;`
+
+	err := scanCompensation(db.db.QueryRowxContext(ctx, query, userId, date), &compensation)
+	if err != nil {
+		utils.LogError("Repo", "func GetCompensationInForce", err)
+		return compensation, err
+	}
+	return compensation, err
+}
+
+func (db *compensationRepo) CreateCompensation(ctx context.Context, tx *sqlx.Tx, c model.Compensation) (uuid.UUID, error) {
+	var (
+		compensation_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO employee_compensation(
+			user_id, effective_from, basic_salary, allowances, pay_grade, reason
+		) VALUES(
+			$1, $2, $3, $4, $5, $6
+		) RETURNING compensation_id;`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		c.User_id,
+		c.Effective_from,
+		c.Basic_salary,
+		c.Allowances,
+		c.Pay_grade,
+		c.Reason,
+	).Scan(
+		&compensation_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreateCompensation", err)
+		return compensation_id, err
+	}
+
+	return compensation_id, err
+}
+
+// Ends every change of a user the day before the next one starts, so a
+// change inserted between two others splits the history
+func (db *compensationRepo) UpdateCompensationEffectiveTo(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID) error {
+	query := `
+		UPDATE employee_compensation c SET
+			effective_to = n.next_from - 1, updated_at = now()
+		FROM (
+			SELECT
+				compensation_id, lead(effective_from) OVER (ORDER BY effective_from) AS next_from
+			FROM
+				employee_compensation
+			WHERE
+				user_id = $1 AND is_delete = false
+		) n
+		WHERE
+			c.compensation_id = n.compensation_id
+			AND c.effective_to IS DISTINCT FROM n.next_from - 1;`
+
+	_, err := tx.ExecContext(ctx, query, userId)
+	if err != nil {
+		utils.LogError("Repo", "func UpdateCompensationEffectiveTo", err)
+		return err
+	}
+
+	return err
+}
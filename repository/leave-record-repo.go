@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dafiqarba/be-payroll/model"
 	"github.com/dafiqarba/be-payroll/utils"
@@ -16,6 +17,7 @@ type LeaveRecordRepo interface {
 	//Read
 	GetLeaveRecordDetail(ctx context.Context, req_id uuid.UUID, id uuid.UUID) (model.LeaveRecord, error)
 	GetLeaveRecordList(ctx context.Context, id uuid.UUID, year string) ([]model.LeaveRecordListModel, error)
+	GetDeductibleLeaveList(ctx context.Context, userId uuid.UUID, from time.Time, to time.Time) ([]model.UnpaidLeave, error)
 	//Create
 	CreateLeaveRecord(ctx context.Context, tx *sqlx.Tx, d model.LeaveRecord) (uuid.UUID, error)
 	//Update
@@ -134,6 +136,59 @@ func (db *leaveRecordConnection) GetLeaveRecordList(ctx context.Context, id uuid
 	return leaveRecordList, err
 }
 
+// Approved leave of a user overlapping from and to that is either unpaid or
+// counted against a yearly quota
+func (db *leaveRecordConnection) GetDeductibleLeaveList(ctx context.Context, userId uuid.UUID, from time.Time, to time.Time) ([]model.UnpaidLeave, error) {
+	list := make([]model.UnpaidLeave, 0)
+
+	query := `
+		SELECT
+			l.request_id, l.leave_id, t.leave_name, t.is_paid, t.quota, l.from_date, l.to_date
+		FROM
+			leave_records as l
+				INNER JOIN status as s
+					ON s.status_id = l.status_id
+				INNER JOIN leave_types as t
+					ON t.leave_id = l.leave_id
+		WHERE
+			l.user_id = $1
+			AND s.name = 'approved'
+			AND (t.is_paid = false OR t.quota IS NOT NULL)
+			AND l.is_delete = false
+			AND l.from_date <= $3
+			AND l.to_date >= $2
+		ORDER BY l.from_date ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, userId, from, to)
+	if err != nil {
+		utils.LogError("Repo", "func GetDeductibleLeaveList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var leave model.UnpaidLeave
+		err = rows.Scan(
+			&leave.Request_id,
+			&leave.Leave_id,
+			&leave.Leave_name,
+			&leave.Is_paid,
+			&leave.Quota,
+			&leave.From_date,
+			&leave.To_date,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetDeductibleLeaveList scan data", err)
+			return list, err
+		}
+		list = append(list, leave)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
 func (db *leaveRecordConnection) CreateLeaveRecord(ctx context.Context, tx *sqlx.Tx, d model.LeaveRecord) (uuid.UUID, error) {
 	var (
 		req_id uuid.UUID
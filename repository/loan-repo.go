@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoanRepo interface {
+	//Create
+	CreateLoan(ctx context.Context, tx *sqlx.Tx, l model.Loan) (uuid.UUID, error)
+	//Read
+	GetLoanList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Loan, error)
+	GetLoanDetail(ctx context.Context, id uuid.UUID) (model.Loan, error)
+	GetOutstandingLoanList(ctx context.Context, userId uuid.UUID, period string) ([]model.Loan, error)
+	//Update
+	UpdateLoanStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status string) (uuid.UUID, error)
+}
+
+type loanRepo struct {
+	db *sqlx.DB
+}
+
+func NewLoanRepo(dbConn *sqlx.DB) LoanRepo {
+	return &loanRepo{
+		db: dbConn,
+	}
+}
+
+// Installments of locked payroll records, reversals count negative
+const loanRepaid = `
+			COALESCE((
+				SELECT SUM(i.amount)
+				FROM
+					payroll_items i
+						INNER JOIN payroll_records p ON p.payroll_id = i.payroll_id
+						INNER JOIN status s ON s.status_id = p.status_id
+				WHERE
+					i.reference_id = l.loan_id AND i.component_code = 'LOAN_INSTALLMENT'
+					AND p.is_delete = false AND s.name IN ('approved', 'paid', 'closed')
+			), 0)`
+
+const loanColumns = `
+			l.loan_id, l.user_id, u.name, l.loan_type, l.principal, l.installment_count, l.installment_amount,
+			l.start_period, l.reason, l.status, r.repaid, l.principal - r.repaid, l.decided_at, l.created_at, l.updated_at`
+
+const loanFrom = `
+		FROM
+			loans l
+				INNER JOIN users u ON u.user_id = l.user_id
+				CROSS JOIN LATERAL (SELECT` + loanRepaid + ` AS repaid) r`
+
+func scanLoan(row interface{ Scan(...interface{}) error }, l *model.Loan) error {
+	return row.Scan(
+		&l.Loan_id,
+		&l.User_id,
+		&l.Name,
+		&l.Loan_type,
+		&l.Principal,
+		&l.Installment_count,
+		&l.Installment_amount,
+		&l.Start_period,
+		&l.Reason,
+		&l.Status,
+		&l.Repaid,
+		&l.Remaining_balance,
+		&l.Decided_at,
+		&l.CreatedAt,
+		&l.UpdatedAt,
+	)
+}
+
+// Loans of every user when userId is not set, of any status when status is
+// empty
+func (db *loanRepo) GetLoanList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Loan, error) {
+	list := make([]model.Loan, 0)
+
+	query := `
+		SELECT` + loanColumns + loanFrom + `
+		WHERE
+			l.is_delete = false
+			AND ($1::uuid IS NULL OR l.user_id = $1)
+			AND ($2 = '' OR l.status = $2)
+		ORDER BY l.created_at DESC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, userId, status)
+	if err != nil {
+		utils.LogError("Repo", "func GetLoanList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var loan model.Loan
+		err = scanLoan(rows, &loan)
+		if err != nil {
+			utils.LogError("Repo", "GetLoanList scan data", err)
+			return list, err
+		}
+		list = append(list, loan)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *loanRepo) GetLoanDetail(ctx context.Context, id uuid.UUID) (model.Loan, error) {
+	var loan model.Loan
+
+	query := `
+		SELECT` + loanColumns + loanFrom + `
+		WHERE
+			l.loan_id = $1 AND l.is_delete = false;`
+
+	err := scanLoan(db.db.QueryRowxContext(ctx, query, id), &loan)
+	if err != nil {
+		utils.LogError("Repo", "func GetLoanDetail", err)
+		return loan, err
+	}
+	return loan, err
+}
+
+// Approved loans of a user with a balance left before the period, the input
+// of the installments of a payroll period. The balance counts the
+// installments of every earlier period whatever their status, so two open
+// periods never deduct the same amount, and leaves the period itself out so
+// it can be recalculated.
+func (db *loanRepo) GetOutstandingLoanList(ctx context.Context, userId uuid.UUID, period string) ([]model.Loan, error) {
+	list := make([]model.Loan, 0)
+
+	query := `
+		SELECT` + loanColumns + `
+		FROM
+			loans l
+				INNER JOIN users u ON u.user_id = l.user_id
+				CROSS JOIN LATERAL (
+					SELECT COALESCE(SUM(i.amount), 0) AS repaid
+					FROM
+						payroll_items i
+							INNER JOIN payroll_records p ON p.payroll_id = i.payroll_id
+					WHERE
+						i.reference_id = l.loan_id AND i.component_code = 'LOAN_INSTALLMENT'
+						AND p.is_delete = false AND p.payment_period < $2
+				) r
+		WHERE
+			l.user_id = $1 AND l.status = 'approved' AND l.is_delete = false
+			AND l.principal > r.repaid
+		ORDER BY l.decided_at ASC, l.created_at ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, userId, period)
+	if err != nil {
+		utils.LogError("Repo", "func GetOutstandingLoanList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var loan model.Loan
+		err = scanLoan(rows, &loan)
+		if err != nil {
+			utils.LogError("Repo", "GetOutstandingLoanList scan data", err)
+			return list, err
+		}
+		list = append(list, loan)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *loanRepo) CreateLoan(ctx context.Context, tx *sqlx.Tx, l model.Loan) (uuid.UUID, error) {
+	var (
+		loan_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO loans(
+			user_id, loan_type, principal, installment_count, installment_amount, start_period, reason
+		) VALUES(
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING loan_id;`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		l.User_id,
+		l.Loan_type,
+		l.Principal,
+		l.Installment_count,
+		l.Installment_amount,
+		l.Start_period,
+		l.Reason,
+	).Scan(
+		&loan_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreateLoan", err)
+		return loan_id, err
+	}
+
+	return loan_id, err
+}
+
+func (db *loanRepo) UpdateLoanStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status string) (uuid.UUID, error) {
+	var (
+		loan_id uuid.UUID
+	)
+
+	query := `
+		UPDATE
+			loans
+		SET
+			status = $2,
+			decided_at = now(),
+			updated_at = now()
+		WHERE
+			loan_id = $1
+		RETURNING loan_id;`
+
+	err := tx.QueryRowxContext(ctx, query, id, status).Scan(&loan_id)
+	if err != nil {
+		utils.LogError("Repo", "func UpdateLoanStatus", err)
+		return loan_id, err
+	}
+
+	return loan_id, err
+}
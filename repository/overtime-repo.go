@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type OvertimeRepo interface {
+	//Create
+	CreateOvertimeRecord(ctx context.Context, tx *sqlx.Tx, d model.OvertimeRecord) (uuid.UUID, error)
+	//Read
+	GetOvertimeRecordList(ctx context.Context, userId uuid.NullUUID, from time.Time, to time.Time) ([]model.OvertimeRecord, error)
+	GetOvertimeRecordDetail(ctx context.Context, id uuid.UUID) (model.OvertimeRecord, error)
+	GetApprovedOvertimeList(ctx context.Context, userId uuid.UUID, from time.Time, to time.Time) ([]model.OvertimeRecord, error)
+	//Update
+	UpdateOvertimeStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status string) (uuid.UUID, error)
+	ReplaceImportedOvertime(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, period string, list []model.OvertimeRecord) error
+}
+
+type overtimeRepository struct {
+	db *sqlx.DB
+}
+
+func NewOvertimeRepo(dbConn *sqlx.DB) OvertimeRepo {
+	return &overtimeRepository{
+		db: dbConn,
+	}
+}
+
+const overtimeColumns = `
+			o.overtime_id,
+			o.user_id,
+			u.name,
+			o.overtime_date,
+			o.hours,
+			o.day_type,
+			o.reason,
+			o.status,
+			o.decided_at,
+			COALESCE(o.import_period, ''),
+			o.created_at,
+			o.updated_at`
+
+func scanOvertimeRecord(row interface{ Scan(...interface{}) error }, record *model.OvertimeRecord) error {
+	return row.Scan(
+		&record.Overtime_id,
+		&record.User_id,
+		&record.Name,
+		&record.Overtime_date,
+		&record.Hours,
+		&record.Day_type,
+		&record.Reason,
+		&record.Status,
+		&record.Decided_at,
+		&record.Import_period,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+	)
+}
+
+// Overtime between from and to, of every user when userId is not set
+func (r *overtimeRepository) GetOvertimeRecordList(ctx context.Context, userId uuid.NullUUID, from time.Time, to time.Time) ([]model.OvertimeRecord, error) {
+	list := make([]model.OvertimeRecord, 0)
+
+	query := `
+		SELECT` + overtimeColumns + `
+		FROM
+			overtime_records o
+				INNER JOIN users u ON u.user_id = o.user_id
+		WHERE
+			o.is_delete = false
+			AND ($1::uuid IS NULL OR o.user_id = $1)
+			AND o.overtime_date BETWEEN $2 AND $3
+		ORDER BY o.overtime_date ASC, u.name ASC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query, userId, from, to)
+	if err != nil {
+		utils.LogError("Repo", "func GetOvertimeRecordList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var record model.OvertimeRecord
+		err = scanOvertimeRecord(rows, &record)
+		if err != nil {
+			utils.LogError("Repo", "GetOvertimeRecordList scan data", err)
+			return list, err
+		}
+		list = append(list, record)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (r *overtimeRepository) GetOvertimeRecordDetail(ctx context.Context, id uuid.UUID) (model.OvertimeRecord, error) {
+	var record model.OvertimeRecord
+
+	query := `
+		SELECT` + overtimeColumns + `
+		FROM
+			overtime_records o
+				INNER JOIN users u ON u.user_id = o.user_id
+		WHERE
+			o.overtime_id = $1 AND o.is_delete = false;
+		`
+	err := scanOvertimeRecord(r.db.QueryRowxContext(ctx, query, id), &record)
+	if err != nil {
+		utils.LogError("Repo", "func GetOvertimeRecordDetail", err)
+		return record, err
+	}
+	return record, err
+}
+
+// Approved overtime of a user worked between from and to, the input of the
+// overtime pay of a payroll period
+func (r *overtimeRepository) GetApprovedOvertimeList(ctx context.Context, userId uuid.UUID, from time.Time, to time.Time) ([]model.OvertimeRecord, error) {
+	list := make([]model.OvertimeRecord, 0)
+
+	query := `
+		SELECT` + overtimeColumns + `
+		FROM
+			overtime_records o
+				INNER JOIN users u ON u.user_id = o.user_id
+		WHERE
+			o.user_id = $1
+			AND o.status = 'approved'
+			AND o.is_delete = false
+			AND o.overtime_date BETWEEN $2 AND $3
+		ORDER BY o.overtime_date ASC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query, userId, from, to)
+	if err != nil {
+		utils.LogError("Repo", "func GetApprovedOvertimeList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var record model.OvertimeRecord
+		err = scanOvertimeRecord(rows, &record)
+		if err != nil {
+			utils.LogError("Repo", "GetApprovedOvertimeList scan data", err)
+			return list, err
+		}
+		list = append(list, record)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (r *overtimeRepository) CreateOvertimeRecord(ctx context.Context, tx *sqlx.Tx, d model.OvertimeRecord) (uuid.UUID, error) {
+	var (
+		overtime_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO
+			overtime_records (user_id, overtime_date, hours, day_type, reason)
+		VALUES
+			($1, $2, $3, $4, $5)
+		RETURNING overtime_id
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		d.User_id,
+		d.Overtime_date,
+		d.Hours,
+		d.Day_type,
+		d.Reason,
+	).Scan(
+		&overtime_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreateOvertimeRecord", err)
+		return overtime_id, err
+	}
+
+	return overtime_id, err
+}
+
+func (r *overtimeRepository) UpdateOvertimeStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status string) (uuid.UUID, error) {
+	var (
+		overtime_id uuid.UUID
+	)
+
+	query := `
+		UPDATE
+			overtime_records
+		SET
+			status = $2,
+			decided_at = now(),
+			updated_at = now()
+		WHERE
+			overtime_id = $1
+		RETURNING overtime_id
+			;
+	`
+	err := tx.QueryRowxContext(ctx, query, id, status).Scan(&overtime_id)
+	if err != nil {
+		utils.LogError("Repo", "func UpdateOvertimeStatus", err)
+		return overtime_id, err
+	}
+
+	return overtime_id, err
+}
+
+// Overtime imported with a payroll input sheet is approved as it comes in.
+// The records of an earlier import of the same period are deleted first, the
+// sheet replaces them.
+func (r *overtimeRepository) ReplaceImportedOvertime(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, period string, list []model.OvertimeRecord) error {
+	query := `
+		UPDATE
+			overtime_records
+		SET
+			is_delete = true,
+			updated_at = now()
+		WHERE
+			user_id = $1
+			AND import_period = $2
+			AND is_delete = false
+			;
+	`
+	_, err := tx.ExecContext(ctx, query, userId, period)
+	if err != nil {
+		utils.LogError("Repo", "func ReplaceImportedOvertime delete", err)
+		return err
+	}
+
+	query = `
+		INSERT INTO
+			overtime_records (overtime_id, user_id, overtime_date, hours, day_type, reason, status, decided_at, import_period)
+		VALUES
+			($1, $2, $3, $4, $5, $6, 'approved', now(), $7)
+			;
+	`
+	for _, d := range list {
+		_, err = tx.ExecContext(ctx, query, d.Overtime_id, userId, d.Overtime_date, d.Hours, d.Day_type, d.Reason, period)
+		if err != nil {
+			utils.LogError("Repo", "func ReplaceImportedOvertime insert", err)
+			return err
+		}
+	}
+	return err
+}
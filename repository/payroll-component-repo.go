@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type PayrollComponentRepo interface {
+	//Create
+	CreatePayrollComponent(ctx context.Context, tx *sqlx.Tx, c model.PayrollComponent) (string, error)
+	//Read
+	GetPayrollComponentList(ctx context.Context) ([]model.PayrollComponent, error)
+}
+
+type payrollComponentRepository struct {
+	db *sqlx.DB
+}
+
+func NewPayrollComponentRepo(dbConn *sqlx.DB) PayrollComponentRepo {
+	return &payrollComponentRepository{
+		db: dbConn,
+	}
+}
+
+func (r *payrollComponentRepository) GetPayrollComponentList(ctx context.Context) ([]model.PayrollComponent, error) {
+	list := make([]model.PayrollComponent, 0)
+
+	query := `
+		SELECT
+			c.component_code,
+			c.name,
+			c.component_type,
+			c.is_taxable,
+			c.is_bpjs_base,
+			c.is_one_off,
+			c.is_system,
+			c.created_at,
+			c.updated_at,
+			c.is_delete
+		FROM
+			payroll_components c
+		WHERE c.is_delete = false
+		ORDER BY c.component_type DESC, c.component_code ASC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollComponentList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var component model.PayrollComponent
+		err = rows.Scan(
+			&component.Component_code,
+			&component.Name,
+			&component.Component_type,
+			&component.Is_taxable,
+			&component.Is_bpjs_base,
+			&component.Is_one_off,
+			&component.Is_system,
+			&component.CreatedAt,
+			&component.UpdatedAt,
+			&component.Is_delete,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollComponentList scan data", err)
+			return list, err
+		}
+		list = append(list, component)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (r *payrollComponentRepository) CreatePayrollComponent(ctx context.Context, tx *sqlx.Tx, c model.PayrollComponent) (string, error) {
+	var (
+		component_code string
+	)
+
+	query := `
+		INSERT INTO
+			payroll_components (component_code, name, component_type, is_taxable, is_bpjs_base, is_one_off)
+		VALUES
+			($1, $2, $3, $4, $5, $6)
+		RETURNING component_code
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		c.Component_code,
+		c.Name,
+		c.Component_type,
+		c.Is_taxable,
+		c.Is_bpjs_base,
+		c.Is_one_off,
+	).Scan(
+		&component_code,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreatePayrollComponent", err)
+		return component_code, err
+	}
+
+	return component_code, err
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type PayrollItemRepo interface {
+	//Read
+	GetPayrollItemList(ctx context.Context, payrollId uuid.UUID) ([]model.PayrollItem, error)
+	//Create
+	CreatePayrollItem(ctx context.Context, tx *sqlx.Tx, item model.PayrollItem) (uuid.UUID, error)
+	//Delete
+	DeletePayrollItems(ctx context.Context, tx *sqlx.Tx, payrollId uuid.UUID) error
+}
+
+type payrollItemRepository struct {
+	db *sqlx.DB
+}
+
+func NewPayrollItemRepo(dbConn *sqlx.DB) PayrollItemRepo {
+	return &payrollItemRepository{
+		db: dbConn,
+	}
+}
+
+func (r *payrollItemRepository) GetPayrollItemList(ctx context.Context, payrollId uuid.UUID) ([]model.PayrollItem, error) {
+	list := make([]model.PayrollItem, 0)
+
+	query := `
+		SELECT
+			i.item_id,
+			i.payroll_id,
+			i.component_code,
+			c.name,
+			i.component_type,
+			i.amount,
+			i.is_taxable,
+			i.is_bpjs_base,
+			i.is_one_off,
+			i.note,
+			i.reference_id
+		FROM
+			payroll_items i
+				INNER JOIN payroll_components c ON c.component_code = i.component_code
+		WHERE i.payroll_id = $1
+		ORDER BY i.component_type DESC, i.created_at ASC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query, payrollId)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollItemList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item model.PayrollItem
+		err = rows.Scan(
+			&item.Item_id,
+			&item.Payroll_id,
+			&item.Component_code,
+			&item.Name,
+			&item.Component_type,
+			&item.Amount,
+			&item.Is_taxable,
+			&item.Is_bpjs_base,
+			&item.Is_one_off,
+			&item.Note,
+			&item.Reference_id,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollItemList scan data", err)
+			return list, err
+		}
+		list = append(list, item)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (r *payrollItemRepository) CreatePayrollItem(ctx context.Context, tx *sqlx.Tx, item model.PayrollItem) (uuid.UUID, error) {
+	var (
+		item_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO
+			payroll_items (payroll_id, component_code, component_type, amount, is_taxable, is_bpjs_base, is_one_off, note, reference_id)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING item_id
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		item.Payroll_id,
+		item.Component_code,
+		item.Component_type,
+		item.Amount,
+		item.Is_taxable,
+		item.Is_bpjs_base,
+		item.Is_one_off,
+		item.Note,
+		item.Reference_id,
+	).Scan(
+		&item_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreatePayrollItem", err)
+		return item_id, err
+	}
+
+	return item_id, err
+}
+
+func (r *payrollItemRepository) DeletePayrollItems(ctx context.Context, tx *sqlx.Tx, payrollId uuid.UUID) error {
+	query := `
+		DELETE FROM
+			payroll_items
+		WHERE
+			payroll_id = $1;
+	`
+
+	_, err := tx.ExecContext(ctx, query, payrollId)
+	if err != nil {
+		utils.LogError("Repo", "func DeletePayrollItems", err)
+		return err
+	}
+
+	return err
+}
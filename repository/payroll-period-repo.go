@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PayrollPeriodRepo interface {
+	//Create
+	CreatePayrollPeriod(ctx context.Context, tx *sqlx.Tx, p model.PayrollPeriod) (string, error)
+	CreatePayrollPeriodLog(ctx context.Context, tx *sqlx.Tx, l model.PayrollPeriodLog) (uuid.UUID, error)
+	//Read
+	GetPayrollPeriodList(ctx context.Context) ([]model.PayrollPeriod, error)
+	GetPayrollPeriod(ctx context.Context, period string) (model.PayrollPeriod, error)
+	GetPayrollPeriodLogList(ctx context.Context, period string) ([]model.PayrollPeriodLog, error)
+	CountUnsettledPayroll(ctx context.Context, period string) (int, int, error)
+	//Update
+	UpdatePayrollPeriodStatus(ctx context.Context, tx *sqlx.Tx, period string, status string) (string, error)
+}
+
+type payrollPeriodRepo struct {
+	db *sqlx.DB
+}
+
+func NewPayrollPeriodRepo(dbConn *sqlx.DB) PayrollPeriodRepo {
+	return &payrollPeriodRepo{
+		db: dbConn,
+	}
+}
+
+const payrollPeriodColumns = `
+			p.period, p.start_date, p.end_date, p.cutoff_date, p.payment_date, p.status, p.closed_at, p.created_at, p.updated_at`
+
+func scanPayrollPeriod(row interface{ Scan(...interface{}) error }, p *model.PayrollPeriod) error {
+	return row.Scan(
+		&p.Period,
+		&p.Start_date,
+		&p.End_date,
+		&p.Cutoff_date,
+		&p.Payment_date,
+		&p.Status,
+		&p.Closed_at,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+}
+
+func (db *payrollPeriodRepo) GetPayrollPeriodList(ctx context.Context) ([]model.PayrollPeriod, error) {
+	list := make([]model.PayrollPeriod, 0)
+
+	query := `
+		SELECT` + payrollPeriodColumns + `
+		FROM
+			payroll_periods p
+		WHERE p.is_delete = false
+		ORDER BY p.period DESC;`
+
+	rows, err := db.db.QueryxContext(ctx, query)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollPeriodList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var period model.PayrollPeriod
+		err = scanPayrollPeriod(rows, &period)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollPeriodList scan data", err)
+			return list, err
+		}
+		list = append(list, period)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *payrollPeriodRepo) GetPayrollPeriod(ctx context.Context, period string) (model.PayrollPeriod, error) {
+	var payrollPeriod model.PayrollPeriod
+
+	query := `
+		SELECT` + payrollPeriodColumns + `
+		FROM
+			payroll_periods p
+		WHERE
+			p.period = $1 AND p.is_delete = false;`
+
+	err := scanPayrollPeriod(db.db.QueryRowxContext(ctx, query, period), &payrollPeriod)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollPeriod", err)
+		return payrollPeriod, err
+	}
+	return payrollPeriod, err
+}
+
+// Close and reopen history of a period, oldest first
+func (db *payrollPeriodRepo) GetPayrollPeriodLogList(ctx context.Context, period string) ([]model.PayrollPeriodLog, error) {
+	list := make([]model.PayrollPeriodLog, 0)
+
+	query := `
+		SELECT
+			l.log_id, l.period, l.action, l.user_id, COALESCE(u.name, ''), l.reason, l.created_at
+		FROM
+			payroll_period_logs l
+				LEFT JOIN users u ON u.user_id = l.user_id
+		WHERE l.period = $1
+		ORDER BY l.created_at ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, period)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollPeriodLogList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var log model.PayrollPeriodLog
+		err = rows.Scan(
+			&log.Log_id,
+			&log.Period,
+			&log.Action,
+			&log.User_id,
+			&log.Name,
+			&log.Reason,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollPeriodLogList scan data", err)
+			return list, err
+		}
+		list = append(list, log)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Runs of a period that are not paid yet and records still being edited,
+// a period is only closed once both are zero
+func (db *payrollPeriodRepo) CountUnsettledPayroll(ctx context.Context, period string) (int, int, error) {
+	var runs, records int
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM payroll_runs r
+				WHERE r.payment_period = $1 AND r.is_delete = false AND r.status NOT IN ('paid', 'closed')),
+			(SELECT COUNT(*) FROM payroll_records p
+				INNER JOIN status s ON s.status_id = p.status_id
+				WHERE p.payment_period = $1 AND p.is_delete = false AND s.name NOT IN ('approved', 'paid', 'closed'));`
+
+	err := db.db.QueryRowxContext(ctx, query, period).Scan(&runs, &records)
+	if err != nil {
+		utils.LogError("Repo", "func CountUnsettledPayroll", err)
+		return runs, records, err
+	}
+	return runs, records, err
+}
+
+func (db *payrollPeriodRepo) CreatePayrollPeriod(ctx context.Context, tx *sqlx.Tx, p model.PayrollPeriod) (string, error) {
+	var (
+		period string
+	)
+
+	query := `
+		INSERT INTO payroll_periods(
+			period, start_date, end_date, cutoff_date, payment_date
+		) VALUES(
+			$1, $2, $3, $4, $5
+		) RETURNING period;`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		p.Period,
+		p.Start_date,
+		p.End_date,
+		p.Cutoff_date,
+		p.Payment_date,
+	).Scan(
+		&period,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreatePayrollPeriod", err)
+		return period, err
+	}
+
+	return period, err
+}
+
+// Opens or closes a period, closed_at keeps the time of the latest close
+func (db *payrollPeriodRepo) UpdatePayrollPeriodStatus(ctx context.Context, tx *sqlx.Tx, period string, status string) (string, error) {
+	var (
+		updated string
+	)
+
+	query := `
+		UPDATE
+			payroll_periods
+		SET
+			status = $2,
+			closed_at = CASE WHEN $2 = 'closed' THEN now() ELSE closed_at END,
+			updated_at = now()
+		WHERE
+			period = $1
+		RETURNING period;`
+
+	err := tx.QueryRowxContext(ctx, query, period, status).Scan(&updated)
+	if err != nil {
+		utils.LogError("Repo", "func UpdatePayrollPeriodStatus", err)
+		return updated, err
+	}
+
+	return updated, err
+}
+
+func (db *payrollPeriodRepo) CreatePayrollPeriodLog(ctx context.Context, tx *sqlx.Tx, l model.PayrollPeriodLog) (uuid.UUID, error) {
+	var (
+		log_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO payroll_period_logs(
+			period, action, user_id, reason
+		) VALUES(
+			$1, $2, $3, $4
+		) RETURNING log_id;`
+
+	err := tx.QueryRowxContext(ctx, query, l.Period, l.Action, l.User_id, l.Reason).Scan(&log_id)
+	if err != nil {
+		utils.LogError("Repo", "func CreatePayrollPeriodLog", err)
+		return log_id, err
+	}
+
+	return log_id, err
+}
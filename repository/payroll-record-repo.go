@@ -2,8 +2,8 @@ package repository
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/dafiqarba/be-payroll/model"
 	"github.com/dafiqarba/be-payroll/utils"
@@ -13,14 +13,29 @@ import (
 
 type PayrollRecordRepo interface {
 	//Read
-	GetPayrollRecordList(ctx context.Context) ([]model.PayrollRecordListModel, error)
+	GetPayrollRecordList(ctx context.Context, f model.PayrollRecordFilter) ([]model.PayrollRecordListModel, int, error)
 	GetPayrollRecordDetail(ctx context.Context, id uuid.UUID) (model.PayrollRecordDetailModel, error)
+	GetPayrollRecord(ctx context.Context, id uuid.UUID) (model.PayrollRecord, error)
+	GetLatestPayrollRecord(ctx context.Context, userId uuid.UUID) (model.PayrollRecord, error)
+	GetPayrollRecordByPeriod(ctx context.Context, userId uuid.UUID, period string, runType string) (model.PayrollRecord, error)
+	GetPayrollRecordListByRun(ctx context.Context, runId uuid.UUID) ([]model.PayrollRecordListModel, error)
+	GetPayslipRecordList(ctx context.Context, period string) ([]model.PayrollRecordListModel, error)
+	GetPayslipCorrectionList(ctx context.Context, id uuid.UUID) ([]model.PayslipCorrection, error)
+	GetBankTransferList(ctx context.Context, period string, runType string, statusName string) ([]model.BankTransferLine, error)
+	GetPayrollCorrectionList(ctx context.Context, id uuid.UUID) ([]model.PayrollRecordListModel, error)
+	HasPaidPayrollRecordSince(ctx context.Context, userId uuid.UUID, period string) (bool, error)
 	//Create
 	// CreatePayrollRecord(ctx context.Context, tx *sqlx.Tx, p model.PayrollRecord) (model.PayrollRecord, error)
 	CreatePayrollRecord(ctx context.Context, tx *sqlx.Tx, p model.PayrollRecord) (uuid.UUID, error)
 	//Update
 	UpdatePayrollRecord(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, p model.PayrollRecord) (uuid.UUID, error)
 	// UpdatePayrollRecord(ctx context.Context, id int, p model.PayrollRecord) (model.PayrollRecord, error)
+	UpdatePayrollRecordStatusByRun(ctx context.Context, tx *sqlx.Tx, runId uuid.UUID, statusId uuid.UUID) error
+	AttachPayrollCorrectionsToRun(ctx context.Context, tx *sqlx.Tx, runId uuid.UUID, period string, statusId uuid.UUID) error
+	//Tax
+	GetPph21YearToDate(ctx context.Context, userId uuid.UUID, period string) (model.Pph21YearToDate, error)
+	GetPayrollRecordListByYear(ctx context.Context, userId uuid.UUID, year int) ([]model.PayrollRecord, error)
+	GetTaxYearUserList(ctx context.Context, year int) ([]uuid.UUID, error)
 }
 
 type payrollRecordRepo struct {
@@ -33,35 +48,86 @@ func NewPayrollRecordRepo(db *sqlx.DB) PayrollRecordRepo {
 	}
 }
 
-func (db *payrollRecordRepo) GetPayrollRecordList(ctx context.Context) ([]model.PayrollRecordListModel, error) {
-	payrollRecordList := make([]model.PayrollRecordListModel, 0)
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
 
-	// err := db.connection.QueryRow("SELECT * FROM payroll_records WHERE employee_id = ? AND year = ?", id, year).Scan(&payrollRecordList)
+// Columns the payroll list can be sorted by, keyed by the sort parameter
+var payrollRecordSortColumns = map[string]string{
+	"payment_period": "p.payment_period",
+	"payment_date":   "p.payment_date",
+	"name":           "u.name",
+	"status":         "s.name",
+	"total_salary":   "p.total_salary",
+	"created_at":     "p.created_at",
+}
 
-	// query := fmt.Sprintf(`
-	// 	SELECT
-	// 		p.payroll_id, u.name, p.payment_period, p.payment_date, s.status_name
-	// 	FROM
-	// 		payroll_records p
-	// 			INNER JOIN status s ON p.status_id = s.status_id
-	// 			INNER JOIN users u ON p.user_id = u.user_id
-	// 	WHERE
-	// 		p.user_id = %v AND p.payment_date = '%v';`, id, year)
+// One page of the payroll list matching the filter, along with the number
+// of matching rows. The filter is expected to be validated by the caller.
+func (db *payrollRecordRepo) GetPayrollRecordList(ctx context.Context, f model.PayrollRecordFilter) ([]model.PayrollRecordListModel, int, error) {
+	var (
+		payrollRecordList = make([]model.PayrollRecordListModel, 0)
+		total             int
+		args              []interface{}
+	)
 
-	query := `
-		SELECT
-			p.payroll_id, u.name, p.payment_period, p.payment_date, s.status_name
+	where := "p.is_delete = false"
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		where += " AND " + strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args)))
+	}
+	if f.Period_from != "" {
+		add("p.payment_period >= ?", f.Period_from)
+	}
+	if f.Period_to != "" {
+		add("p.payment_period <= ?", f.Period_to)
+	}
+	if f.User_id.Valid {
+		add("p.user_id = ?", f.User_id.UUID)
+	}
+	if f.Position_id.Valid {
+		add("u.position_id = ?", f.Position_id.UUID)
+	}
+	if f.Status != "" {
+		add("s.name = ?", f.Status)
+	}
+	if f.Payment_date_from != "" {
+		add("p.payment_date >= ?", f.Payment_date_from)
+	}
+	if f.Payment_date_to != "" {
+		add("p.payment_date <= ?", f.Payment_date_to)
+	}
+	if f.Search != "" {
+		// The search is matched literally, wildcards typed by the user included
+		add("u.name ILIKE ?", "%"+likeEscaper.Replace(f.Search)+"%")
+	}
+
+	from := `
 		FROM
 			payroll_records p
 				INNER JOIN status s ON p.status_id = s.status_id
 				INNER JOIN users u ON p.user_id = u.user_id
-		;`
+		WHERE
+			` + where
 
-	rows, err := db.connection.QueryxContext(ctx, query)
+	err := db.connection.QueryRowxContext(ctx, `SELECT COUNT(*)`+from+`;`, args...).Scan(&total)
+	if err != nil {
+		utils.LogError("Repo", "GetPayrollRecordList count", err)
+		return payrollRecordList, total, err
+	}
+
+	// payroll_id breaks ties so pages never overlap
+	order := payrollRecordSortColumns[f.Sort] + " " + f.Order + ", u.name ASC, p.payroll_id ASC"
+	args = append(args, f.Page_size, (f.Page-1)*f.Page_size)
+	query := `
+		SELECT
+			p.payroll_id, u.name, p.payment_period, p.payment_date, s.name, p.record_type` + from + `
+		ORDER BY ` + order + `
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args)) + `;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, args...)
 
 	if err != nil {
 		utils.LogError("Repo", "GetPayrollRecordList", err)
-		return payrollRecordList, err
+		return payrollRecordList, total, err
 	}
 
 	defer rows.Close()
@@ -75,24 +141,19 @@ func (db *payrollRecordRepo) GetPayrollRecordList(ctx context.Context) ([]model.
 			&payrollRecord.Payment_period,
 			&payrollRecord.Payment_date,
 			&payrollRecord.Status_name,
+			&payrollRecord.Record_type,
 		)
 
 		if err != nil {
 			utils.LogError("Repo", "GetPayrollRecordList scan data", err)
-			return payrollRecordList, err
+			return payrollRecordList, total, err
 		}
 		payrollRecordList = append(payrollRecordList, payrollRecord)
 	}
 
-	if len(payrollRecordList) == 0 {
-		err := errors.New("sql: no data found")
-		utils.LogError("Repo", "GetPayrollRecordList", err)
-		return payrollRecordList, err
-	}
-
 	utils.CloseDB(rows)
 
-	return payrollRecordList, err
+	return payrollRecordList, total, err
 }
 
 func (db *payrollRecordRepo) GetPayrollRecordDetail(ctx context.Context, id uuid.UUID) (model.PayrollRecordDetailModel, error) {
@@ -101,26 +162,36 @@ func (db *payrollRecordRepo) GetPayrollRecordDetail(ctx context.Context, id uuid
 	)
 
 	// err := db.connection.QueryRow("SELECT * FROM payroll_records WHERE employee_id = ? AND year = ?", id, year).Scan(&payrollRecord)
-	query := fmt.Sprintf(`
+	query := `
 		SELECT
-			p.payroll_id, u.name, p.payment_period, p.payment_date, p.basic_salary, p.bpjs, p.tax, p.total_salary, s.status_name
+			p.payroll_id, p.user_id, u.name, p.payment_period, p.payment_date, p.basic_salary, COALESCE(p.allowance, 0), p.bpjs, p.tax, p.tax_method, p.tax_detail, p.total_salary, s.name, p.run_id, p.proration,
+			p.record_type, p.corrects_payroll_id, p.correction_reason
 		FROM
 			payroll_records p
 				INNER JOIN status s ON p.status_id = s.status_id
 				INNER JOIN users u ON p.user_id = u.user_id
 		WHERE
-			p.payroll_id = %v;`, id)
+			p.payroll_id = $1;`
 
-	err := db.connection.QueryRowxContext(ctx, query).Scan(
+	err := db.connection.QueryRowxContext(ctx, query, id).Scan(
 		&payrollRecord.Payroll_id,
+		&payrollRecord.User_id,
 		&payrollRecord.Name,
 		&payrollRecord.Payment_period,
 		&payrollRecord.Payment_date,
 		&payrollRecord.Basic_salary,
+		&payrollRecord.Allowance,
 		&payrollRecord.Bpjs,
 		&payrollRecord.Tax,
+		&payrollRecord.Tax_method,
+		&payrollRecord.Tax_detail,
 		&payrollRecord.Total_salary,
 		&payrollRecord.Status_name,
+		&payrollRecord.Run_id,
+		&payrollRecord.Proration,
+		&payrollRecord.Record_type,
+		&payrollRecord.Corrects_payroll_id,
+		&payrollRecord.Correction_reason,
 	)
 
 	if err != nil {
@@ -156,9 +227,10 @@ func (db *payrollRecordRepo) CreatePayrollRecord(ctx context.Context, tx *sqlx.T
 
 	query := `
 		INSERT INTO payroll_records(
-			user_id, payment_period, payment_date, basic_salary, bpjs, tax, total_salary, status_id
+			user_id, payment_period, payment_date, basic_salary, bpjs, tax, total_salary, status_id, tax_method, tax_detail, allowance, run_id, proration,
+			record_type, corrects_payroll_id, correction_reason, earned_period
 		) VALUES(
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, COALESCE(NULLIF($14, ''), 'regular'), $15, $16, COALESCE(NULLIF($17, ''), $2)
 		) RETURNING payroll_id;`
 
 	err := tx.QueryRowxContext(
@@ -172,6 +244,15 @@ func (db *payrollRecordRepo) CreatePayrollRecord(ctx context.Context, tx *sqlx.T
 		p.Tax,
 		p.Total_salary,
 		p.Status_id,
+		p.Tax_method,
+		p.Tax_detail,
+		p.Allowance,
+		p.Run_id,
+		p.Proration,
+		p.Record_type,
+		p.Corrects_payroll_id,
+		p.Correction_reason,
+		p.Earned_period,
 	).Scan(
 		&user_id,
 	)
@@ -204,9 +285,12 @@ func (db *payrollRecordRepo) CreatePayrollRecord(ctx context.Context, tx *sqlx.T
 func (db *payrollRecordRepo) UpdatePayrollRecord(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, p model.PayrollRecord) (uuid.UUID, error) {
 	query := `
 		UPDATE payroll_records SET
-			user_id = $1, payment_period = $2, payment_date = $3, basic_salary = $4, bpjs = $5, tax = $6, total_salary = $7, status_id = $8
+			user_id = $1, payment_period = $2, payment_date = $3, basic_salary = $4, bpjs = $5, tax = $6, total_salary = $7, status_id = $8,
+			tax_method = $9, tax_detail = $10, allowance = $11, proration = $12, run_id = COALESCE($14, run_id),
+			earned_period = CASE WHEN record_type = 'regular' THEN $2 ELSE earned_period END, updated_at = now()
 		WHERE
-			payroll_id = $9;`
+			payroll_id = $13
+		RETURNING payroll_id;`
 
 	err := tx.QueryRowxContext(
 		ctx,
@@ -219,15 +303,565 @@ func (db *payrollRecordRepo) UpdatePayrollRecord(ctx context.Context, tx *sqlx.T
 		p.Tax,
 		p.Total_salary,
 		p.Status_id,
-		p.Payroll_id,
+		p.Tax_method,
+		p.Tax_detail,
+		p.Allowance,
+		p.Proration,
+		id,
+		p.Run_id,
 	).Scan(
 		&p.Payroll_id,
 	)
 
 	if err != nil {
-		utils.LogError("Repo", "func UpdateLeaveBalance", err)
+		utils.LogError("Repo", "func UpdatePayrollRecord", err)
 		return p.Payroll_id, err
 	}
 
 	return p.Payroll_id, err
 }
+
+const payrollRecordColumns = `
+			p.payroll_id, p.payment_period, p.payment_date, p.basic_salary, COALESCE(p.allowance, 0), p.bpjs, p.tax, p.tax_method, p.tax_detail,
+			p.total_salary, p.status_id, p.user_id, p.run_id, p.proration,
+			p.record_type, p.corrects_payroll_id, p.correction_reason, p.earned_period, p.created_at, p.updated_at, p.is_delete`
+
+func scanPayrollRecord(row interface{ Scan(...interface{}) error }, p *model.PayrollRecord) error {
+	return row.Scan(
+		&p.Payroll_id,
+		&p.Payment_period,
+		&p.Payment_date,
+		&p.Basic_salary,
+		&p.Allowance,
+		&p.Bpjs,
+		&p.Tax,
+		&p.Tax_method,
+		&p.Tax_detail,
+		&p.Total_salary,
+		&p.Status_id,
+		&p.User_id,
+		&p.Run_id,
+		&p.Proration,
+		&p.Record_type,
+		&p.Corrects_payroll_id,
+		&p.Correction_reason,
+		&p.Earned_period,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Is_delete,
+	)
+}
+
+func (db *payrollRecordRepo) GetPayrollRecord(ctx context.Context, id uuid.UUID) (model.PayrollRecord, error) {
+	var (
+		payrollRecord model.PayrollRecord
+	)
+
+	query := `
+		SELECT` + payrollRecordColumns + `
+		FROM
+			payroll_records p
+		WHERE
+			p.payroll_id = $1 AND p.is_delete = false;`
+
+	err := scanPayrollRecord(db.connection.QueryRowxContext(ctx, query, id), &payrollRecord)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollRecord", err)
+		return payrollRecord, err
+	}
+
+	return payrollRecord, err
+}
+
+// Most recent regular payroll record of a user, the template for the next
+// run. Records of special runs such as THR and corrections are left out.
+func (db *payrollRecordRepo) GetLatestPayrollRecord(ctx context.Context, userId uuid.UUID) (model.PayrollRecord, error) {
+	var (
+		payrollRecord model.PayrollRecord
+	)
+
+	query := `
+		SELECT` + payrollRecordColumns + `
+		FROM
+			payroll_records p
+				LEFT JOIN payroll_runs r ON r.run_id = p.run_id
+		WHERE
+			p.user_id = $1 AND p.is_delete = false AND p.record_type = 'regular'
+			AND (r.run_type IS NULL OR r.run_type = 'regular')
+		ORDER BY p.payment_period DESC, p.created_at DESC
+		LIMIT 1;`
+
+	err := scanPayrollRecord(db.connection.QueryRowxContext(ctx, query, userId), &payrollRecord)
+	if err != nil {
+		utils.LogError("Repo", "func GetLatestPayrollRecord", err)
+		return payrollRecord, err
+	}
+
+	return payrollRecord, err
+}
+
+// Payroll record of a user in a period for a run type, records created
+// outside of a run count as regular. Corrections booked in the period are
+// left out.
+func (db *payrollRecordRepo) GetPayrollRecordByPeriod(ctx context.Context, userId uuid.UUID, period string, runType string) (model.PayrollRecord, error) {
+	var (
+		payrollRecord model.PayrollRecord
+	)
+
+	query := `
+		SELECT` + payrollRecordColumns + `
+		FROM
+			payroll_records p
+				LEFT JOIN payroll_runs r ON r.run_id = p.run_id
+		WHERE
+			p.user_id = $1 AND p.payment_period = $2 AND p.is_delete = false AND p.record_type = 'regular'
+			AND COALESCE(r.run_type, 'regular') = $3
+		ORDER BY p.created_at DESC
+		LIMIT 1;`
+
+	err := scanPayrollRecord(db.connection.QueryRowxContext(ctx, query, userId, period, runType), &payrollRecord)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollRecordByPeriod", err)
+		return payrollRecord, err
+	}
+
+	return payrollRecord, err
+}
+
+// Whether a regular record of the user from the period on was already paid
+func (db *payrollRecordRepo) HasPaidPayrollRecordSince(ctx context.Context, userId uuid.UUID, period string) (bool, error) {
+	var paid bool
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM
+				payroll_records p
+					INNER JOIN status s ON p.status_id = s.status_id
+					LEFT JOIN payroll_runs r ON r.run_id = p.run_id
+			WHERE
+				p.user_id = $1 AND p.payment_period >= $2 AND p.is_delete = false AND p.record_type = 'regular'
+				AND COALESCE(r.run_type, 'regular') = 'regular'
+				AND s.name IN ('paid', 'closed')
+		);`
+
+	err := db.connection.QueryRowxContext(ctx, query, userId, period).Scan(&paid)
+	if err != nil {
+		utils.LogError("Repo", "func HasPaidPayrollRecordSince", err)
+		return paid, err
+	}
+
+	return paid, err
+}
+
+func (db *payrollRecordRepo) GetPayrollRecordListByRun(ctx context.Context, runId uuid.UUID) ([]model.PayrollRecordListModel, error) {
+	payrollRecordList := make([]model.PayrollRecordListModel, 0)
+
+	query := `
+		SELECT
+			p.payroll_id, u.name, p.payment_period, p.payment_date, s.name, p.record_type
+		FROM
+			payroll_records p
+				INNER JOIN status s ON p.status_id = s.status_id
+				INNER JOIN users u ON p.user_id = u.user_id
+		WHERE
+			p.run_id = $1 AND p.is_delete = false
+		ORDER BY u.name ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, runId)
+
+	if err != nil {
+		utils.LogError("Repo", "GetPayrollRecordListByRun", err)
+		return payrollRecordList, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var payrollRecord model.PayrollRecordListModel
+
+		err = rows.Scan(
+			&payrollRecord.Payroll_id,
+			&payrollRecord.Name,
+			&payrollRecord.Payment_period,
+			&payrollRecord.Payment_date,
+			&payrollRecord.Status_name,
+			&payrollRecord.Record_type,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollRecordListByRun scan data", err)
+			return payrollRecordList, err
+		}
+		payrollRecordList = append(payrollRecordList, payrollRecord)
+	}
+
+	utils.CloseDB(rows)
+
+	return payrollRecordList, err
+}
+
+// Regular records of a period that get a payslip, only once they are
+// approved. Corrections are left out, they are shown on the regular payslip
+// of the period they are booked in.
+func (db *payrollRecordRepo) GetPayslipRecordList(ctx context.Context, period string) ([]model.PayrollRecordListModel, error) {
+	payrollRecordList := make([]model.PayrollRecordListModel, 0)
+
+	query := `
+		SELECT
+			p.payroll_id, u.name, p.payment_period, p.payment_date, s.name, p.record_type
+		FROM
+			payroll_records p
+				INNER JOIN status s ON p.status_id = s.status_id
+				INNER JOIN users u ON p.user_id = u.user_id
+		WHERE
+			p.payment_period = $1 AND p.is_delete = false AND p.record_type = 'regular'
+			AND s.name IN ('approved', 'paid', 'closed')
+		ORDER BY u.name ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, period)
+
+	if err != nil {
+		utils.LogError("Repo", "GetPayslipRecordList", err)
+		return payrollRecordList, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var payrollRecord model.PayrollRecordListModel
+
+		err = rows.Scan(
+			&payrollRecord.Payroll_id,
+			&payrollRecord.Name,
+			&payrollRecord.Payment_period,
+			&payrollRecord.Payment_date,
+			&payrollRecord.Status_name,
+			&payrollRecord.Record_type,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetPayslipRecordList scan data", err)
+			return payrollRecordList, err
+		}
+		payrollRecordList = append(payrollRecordList, payrollRecord)
+	}
+
+	utils.CloseDB(rows)
+
+	return payrollRecordList, err
+}
+
+// Approved corrections settled on the payslip of a regular record, i.e. the
+// reversals and adjustments of the same user booked in its period. Payslips
+// of THR runs and of corrections themselves settle none.
+func (db *payrollRecordRepo) GetPayslipCorrectionList(ctx context.Context, id uuid.UUID) ([]model.PayslipCorrection, error) {
+	list := make([]model.PayslipCorrection, 0)
+
+	query := `
+		SELECT
+			c.payroll_id, c.record_type, o.payment_period, c.correction_reason, c.total_salary
+		FROM
+			payroll_records p
+				LEFT JOIN payroll_runs r ON r.run_id = p.run_id
+				INNER JOIN payroll_records c ON c.user_id = p.user_id AND c.payment_period = p.payment_period
+				INNER JOIN status s ON c.status_id = s.status_id
+				INNER JOIN payroll_records o ON o.payroll_id = c.corrects_payroll_id
+		WHERE
+			p.payroll_id = $1 AND p.record_type = 'regular' AND COALESCE(r.run_type, 'regular') = 'regular'
+			AND c.record_type <> 'regular' AND c.is_delete = false
+			AND s.name IN ('approved', 'paid', 'closed')
+		ORDER BY c.created_at ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, id)
+	if err != nil {
+		utils.LogError("Repo", "GetPayslipCorrectionList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var correction model.PayslipCorrection
+		err = rows.Scan(
+			&correction.Payroll_id,
+			&correction.Record_type,
+			&correction.Corrected_period,
+			&correction.Correction_reason,
+			&correction.Total_salary,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetPayslipCorrectionList scan data", err)
+			return list, err
+		}
+		list = append(list, correction)
+	}
+
+	utils.CloseDB(rows)
+
+	return list, err
+}
+
+// Net salary and salary account of every user paid by the run of a type in a
+// period in a status, records created outside of a run count as regular.
+// Corrections booked in the period are netted into the user's single line.
+func (db *payrollRecordRepo) GetBankTransferList(ctx context.Context, period string, runType string, statusName string) ([]model.BankTransferLine, error) {
+	list := make([]model.BankTransferLine, 0)
+
+	query := `
+		SELECT
+			(array_agg(p.payroll_id ORDER BY p.created_at ASC))[1], u.user_id, u.name, u.nik, u.email,
+			COALESCE(u.bank_code, ''), COALESCE(u.bank_account_number, ''), COALESCE(u.bank_account_name, ''),
+			SUM(p.total_salary)
+		FROM
+			payroll_records p
+				INNER JOIN status s ON p.status_id = s.status_id
+				INNER JOIN users u ON p.user_id = u.user_id
+				LEFT JOIN payroll_runs r ON r.run_id = p.run_id
+		WHERE
+			p.payment_period = $1 AND s.name = $2 AND p.is_delete = false
+			AND COALESCE(r.run_type, 'regular') = $3
+		GROUP BY u.user_id
+		ORDER BY u.name ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, period, statusName, runType)
+	if err != nil {
+		utils.LogError("Repo", "GetBankTransferList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var line model.BankTransferLine
+		err = rows.Scan(
+			&line.Payroll_id,
+			&line.User_id,
+			&line.Name,
+			&line.Nik,
+			&line.Email,
+			&line.Bank_code,
+			&line.Bank_account_number,
+			&line.Bank_account_name,
+			&line.Amount,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetBankTransferList scan data", err)
+			return list, err
+		}
+		list = append(list, line)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Reversals and adjustments that correct a payroll record
+func (db *payrollRecordRepo) GetPayrollCorrectionList(ctx context.Context, id uuid.UUID) ([]model.PayrollRecordListModel, error) {
+	payrollRecordList := make([]model.PayrollRecordListModel, 0)
+
+	query := `
+		SELECT
+			p.payroll_id, u.name, p.payment_period, p.payment_date, s.name, p.record_type
+		FROM
+			payroll_records p
+				INNER JOIN status s ON p.status_id = s.status_id
+				INNER JOIN users u ON p.user_id = u.user_id
+		WHERE
+			p.corrects_payroll_id = $1 AND p.is_delete = false
+		ORDER BY p.created_at ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, id)
+
+	if err != nil {
+		utils.LogError("Repo", "GetPayrollCorrectionList", err)
+		return payrollRecordList, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var payrollRecord model.PayrollRecordListModel
+
+		err = rows.Scan(
+			&payrollRecord.Payroll_id,
+			&payrollRecord.Name,
+			&payrollRecord.Payment_period,
+			&payrollRecord.Payment_date,
+			&payrollRecord.Status_name,
+			&payrollRecord.Record_type,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollCorrectionList scan data", err)
+			return payrollRecordList, err
+		}
+		payrollRecordList = append(payrollRecordList, payrollRecord)
+	}
+
+	utils.CloseDB(rows)
+
+	return payrollRecordList, err
+}
+
+// Moves every record of a run to the run's status
+func (db *payrollRecordRepo) UpdatePayrollRecordStatusByRun(ctx context.Context, tx *sqlx.Tx, runId uuid.UUID, statusId uuid.UUID) error {
+	query := `
+		UPDATE payroll_records SET
+			status_id = $1, updated_at = now()
+		WHERE
+			run_id = $2 AND is_delete = false;`
+
+	_, err := tx.ExecContext(ctx, query, statusId, runId)
+	if err != nil {
+		utils.LogError("Repo", "func UpdatePayrollRecordStatusByRun", err)
+		return err
+	}
+
+	return err
+}
+
+// Corrections waiting for the run of their period join it when it opens
+func (db *payrollRecordRepo) AttachPayrollCorrectionsToRun(ctx context.Context, tx *sqlx.Tx, runId uuid.UUID, period string, statusId uuid.UUID) error {
+	query := `
+		UPDATE payroll_records SET
+			run_id = $1, status_id = $2, updated_at = now()
+		WHERE
+			payment_period = $3 AND run_id IS NULL AND record_type <> 'regular' AND is_delete = false;`
+
+	_, err := tx.ExecContext(ctx, query, runId, statusId, period)
+	if err != nil {
+		utils.LogError("Repo", "func AttachPayrollCorrectionsToRun", err)
+		return err
+	}
+
+	return err
+}
+
+// Sums what was already reported for PPh 21 in the same tax year, before
+// period. Records still in draft or review are not reported yet, and
+// corrections count in the tax year their salary was earned in.
+func (db *payrollRecordRepo) GetPph21YearToDate(ctx context.Context, userId uuid.UUID, period string) (model.Pph21YearToDate, error) {
+	var (
+		ytd model.Pph21YearToDate
+	)
+
+	query := `
+		SELECT
+			COALESCE(SUM((p.tax_detail->>'taxable_income')::bigint), 0),
+			COALESCE(SUM((p.tax_detail->>'pension_deduction')::bigint), 0),
+			COALESCE(SUM(p.tax), 0),
+			COUNT(DISTINCT p.earned_period)
+		FROM
+			payroll_records p
+				INNER JOIN status s ON s.status_id = p.status_id
+		WHERE
+			p.user_id = $1
+			AND p.is_delete = false
+			AND p.earned_period LIKE $2
+			AND p.earned_period < $3
+			AND s.name IN ('approved', 'paid', 'closed');`
+
+	err := db.connection.QueryRowxContext(
+		ctx,
+		query,
+		userId,
+		period[:4]+"-%",
+		period,
+	).Scan(
+		&ytd.Gross_income,
+		&ytd.Pension_deduction,
+		&ytd.Tax,
+		&ytd.Months,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func GetPph21YearToDate", err)
+		return ytd, err
+	}
+
+	return ytd, err
+}
+
+// Payroll records of a user in a tax year that are approved, the input
+// of the 1721-A1 certificate. Corrections belong to the year they correct.
+func (db *payrollRecordRepo) GetPayrollRecordListByYear(ctx context.Context, userId uuid.UUID, year int) ([]model.PayrollRecord, error) {
+	list := make([]model.PayrollRecord, 0)
+
+	query := `
+		SELECT` + payrollRecordColumns + `
+		FROM
+			payroll_records p
+				INNER JOIN status s ON s.status_id = p.status_id
+		WHERE
+			p.user_id = $1
+			AND p.is_delete = false
+			AND p.earned_period LIKE $2
+			AND s.name IN ('approved', 'paid', 'closed')
+		ORDER BY p.earned_period ASC, p.created_at ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, userId, strconv.Itoa(year)+"-%")
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollRecordListByYear", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var payrollRecord model.PayrollRecord
+		err = scanPayrollRecord(rows, &payrollRecord)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollRecordListByYear scan data", err)
+			return list, err
+		}
+		list = append(list, payrollRecord)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Users paid in a tax year ordered by NIK, the order also numbers the
+// 1721-A1 certificates
+func (db *payrollRecordRepo) GetTaxYearUserList(ctx context.Context, year int) ([]uuid.UUID, error) {
+	list := make([]uuid.UUID, 0)
+
+	query := `
+		SELECT
+			u.user_id
+		FROM
+			users u
+		WHERE EXISTS (
+			SELECT 1
+			FROM
+				payroll_records p
+					INNER JOIN status s ON s.status_id = p.status_id
+			WHERE
+				p.user_id = u.user_id
+				AND p.is_delete = false
+				AND p.earned_period LIKE $1
+				AND s.name IN ('approved', 'paid', 'closed')
+		)
+		ORDER BY u.nik ASC;`
+
+	rows, err := db.connection.QueryxContext(ctx, query, strconv.Itoa(year)+"-%")
+	if err != nil {
+		utils.LogError("Repo", "func GetTaxYearUserList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			utils.LogError("Repo", "GetTaxYearUserList scan data", err)
+			return list, err
+		}
+		list = append(list, id)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
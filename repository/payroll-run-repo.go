@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type PayrollRunRepo interface {
+	//Create
+	CreatePayrollRun(ctx context.Context, tx *sqlx.Tx, r model.PayrollRun) (uuid.UUID, error)
+	//Read
+	GetPayrollRunList(ctx context.Context) ([]model.PayrollRun, error)
+	GetPayrollRunDetail(ctx context.Context, id uuid.UUID) (model.PayrollRun, error)
+	GetPayrollRunByPeriod(ctx context.Context, period string, runType string) (model.PayrollRun, error)
+	//Update
+	UpdatePayrollRunStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status string) (uuid.UUID, error)
+}
+
+type payrollRunRepository struct {
+	db *sqlx.DB
+}
+
+func NewPayrollRunRepo(dbConn *sqlx.DB) PayrollRunRepo {
+	return &payrollRunRepository{
+		db: dbConn,
+	}
+}
+
+const payrollRunColumns = `
+			r.run_id,
+			r.payment_period,
+			r.payment_date,
+			r.run_type,
+			r.status,
+			COALESCE(r.holiday_name, ''),
+			r.holiday_date,
+			r.reviewed_at,
+			r.approved_at,
+			r.paid_at,
+			r.closed_at,
+			r.created_at,
+			r.updated_at,
+			r.is_delete`
+
+func scanPayrollRun(row interface{ Scan(...interface{}) error }, run *model.PayrollRun) error {
+	return row.Scan(
+		&run.Run_id,
+		&run.Payment_period,
+		&run.Payment_date,
+		&run.Run_type,
+		&run.Status,
+		&run.Holiday_name,
+		&run.Holiday_date,
+		&run.Reviewed_at,
+		&run.Approved_at,
+		&run.Paid_at,
+		&run.Closed_at,
+		&run.CreatedAt,
+		&run.UpdatedAt,
+		&run.Is_delete,
+	)
+}
+
+func (r *payrollRunRepository) GetPayrollRunList(ctx context.Context) ([]model.PayrollRun, error) {
+	list := make([]model.PayrollRun, 0)
+
+	query := `
+		SELECT` + payrollRunColumns + `
+		FROM
+			payroll_runs r
+		WHERE r.is_delete = false
+		ORDER BY r.payment_period DESC, r.created_at DESC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollRunList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var run model.PayrollRun
+		err = scanPayrollRun(rows, &run)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollRunList scan data", err)
+			return list, err
+		}
+		list = append(list, run)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (r *payrollRunRepository) GetPayrollRunDetail(ctx context.Context, id uuid.UUID) (model.PayrollRun, error) {
+	var (
+		run model.PayrollRun
+	)
+
+	query := `
+		SELECT` + payrollRunColumns + `
+		FROM
+			payroll_runs r
+		WHERE r.run_id = $1 AND r.is_delete = false;
+		`
+
+	err := scanPayrollRun(r.db.QueryRowxContext(ctx, query, id), &run)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollRunDetail", err)
+		return run, err
+	}
+
+	return run, err
+}
+
+func (r *payrollRunRepository) GetPayrollRunByPeriod(ctx context.Context, period string, runType string) (model.PayrollRun, error) {
+	var (
+		run model.PayrollRun
+	)
+
+	query := `
+		SELECT` + payrollRunColumns + `
+		FROM
+			payroll_runs r
+		WHERE r.payment_period = $1 AND r.run_type = $2 AND r.is_delete = false;
+		`
+
+	err := scanPayrollRun(r.db.QueryRowxContext(ctx, query, period, runType), &run)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollRunByPeriod", err)
+		return run, err
+	}
+
+	return run, err
+}
+
+func (r *payrollRunRepository) CreatePayrollRun(ctx context.Context, tx *sqlx.Tx, run model.PayrollRun) (uuid.UUID, error) {
+	var (
+		run_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO
+			payroll_runs (payment_period, payment_date, run_type, status, holiday_name, holiday_date)
+		VALUES
+			($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING run_id
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		run.Payment_period,
+		run.Payment_date,
+		run.Run_type,
+		run.Status,
+		run.Holiday_name,
+		run.Holiday_date,
+	).Scan(
+		&run_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreatePayrollRun", err)
+		return run_id, err
+	}
+
+	return run_id, err
+}
+
+// Moves the run to status and stamps the matching timestamp column
+func (r *payrollRunRepository) UpdatePayrollRunStatus(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, status string) (uuid.UUID, error) {
+	var (
+		run_id uuid.UUID
+	)
+
+	query := `
+		UPDATE
+			payroll_runs
+		SET
+			status = $2,
+			reviewed_at = CASE WHEN $2 = 'reviewed' THEN now() ELSE reviewed_at END,
+			approved_at = CASE WHEN $2 = 'approved' THEN now() ELSE approved_at END,
+			paid_at = CASE WHEN $2 = 'paid' THEN now() ELSE paid_at END,
+			closed_at = CASE WHEN $2 = 'closed' THEN now() ELSE closed_at END,
+			updated_at = now()
+		WHERE
+			run_id = $1
+		RETURNING run_id
+			;
+	`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		id,
+		status,
+	).Scan(
+		&run_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func UpdatePayrollRunStatus", err)
+		return run_id, err
+	}
+
+	return run_id, err
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type PayslipDeliveryRepo interface {
+	//Create
+	CreatePayslipDelivery(ctx context.Context, tx *sqlx.Tx, d model.PayslipDelivery) (uuid.UUID, error)
+	//Read
+	GetPayslipDeliveryList(ctx context.Context, period string) ([]model.PayslipDelivery, error)
+	//Update
+	UpdatePayslipDelivery(ctx context.Context, tx *sqlx.Tx, d model.PayslipDelivery) (uuid.UUID, error)
+}
+
+type payslipDeliveryRepository struct {
+	db *sqlx.DB
+}
+
+func NewPayslipDeliveryRepo(dbConn *sqlx.DB) PayslipDeliveryRepo {
+	return &payslipDeliveryRepository{
+		db: dbConn,
+	}
+}
+
+func (r *payslipDeliveryRepository) GetPayslipDeliveryList(ctx context.Context, period string) ([]model.PayslipDelivery, error) {
+	list := make([]model.PayslipDelivery, 0)
+
+	query := `
+		SELECT
+			d.delivery_id,
+			d.payroll_id,
+			d.user_id,
+			u.name,
+			d.payment_period,
+			d.email,
+			d.status,
+			d.attempts,
+			COALESCE(d.last_error, ''),
+			d.sent_at,
+			d.created_at,
+			d.updated_at
+		FROM
+			payslip_deliveries d
+				INNER JOIN users u ON u.user_id = d.user_id
+		WHERE d.payment_period = $1
+		ORDER BY u.name ASC;
+		`
+	rows, err := r.db.QueryxContext(ctx, query, period)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayslipDeliveryList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery model.PayslipDelivery
+		err = rows.Scan(
+			&delivery.Delivery_id,
+			&delivery.Payroll_id,
+			&delivery.User_id,
+			&delivery.Name,
+			&delivery.Payment_period,
+			&delivery.Email,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.Last_error,
+			&delivery.Sent_at,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetPayslipDeliveryList scan data", err)
+			return list, err
+		}
+		list = append(list, delivery)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Registers a payslip for delivery, an existing row keeps its history but
+// picks up a changed email address
+func (r *payslipDeliveryRepository) CreatePayslipDelivery(ctx context.Context, tx *sqlx.Tx, d model.PayslipDelivery) (uuid.UUID, error) {
+	var (
+		delivery_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO
+			payslip_deliveries (payroll_id, user_id, payment_period, email)
+		VALUES
+			($1, $2, $3, $4)
+		ON CONFLICT (payroll_id) DO UPDATE SET
+			email = EXCLUDED.email,
+			updated_at = now()
+		RETURNING delivery_id
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		d.Payroll_id,
+		d.User_id,
+		d.Payment_period,
+		d.Email,
+	).Scan(
+		&delivery_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreatePayslipDelivery", err)
+		return delivery_id, err
+	}
+
+	return delivery_id, err
+}
+
+func (r *payslipDeliveryRepository) UpdatePayslipDelivery(ctx context.Context, tx *sqlx.Tx, d model.PayslipDelivery) (uuid.UUID, error) {
+	var (
+		delivery_id uuid.UUID
+	)
+
+	query := `
+		UPDATE
+			payslip_deliveries
+		SET
+			status = $2,
+			attempts = $3,
+			last_error = NULLIF($4, ''),
+			sent_at = $5,
+			updated_at = now()
+		WHERE
+			delivery_id = $1
+		RETURNING delivery_id
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		d.Delivery_id,
+		d.Status,
+		d.Attempts,
+		d.Last_error,
+		d.Sent_at,
+	).Scan(
+		&delivery_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func UpdatePayslipDelivery", err)
+		return delivery_id, err
+	}
+
+	return delivery_id, err
+}
@@ -41,6 +41,7 @@ func (r *positionRepository) GetPositionList(ctx context.Context) ([]model.Posit
 		SELECT 
 			p.position_id,
 			p.name,
+			p.jkk_risk_class,
 			p.created_at, 
 			p.updated_at, 
 			p.is_delete 
@@ -66,6 +67,7 @@ func (r *positionRepository) GetPositionList(ctx context.Context) ([]model.Posit
 		err = rows.Scan(
 			&position.Position_id,
 			&position.Name,
+			&position.Jkk_risk_class,
 			&position.CreatedAt,
 			&position.UpdatedAt,
 			&position.Is_delete,
@@ -95,12 +97,13 @@ func (r *positionRepository) GetPositionDetail(ctx context.Context, id uuid.UUID
 		SELECT 
 			p.position_id,
 			p.name,
+			p.jkk_risk_class,
 			p.created_at, 
 			p.updated_at, 
 			p.is_delete 
 		FROM 
 			positions p
-		WHERE r.position_id=$1;
+		WHERE p.position_id=$1;
 		`
 
 	//Execute SQL Query
@@ -111,6 +114,7 @@ func (r *positionRepository) GetPositionDetail(ctx context.Context, id uuid.UUID
 	).Scan(
 		&position.Position_id,
 		&position.Name,
+		&position.Jkk_risk_class,
 		&position.CreatedAt,
 		&position.UpdatedAt,
 		&position.Is_delete,
@@ -135,9 +139,9 @@ func (r *positionRepository) CreatePosition(ctx context.Context, tx *sqlx.Tx, u
 	//Query
 	query := `
 		INSERT INTO 
-			positions (name) 
+			positions (name, jkk_risk_class) 
 		VALUES
-			($1)
+			($1, $2)
 		RETURNING position_id
 			;
 	`
@@ -146,6 +150,7 @@ func (r *positionRepository) CreatePosition(ctx context.Context, tx *sqlx.Tx, u
 		ctx,
 		query,
 		u.Name,
+		u.Jkk_risk_class,
 	).Scan(
 		&position_id,
 	)
@@ -169,10 +174,9 @@ func (r *positionRepository) UpdatePosition(ctx context.Context, tx *sqlx.Tx, u
 		UPDATE 
 			positions
 		SET
-			name = $1,
+			name = $2,
+			jkk_risk_class = $3,
 			updated_at = now()
-		VALUES
-			($2)
 		WHERE
 			position_id = $1
 		RETURNING position_id
@@ -184,6 +188,7 @@ func (r *positionRepository) UpdatePosition(ctx context.Context, tx *sqlx.Tx, u
 		query,
 		u.Position_id,
 		u.Name,
+		u.Jkk_risk_class,
 	).Scan(
 		&position_id,
 	)
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+type ReportRepo interface {
+	//Read
+	GetPayrollCostList(ctx context.Context, periodFrom string, periodTo string, groupBy []string) ([]model.PayrollCostRow, error)
+	GetPayrollHeadcount(ctx context.Context, periodFrom string, periodTo string) (int, error)
+	GetPayrollPeriodUserList(ctx context.Context, period string) ([]model.PayrollPeriodUserTotal, error)
+	GetPayrollPeriodItemList(ctx context.Context, period string) ([]model.PayrollPeriodItemTotal, error)
+}
+
+type reportRepo struct {
+	db *sqlx.DB
+}
+
+func NewReportRepo(dbConn *sqlx.DB) ReportRepo {
+	return &reportRepo{
+		db: dbConn,
+	}
+}
+
+// Records that were actually paid or are about to be
+const payrollCostFrom = `
+		FROM
+			payroll_records p
+				INNER JOIN status s ON p.status_id = s.status_id
+				INNER JOIN users u ON p.user_id = u.user_id
+				INNER JOIN positions po ON u.position_id = po.position_id
+				INNER JOIN roles ro ON u.role_id = ro.role_id
+				LEFT JOIN (
+					SELECT payroll_id, SUM(amount) AS gross_pay
+					FROM payroll_items
+					WHERE component_type = 'earning'
+					GROUP BY payroll_id
+				) i ON i.payroll_id = p.payroll_id
+				LEFT JOIN (
+					SELECT payroll_id, SUM(employee_amount) AS employee_bpjs, SUM(employer_amount) AS employer_bpjs
+					FROM payroll_bpjs_items
+					GROUP BY payroll_id
+				) b ON b.payroll_id = p.payroll_id
+		WHERE
+			p.is_delete = false AND p.payment_period BETWEEN $1 AND $2
+			AND s.name IN ('approved', 'paid', 'closed')`
+
+// Payroll cost per period, and per position and role when grouped by them
+func (db *reportRepo) GetPayrollCostList(ctx context.Context, periodFrom string, periodTo string, groupBy []string) ([]model.PayrollCostRow, error) {
+	list := make([]model.PayrollCostRow, 0)
+
+	// Position and role stay empty unless grouped by
+	columns := []string{"p.payment_period", "''", "''"}
+	for _, group := range groupBy {
+		switch group {
+		case "position":
+			columns[1] = "po.name"
+		case "role":
+			columns[2] = "ro.name"
+		}
+	}
+
+	query := `
+		SELECT
+			` + strings.Join(columns, ", ") + `,
+			COUNT(DISTINCT p.user_id), COUNT(*),
+			COALESCE(SUM(i.gross_pay), 0), COALESCE(SUM(b.employee_bpjs), 0), COALESCE(SUM(b.employer_bpjs), 0),
+			COALESCE(SUM(p.tax), 0), COALESCE(SUM((p.tax_detail->>'employer_tax')::bigint), 0), COALESCE(SUM(p.total_salary), 0)` + payrollCostFrom + `
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3;`
+
+	rows, err := db.db.QueryxContext(ctx, query, periodFrom, periodTo)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollCostList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var row model.PayrollCostRow
+		err = rows.Scan(
+			&row.Payment_period,
+			&row.Position,
+			&row.Role,
+			&row.Headcount,
+			&row.Record_count,
+			&row.Gross_pay,
+			&row.Employee_bpjs,
+			&row.Employer_bpjs,
+			&row.Tax_withheld,
+			&row.Employer_tax,
+			&row.Net_pay,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollCostList scan data", err)
+			return list, err
+		}
+		row.Total_cost = row.Gross_pay + row.Employer_bpjs + row.Employer_tax
+		list = append(list, row)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Employees paid in the periods, each counted once
+func (db *reportRepo) GetPayrollHeadcount(ctx context.Context, periodFrom string, periodTo string) (int, error) {
+	var headcount int
+
+	query := `
+		SELECT COUNT(DISTINCT p.user_id)` + payrollCostFrom + `;`
+
+	err := db.db.QueryRowxContext(ctx, query, periodFrom, periodTo).Scan(&headcount)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollHeadcount", err)
+		return headcount, err
+	}
+	return headcount, err
+}
+
+// Users of the regular payroll of a period with their net pay and the
+// basic salary in force at the end of the period
+func (db *reportRepo) GetPayrollPeriodUserList(ctx context.Context, period string) ([]model.PayrollPeriodUserTotal, error) {
+	list := make([]model.PayrollPeriodUserTotal, 0)
+
+	query := `
+		SELECT
+			p.user_id, u.name, u.nik, u.termination_date, COALESCE(c.basic_salary, 0), SUM(p.total_salary)
+		FROM
+			payroll_records p
+				INNER JOIN users u ON p.user_id = u.user_id
+				LEFT JOIN payroll_runs r ON r.run_id = p.run_id
+				LEFT JOIN LATERAL (
+					SELECT basic_salary
+					FROM employee_compensation
+					WHERE
+						user_id = p.user_id AND is_delete = false
+						AND effective_from <= (to_date($1, 'YYYY-MM') + interval '1 month - 1 day')::date
+					ORDER BY effective_from DESC
+					LIMIT 1
+				) c ON true
+		WHERE
+			p.payment_period = $1 AND p.is_delete = false
+			AND COALESCE(r.run_type, 'regular') = 'regular'
+		GROUP BY p.user_id, u.name, u.nik, u.termination_date, c.basic_salary
+		ORDER BY u.name ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, period)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollPeriodUserList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var total model.PayrollPeriodUserTotal
+		err = rows.Scan(
+			&total.User_id,
+			&total.Name,
+			&total.Nik,
+			&total.Termination_date,
+			&total.Basic_salary,
+			&total.Net_pay,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollPeriodUserList scan data", err)
+			return list, err
+		}
+		list = append(list, total)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Amount of every component per user in the regular payroll of a period
+func (db *reportRepo) GetPayrollPeriodItemList(ctx context.Context, period string) ([]model.PayrollPeriodItemTotal, error) {
+	list := make([]model.PayrollPeriodItemTotal, 0)
+
+	query := `
+		SELECT
+			p.user_id, i.component_code, c.name, i.component_type, SUM(i.amount)
+		FROM
+			payroll_items i
+				INNER JOIN payroll_records p ON i.payroll_id = p.payroll_id
+				INNER JOIN payroll_components c ON i.component_code = c.component_code
+				LEFT JOIN payroll_runs r ON r.run_id = p.run_id
+		WHERE
+			p.payment_period = $1 AND p.is_delete = false
+			AND COALESCE(r.run_type, 'regular') = 'regular'
+		GROUP BY p.user_id, i.component_code, c.name, i.component_type
+		ORDER BY i.component_type ASC, i.component_code ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, period)
+	if err != nil {
+		utils.LogError("Repo", "func GetPayrollPeriodItemList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var total model.PayrollPeriodItemTotal
+		err = rows.Scan(
+			&total.User_id,
+			&total.Component_code,
+			&total.Name,
+			&total.Component_type,
+			&total.Amount,
+		)
+		if err != nil {
+			utils.LogError("Repo", "GetPayrollPeriodItemList scan data", err)
+			return list, err
+		}
+		list = append(list, total)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type RetroPayRepo interface {
+	//Create
+	CreateRetroPay(ctx context.Context, tx *sqlx.Tx, r model.RetroPay) (uuid.UUID, error)
+	//Read
+	GetRetroPayList(ctx context.Context, userId uuid.NullUUID, paymentPeriod string) ([]model.RetroPay, error)
+	GetRetroPayListByEarnedPeriod(ctx context.Context, userId uuid.UUID, earnedPeriod string) ([]model.RetroPay, error)
+}
+
+type retroPayRepo struct {
+	db *sqlx.DB
+}
+
+func NewRetroPayRepo(dbConn *sqlx.DB) RetroPayRepo {
+	return &retroPayRepo{
+		db: dbConn,
+	}
+}
+
+const retroPayColumns = `
+			r.retro_id, r.user_id, r.earned_period, r.payment_period, r.basic_salary_before, r.basic_salary_after,
+			r.earning_difference, r.bpjs_employee_difference, r.bpjs_employer_difference, r.bpjs_items,
+			r.employer_premiums_difference, r.pension_difference, r.taxable_difference, r.tax_difference,
+			r.employee_tax_difference, r.net_difference, r.reason, r.created_at`
+
+func scanRetroPay(row interface{ Scan(...interface{}) error }, r *model.RetroPay) error {
+	return row.Scan(
+		&r.Retro_id,
+		&r.User_id,
+		&r.Earned_period,
+		&r.Payment_period,
+		&r.Basic_salary_before,
+		&r.Basic_salary_after,
+		&r.Earning_difference,
+		&r.Bpjs_employee_difference,
+		&r.Bpjs_employer_difference,
+		&r.Bpjs_items,
+		&r.Employer_premiums_difference,
+		&r.Pension_difference,
+		&r.Taxable_difference,
+		&r.Tax_difference,
+		&r.Employee_tax_difference,
+		&r.Net_difference,
+		&r.Reason,
+		&r.CreatedAt,
+	)
+}
+
+// Retro pay booked in a payment period, of a single user when userId is set
+func (db *retroPayRepo) GetRetroPayList(ctx context.Context, userId uuid.NullUUID, paymentPeriod string) ([]model.RetroPay, error) {
+	list := make([]model.RetroPay, 0)
+
+	query := `
+		SELECT` + retroPayColumns + `
+		FROM
+			retro_pays r
+		WHERE
+			r.is_delete = false
+			AND ($1::uuid IS NULL OR r.user_id = $1)
+			AND r.payment_period = $2
+		ORDER BY r.user_id ASC, r.earned_period ASC, r.created_at ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, userId, paymentPeriod)
+	if err != nil {
+		utils.LogError("Repo", "func GetRetroPayList", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var retro model.RetroPay
+		err = scanRetroPay(rows, &retro)
+		if err != nil {
+			utils.LogError("Repo", "GetRetroPayList scan data", err)
+			return list, err
+		}
+		list = append(list, retro)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+// Retro pay already computed for an earned period, a later change only
+// pays what these did not
+func (db *retroPayRepo) GetRetroPayListByEarnedPeriod(ctx context.Context, userId uuid.UUID, earnedPeriod string) ([]model.RetroPay, error) {
+	list := make([]model.RetroPay, 0)
+
+	query := `
+		SELECT` + retroPayColumns + `
+		FROM
+			retro_pays r
+		WHERE
+			r.is_delete = false
+			AND r.user_id = $1
+			AND r.earned_period = $2
+		ORDER BY r.created_at ASC;`
+
+	rows, err := db.db.QueryxContext(ctx, query, userId, earnedPeriod)
+	if err != nil {
+		utils.LogError("Repo", "func GetRetroPayListByEarnedPeriod", err)
+		return list, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var retro model.RetroPay
+		err = scanRetroPay(rows, &retro)
+		if err != nil {
+			utils.LogError("Repo", "GetRetroPayListByEarnedPeriod scan data", err)
+			return list, err
+		}
+		list = append(list, retro)
+	}
+
+	utils.CloseDB(rows)
+	return list, err
+}
+
+func (db *retroPayRepo) CreateRetroPay(ctx context.Context, tx *sqlx.Tx, r model.RetroPay) (uuid.UUID, error) {
+	var (
+		retro_id uuid.UUID
+	)
+
+	query := `
+		INSERT INTO retro_pays(
+			user_id, earned_period, payment_period, basic_salary_before, basic_salary_after,
+			earning_difference, bpjs_employee_difference, bpjs_employer_difference, bpjs_items,
+			employer_premiums_difference, pension_difference, taxable_difference, tax_difference,
+			employee_tax_difference, net_difference, reason
+		) VALUES(
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+		) RETURNING retro_id;`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		r.User_id,
+		r.Earned_period,
+		r.Payment_period,
+		r.Basic_salary_before,
+		r.Basic_salary_after,
+		r.Earning_difference,
+		r.Bpjs_employee_difference,
+		r.Bpjs_employer_difference,
+		r.Bpjs_items,
+		r.Employer_premiums_difference,
+		r.Pension_difference,
+		r.Taxable_difference,
+		r.Tax_difference,
+		r.Employee_tax_difference,
+		r.Net_difference,
+		r.Reason,
+	).Scan(
+		&retro_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func CreateRetroPay", err)
+		return retro_id, err
+	}
+
+	return retro_id, err
+}
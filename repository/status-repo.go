@@ -16,6 +16,7 @@ type StatusRepo interface {
 	//Read
 	GetStatusList(ctx context.Context) ([]model.Status, error)
 	GetStatusDetail(ctx context.Context, id uuid.UUID) (model.Status, error)
+	GetStatusByName(ctx context.Context, name string) (model.Status, error)
 	//Update
 	UpdateStatus(ctx context.Context, tx *sqlx.Tx, u model.Status) (uuid.UUID, error)
 	//Delete
@@ -124,6 +125,47 @@ func (r *statusRepository) GetStatusDetail(ctx context.Context, id uuid.UUID) (m
 	return status, err
 }
 
+func (r *statusRepository) GetStatusByName(ctx context.Context, name string) (model.Status, error) {
+
+	var (
+		status model.Status
+	)
+
+	//SQL Query
+	query := `
+		SELECT 
+			s.status_id,
+			s.name,
+			s.created_at, 
+			s.updated_at, 
+			s.is_delete 
+		FROM 
+			status s
+		WHERE s.name = $1;
+		`
+
+	//Execute SQL Query
+	err := r.db.QueryRowxContext(
+		ctx,
+		query,
+		name,
+	).Scan(
+		&status.Status_id,
+		&status.Name,
+		&status.CreatedAt,
+		&status.UpdatedAt,
+		&status.Is_delete,
+	)
+
+	//Err Handling
+	if err != nil {
+		utils.LogError("Repo", "func GetStatusByName", err)
+		return status, err
+	}
+
+	return status, err
+}
+
 func (r *statusRepository) CreateStatus(ctx context.Context, tx *sqlx.Tx, u model.Status) (uuid.UUID, error) {
 	//Variable that holds registered user email
 	var (
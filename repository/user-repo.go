@@ -19,6 +19,9 @@ type UserRepo interface {
 	FindByEmail(ctx context.Context, email string) (model.UserResponse, error)
 	GetUserList(ctx context.Context) ([]model.User, error)
 	GetUserDetail(ctx context.Context, id uuid.UUID) (model.UserDetailModel, error)
+	GetActiveUserList(ctx context.Context) ([]model.User, error)
+	//Update
+	UpdateUserEmployment(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, u model.User) (uuid.UUID, error)
 }
 
 type userConnection struct {
@@ -75,6 +78,60 @@ func (db *userConnection) GetUserList(ctx context.Context) ([]model.User, error)
 	return users, err
 }
 
+// Users that are not deleted, the population of a payroll run
+func (db *userConnection) GetActiveUserList(ctx context.Context) ([]model.User, error) {
+	users := make([]model.User, 0)
+
+	query := `
+		SELECT
+			u.user_id,
+			u.username,
+			u.name,
+			u.email,
+			u.nik,
+			u.role_id,
+			u.position_id,
+			u.ptkp_status,
+			u.join_date,
+			u.termination_date
+		FROM users AS u
+		WHERE u.is_delete = false
+		ORDER BY u.name ASC`
+	rows, err := db.connection.QueryxContext(ctx, query)
+
+	if err != nil {
+		utils.LogError("Repo", "func GetActiveUserList", err)
+		return users, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.User
+		err = rows.Scan(
+			&user.User_id,
+			&user.Username,
+			&user.Name,
+			&user.Email,
+			&user.Nik,
+			&user.Role_id,
+			&user.Position_id,
+			&user.Ptkp_status,
+			&user.Join_date,
+			&user.Termination_date,
+		)
+
+		if err != nil {
+			utils.LogError("Repo", "GetActiveUserList scan data", err)
+			return users, err
+		}
+		users = append(users, user)
+	}
+
+	utils.CloseDB(rows)
+	return users, err
+}
+
 func (db *userConnection) GetUserDetail(ctx context.Context, id uuid.UUID) (model.UserDetailModel, error) {
 
 	var (
@@ -84,7 +141,9 @@ func (db *userConnection) GetUserDetail(ctx context.Context, id uuid.UUID) (mode
 	//SQL Query
 	query := `
 		SELECT 
-			u.user_id, u.username, u.name, u.position_id, u.nik, u.role_id, r.role_name, p.position_name 
+			u.user_id, u.username, u.name, u.email, u.position_id, u.nik, u.role_id, r.name, p.name, u.ptkp_status, p.jkk_risk_class, u.birth_date,
+			COALESCE(u.bank_code, ''), COALESCE(u.bank_account_number, ''), COALESCE(u.bank_account_name, ''),
+			u.join_date, u.termination_date 
 		FROM users AS u 
 			INNER JOIN roles AS r 
 				ON r.role_id = u.role_id 
@@ -101,11 +160,20 @@ func (db *userConnection) GetUserDetail(ctx context.Context, id uuid.UUID) (mode
 		&userDetail.User_id,
 		&userDetail.Username,
 		&userDetail.Name,
+		&userDetail.Email,
 		&userDetail.Position_id,
 		&userDetail.Nik,
 		&userDetail.Role_id,
 		&userDetail.Role_name,
 		&userDetail.Position_name,
+		&userDetail.Ptkp_status,
+		&userDetail.Jkk_risk_class,
+		&userDetail.Birth_date,
+		&userDetail.Bank_code,
+		&userDetail.Bank_account_number,
+		&userDetail.Bank_account_name,
+		&userDetail.Join_date,
+		&userDetail.Termination_date,
 	)
 
 	//Err Handling
@@ -127,9 +195,9 @@ func (db *userConnection) CreateUser(ctx context.Context, tx *sqlx.Tx, u model.U
 	//Query
 	query := `
 		INSERT INTO 
-			users (username, name, password, email, nik, role_id, position_id) 
+			users (username, name, password, email, nik, role_id, position_id, ptkp_status, birth_date, bank_code, bank_account_number, bank_account_name, join_date) 
 		VALUES
-			($1, $2, $3, $4, $5, $6, $7)
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), $13)
 		RETURNING email
 			;
 	`
@@ -144,6 +212,12 @@ func (db *userConnection) CreateUser(ctx context.Context, tx *sqlx.Tx, u model.U
 		u.Nik,
 		u.Role_id,
 		u.Position_id,
+		u.Ptkp_status,
+		u.Birth_date,
+		u.Bank_code,
+		u.Bank_account_number,
+		u.Bank_account_name,
+		u.Join_date,
 	).Scan(
 		&createdUser,
 	)
@@ -196,3 +270,38 @@ func (db *userConnection) FindByEmail(ctx context.Context, email string) (model.
 	// returns login data
 	return userData, err
 }
+
+func (db *userConnection) UpdateUserEmployment(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, u model.User) (uuid.UUID, error) {
+	var (
+		user_id uuid.UUID
+	)
+
+	query := `
+		UPDATE
+			users
+		SET
+			join_date = $2,
+			termination_date = $3,
+			updated_at = now()
+		WHERE
+			user_id = $1
+		RETURNING user_id
+			;
+	`
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		id,
+		u.Join_date,
+		u.Termination_date,
+	).Scan(
+		&user_id,
+	)
+
+	if err != nil {
+		utils.LogError("Repo", "func UpdateUserEmployment", err)
+		return user_id, err
+	}
+
+	return user_id, err
+}
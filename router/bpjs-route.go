@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/dafiqarba/be-payroll/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BpjsRouter interface {
+	BpjsProgramList(group fiber.Router, controller controller.BpjsController) fiber.Router
+	BpjsProgramUpdate(group fiber.Router, controller controller.BpjsController) fiber.Router
+}
+
+func (r *fiberRouter) BpjsProgramList(group fiber.Router, controller controller.BpjsController) fiber.Router {
+	return group.Get("/bpjs/programs", controller.GetBpjsProgramList())
+}
+
+func (r *fiberRouter) BpjsProgramUpdate(group fiber.Router, controller controller.BpjsController) fiber.Router {
+	return group.Put("/bpjs/programs/:code", controller.UpdateBpjsProgram())
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"github.com/dafiqarba/be-payroll/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ClaimRouter interface {
+	ClaimCategoryList(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimCategoryCreate(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimList(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimDetail(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimCreate(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimStatusUpdate(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimReceiptCreate(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimReceiptDownload(group fiber.Router, controller controller.ClaimController) fiber.Router
+	ClaimTransferExport(group fiber.Router, controller controller.BankTransferController) fiber.Router
+}
+
+func (r *fiberRouter) ClaimCategoryList(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Get("/claim/categories", controller.GetClaimCategoryList())
+}
+
+func (r *fiberRouter) ClaimCategoryCreate(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Post("/claim/categories", controller.CreateClaimCategory())
+}
+
+func (r *fiberRouter) ClaimList(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Get("/claim", controller.GetClaimList())
+}
+
+func (r *fiberRouter) ClaimDetail(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Get("/claim/:id", controller.GetClaimDetail())
+}
+
+func (r *fiberRouter) ClaimCreate(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Post("/claim", controller.CreateClaim())
+}
+
+func (r *fiberRouter) ClaimStatusUpdate(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Put("/claim/:id/status", controller.UpdateClaimStatus())
+}
+
+func (r *fiberRouter) ClaimReceiptCreate(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Post("/claim/:id/receipt", controller.CreateClaimReceipt())
+}
+
+func (r *fiberRouter) ClaimReceiptDownload(group fiber.Router, controller controller.ClaimController) fiber.Router {
+	return group.Get("/claim/:id/receipt/:receipt_id", controller.GetClaimReceipt())
+}
+
+func (r *fiberRouter) ClaimTransferExport(group fiber.Router, controller controller.BankTransferController) fiber.Router {
+	return group.Get("/claim/transfers", controller.ExportClaimTransfer())
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"github.com/dafiqarba/be-payroll/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LoanRouter interface {
+	LoanList(group fiber.Router, controller controller.LoanController) fiber.Router
+	LoanDetail(group fiber.Router, controller controller.LoanController) fiber.Router
+	LoanCreate(group fiber.Router, controller controller.LoanController) fiber.Router
+	LoanStatusUpdate(group fiber.Router, controller controller.LoanController) fiber.Router
+}
+
+func (r *fiberRouter) LoanList(group fiber.Router, controller controller.LoanController) fiber.Router {
+	return group.Get("/loan", controller.GetLoanList())
+}
+
+func (r *fiberRouter) LoanDetail(group fiber.Router, controller controller.LoanController) fiber.Router {
+	return group.Get("/loan/:id", controller.GetLoanDetail())
+}
+
+func (r *fiberRouter) LoanCreate(group fiber.Router, controller controller.LoanController) fiber.Router {
+	return group.Post("/loan", controller.CreateLoan())
+}
+
+func (r *fiberRouter) LoanStatusUpdate(group fiber.Router, controller controller.LoanController) fiber.Router {
+	return group.Put("/loan/:id/status", controller.UpdateLoanStatus())
+}
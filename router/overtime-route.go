@@ -0,0 +1,29 @@
+package router
+
+import (
+	"github.com/dafiqarba/be-payroll/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OvertimeRouter interface {
+	OvertimeList(group fiber.Router, controller controller.OvertimeController) fiber.Router
+	OvertimeDetail(group fiber.Router, controller controller.OvertimeController) fiber.Router
+	OvertimeCreate(group fiber.Router, controller controller.OvertimeController) fiber.Router
+	OvertimeStatusUpdate(group fiber.Router, controller controller.OvertimeController) fiber.Router
+}
+
+func (r *fiberRouter) OvertimeList(group fiber.Router, controller controller.OvertimeController) fiber.Router {
+	return group.Get("/overtime", controller.GetOvertimeRecordList())
+}
+
+func (r *fiberRouter) OvertimeDetail(group fiber.Router, controller controller.OvertimeController) fiber.Router {
+	return group.Get("/overtime/:id", controller.GetOvertimeRecordDetail())
+}
+
+func (r *fiberRouter) OvertimeCreate(group fiber.Router, controller controller.OvertimeController) fiber.Router {
+	return group.Post("/overtime", controller.CreateOvertimeRecord())
+}
+
+func (r *fiberRouter) OvertimeStatusUpdate(group fiber.Router, controller controller.OvertimeController) fiber.Router {
+	return group.Put("/overtime/:id/status", controller.UpdateOvertimeStatus())
+}
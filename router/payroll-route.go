@@ -11,6 +11,31 @@ type PayrollRouter interface {
 	PayrollCreate(group fiber.Router, controller controller.PayrollRecordController) fiber.Router
 	PayrollUpdate(group fiber.Router, controller controller.PayrollRecordController) fiber.Router
 	PayrollCreateList(group fiber.Router, controller controller.PayrollRecordController) fiber.Router
+	PayrollImport(group fiber.Router, controller controller.PayrollImportController) fiber.Router
+	PayrollSimulate(group fiber.Router, controller controller.PayrollSimulationController) fiber.Router
+	PayrollComponentList(group fiber.Router, controller controller.PayrollComponentController) fiber.Router
+	PayrollComponentCreate(group fiber.Router, controller controller.PayrollComponentController) fiber.Router
+	PayrollRunList(group fiber.Router, controller controller.PayrollRunController) fiber.Router
+	PayrollRunDetail(group fiber.Router, controller controller.PayrollRunController) fiber.Router
+	PayrollRunCreate(group fiber.Router, controller controller.PayrollRunController) fiber.Router
+	PayrollRunStatusUpdate(group fiber.Router, controller controller.PayrollRunController) fiber.Router
+	PayrollPeriodList(group fiber.Router, controller controller.PayrollPeriodController) fiber.Router
+	PayrollPeriodDetail(group fiber.Router, controller controller.PayrollPeriodController) fiber.Router
+	PayrollPeriodCreate(group fiber.Router, controller controller.PayrollPeriodController) fiber.Router
+	PayrollPeriodClose(group fiber.Router, auth fiber.Handler, controller controller.PayrollPeriodController) fiber.Router
+	PayrollPeriodReopen(group fiber.Router, auth fiber.Handler, controller controller.PayrollPeriodController) fiber.Router
+	PayslipDownload(group fiber.Router, controller controller.PayslipController) fiber.Router
+	PayslipArchive(group fiber.Router, controller controller.PayslipController) fiber.Router
+	PayslipDistribute(group fiber.Router, controller controller.PayslipController) fiber.Router
+	PayslipDeliveryList(group fiber.Router, controller controller.PayslipController) fiber.Router
+	BankTransferFormatList(group fiber.Router, controller controller.BankTransferController) fiber.Router
+	BankTransferExport(group fiber.Router, controller controller.BankTransferController) fiber.Router
+	ThrEntitlementList(group fiber.Router, controller controller.ThrController) fiber.Router
+	ThrRunCreate(group fiber.Router, controller controller.ThrController) fiber.Router
+	PayrollCorrectionList(group fiber.Router, controller controller.PayrollCorrectionController) fiber.Router
+	PayrollCorrectionCreate(group fiber.Router, controller controller.PayrollCorrectionController) fiber.Router
+	RetroPayList(group fiber.Router, controller controller.RetroPayController) fiber.Router
+	RetroPayCreate(group fiber.Router, controller controller.RetroPayController) fiber.Router
 }
 
 func (r *fiberRouter) PayrollList(group fiber.Router, controller controller.PayrollRecordController) fiber.Router {
@@ -32,3 +57,103 @@ func (r *fiberRouter) PayrollUpdate(group fiber.Router, controller controller.Pa
 func (r *fiberRouter) PayrollCreateList(group fiber.Router, controller controller.PayrollRecordController) fiber.Router {
 	return group.Post("/payroll/create-list", controller.CreatePayrollRecordList())
 }
+
+func (r *fiberRouter) PayrollImport(group fiber.Router, controller controller.PayrollImportController) fiber.Router {
+	return group.Post("/payroll/import", controller.ImportPayrollInput())
+}
+
+func (r *fiberRouter) PayrollSimulate(group fiber.Router, controller controller.PayrollSimulationController) fiber.Router {
+	return group.Post("/payroll/simulate", controller.SimulatePayroll())
+}
+
+func (r *fiberRouter) PayrollComponentList(group fiber.Router, controller controller.PayrollComponentController) fiber.Router {
+	return group.Get("/payroll/components", controller.GetPayrollComponentList())
+}
+
+func (r *fiberRouter) PayrollComponentCreate(group fiber.Router, controller controller.PayrollComponentController) fiber.Router {
+	return group.Post("/payroll/components", controller.CreatePayrollComponent())
+}
+
+func (r *fiberRouter) PayrollRunList(group fiber.Router, controller controller.PayrollRunController) fiber.Router {
+	return group.Get("/payroll/runs", controller.GetPayrollRunList())
+}
+
+func (r *fiberRouter) PayrollRunDetail(group fiber.Router, controller controller.PayrollRunController) fiber.Router {
+	return group.Get("/payroll/runs/:id", controller.GetPayrollRunDetail())
+}
+
+func (r *fiberRouter) PayrollRunCreate(group fiber.Router, controller controller.PayrollRunController) fiber.Router {
+	return group.Post("/payroll/runs", controller.CreatePayrollRun())
+}
+
+func (r *fiberRouter) PayrollRunStatusUpdate(group fiber.Router, controller controller.PayrollRunController) fiber.Router {
+	return group.Put("/payroll/runs/:id/status", controller.UpdatePayrollRunStatus())
+}
+
+func (r *fiberRouter) PayrollPeriodList(group fiber.Router, controller controller.PayrollPeriodController) fiber.Router {
+	return group.Get("/payroll/periods", controller.GetPayrollPeriodList())
+}
+
+func (r *fiberRouter) PayrollPeriodDetail(group fiber.Router, controller controller.PayrollPeriodController) fiber.Router {
+	return group.Get("/payroll/periods/:period", controller.GetPayrollPeriodDetail())
+}
+
+func (r *fiberRouter) PayrollPeriodCreate(group fiber.Router, controller controller.PayrollPeriodController) fiber.Router {
+	return group.Post("/payroll/periods", controller.CreatePayrollPeriod())
+}
+
+func (r *fiberRouter) PayrollPeriodClose(group fiber.Router, auth fiber.Handler, controller controller.PayrollPeriodController) fiber.Router {
+	return group.Put("/payroll/periods/:period/close", auth, controller.ClosePayrollPeriod())
+}
+
+func (r *fiberRouter) PayrollPeriodReopen(group fiber.Router, auth fiber.Handler, controller controller.PayrollPeriodController) fiber.Router {
+	return group.Put("/payroll/periods/:period/reopen", auth, controller.ReopenPayrollPeriod())
+}
+
+func (r *fiberRouter) PayslipDownload(group fiber.Router, controller controller.PayslipController) fiber.Router {
+	return group.Get("/payroll/:id/payslip.pdf", controller.GetPayslip())
+}
+
+func (r *fiberRouter) PayslipArchive(group fiber.Router, controller controller.PayslipController) fiber.Router {
+	return group.Get("/payroll/payslips.zip", controller.GetPayslipArchive())
+}
+
+func (r *fiberRouter) PayslipDistribute(group fiber.Router, controller controller.PayslipController) fiber.Router {
+	return group.Post("/payroll/payslips/distribute", controller.DistributePayslips())
+}
+
+func (r *fiberRouter) PayslipDeliveryList(group fiber.Router, controller controller.PayslipController) fiber.Router {
+	return group.Get("/payroll/payslips/deliveries", controller.GetPayslipDeliveryList())
+}
+
+func (r *fiberRouter) BankTransferFormatList(group fiber.Router, controller controller.BankTransferController) fiber.Router {
+	return group.Get("/payroll/transfers/formats", controller.GetBankTransferFormatList())
+}
+
+func (r *fiberRouter) BankTransferExport(group fiber.Router, controller controller.BankTransferController) fiber.Router {
+	return group.Get("/payroll/transfers", controller.ExportBankTransfer())
+}
+
+func (r *fiberRouter) ThrEntitlementList(group fiber.Router, controller controller.ThrController) fiber.Router {
+	return group.Get("/payroll/thr", controller.GetThrEntitlementList())
+}
+
+func (r *fiberRouter) ThrRunCreate(group fiber.Router, controller controller.ThrController) fiber.Router {
+	return group.Post("/payroll/thr/runs", controller.CreateThrRun())
+}
+
+func (r *fiberRouter) PayrollCorrectionList(group fiber.Router, controller controller.PayrollCorrectionController) fiber.Router {
+	return group.Get("/payroll/:id/corrections", controller.GetPayrollCorrectionList())
+}
+
+func (r *fiberRouter) PayrollCorrectionCreate(group fiber.Router, controller controller.PayrollCorrectionController) fiber.Router {
+	return group.Post("/payroll/:id/corrections", controller.CreatePayrollCorrection())
+}
+
+func (r *fiberRouter) RetroPayList(group fiber.Router, controller controller.RetroPayController) fiber.Router {
+	return group.Get("/payroll/retro", controller.GetRetroPayList())
+}
+
+func (r *fiberRouter) RetroPayCreate(group fiber.Router, controller controller.RetroPayController) fiber.Router {
+	return group.Post("/payroll/retro", controller.CreateRetroPay())
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/dafiqarba/be-payroll/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportRouter interface {
+	PayrollCostSummary(group fiber.Router, controller controller.ReportController) fiber.Router
+	PayrollCostExport(group fiber.Router, controller controller.ReportController) fiber.Router
+	PayrollVariance(group fiber.Router, controller controller.ReportController) fiber.Router
+}
+
+func (r *fiberRouter) PayrollCostSummary(group fiber.Router, controller controller.ReportController) fiber.Router {
+	return group.Get("/report/payroll-cost", controller.GetPayrollCostSummary())
+}
+
+func (r *fiberRouter) PayrollCostExport(group fiber.Router, controller controller.ReportController) fiber.Router {
+	return group.Get("/report/payroll-cost.csv", controller.ExportPayrollCostSummary())
+}
+
+func (r *fiberRouter) PayrollVariance(group fiber.Router, controller controller.ReportController) fiber.Router {
+	return group.Get("/report/payroll-variance", controller.GetPayrollVariance())
+}
@@ -26,10 +26,16 @@ type Router interface {
 	UserRouter
 	AuthRouter
 	LeaveRouter
+	OvertimeRouter
+	LoanRouter
+	ClaimRouter
 	PayrollRouter
 	RoleRouter
 	PositionRouter
 	StatusRouter
+	BpjsRouter
+	TaxRouter
+	ReportRouter
 }
 
 func NewFiberRouter(
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"github.com/dafiqarba/be-payroll/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TaxRouter interface {
+	TaxCertificateList(group fiber.Router, controller controller.TaxCertificateController) fiber.Router
+	TaxCertificateArchive(group fiber.Router, controller controller.TaxCertificateController) fiber.Router
+	TaxCertificateDetail(group fiber.Router, controller controller.TaxCertificateController) fiber.Router
+	TaxCertificatePDF(group fiber.Router, controller controller.TaxCertificateController) fiber.Router
+}
+
+func (r *fiberRouter) TaxCertificateList(group fiber.Router, controller controller.TaxCertificateController) fiber.Router {
+	return group.Get("/tax/1721-a1", controller.GetTaxCertificateList())
+}
+
+func (r *fiberRouter) TaxCertificateArchive(group fiber.Router, controller controller.TaxCertificateController) fiber.Router {
+	return group.Get("/tax/1721-a1.zip", controller.GetTaxCertificateArchive())
+}
+
+func (r *fiberRouter) TaxCertificateDetail(group fiber.Router, controller controller.TaxCertificateController) fiber.Router {
+	return group.Get("/tax/1721-a1/:user_id", controller.GetTaxCertificate())
+}
+
+func (r *fiberRouter) TaxCertificatePDF(group fiber.Router, controller controller.TaxCertificateController) fiber.Router {
+	return group.Get("/tax/1721-a1/:user_id/pdf", controller.GetTaxCertificatePDF())
+}
@@ -8,6 +8,9 @@ import (
 type UserRouter interface {
 	UserList(group fiber.Router, controller controller.UserController) fiber.Router
 	UserDetail(group fiber.Router, controller controller.UserController) fiber.Router
+	UserEmploymentUpdate(group fiber.Router, controller controller.UserController) fiber.Router
+	CompensationList(group fiber.Router, controller controller.CompensationController) fiber.Router
+	CompensationCreate(group fiber.Router, controller controller.CompensationController) fiber.Router
 }
 
 func (r *fiberRouter) UserList(group fiber.Router, controller controller.UserController) fiber.Router {
@@ -17,3 +20,15 @@ func (r *fiberRouter) UserList(group fiber.Router, controller controller.UserCon
 func (r *fiberRouter) UserDetail(group fiber.Router, controller controller.UserController) fiber.Router {
 	return group.Get("/user-detail/{id:[0-9]+}", controller.GetUserDetail())
 }
+
+func (r *fiberRouter) UserEmploymentUpdate(group fiber.Router, controller controller.UserController) fiber.Router {
+	return group.Put("/user/:id/employment", controller.UpdateUserEmployment())
+}
+
+func (r *fiberRouter) CompensationList(group fiber.Router, controller controller.CompensationController) fiber.Router {
+	return group.Get("/user/:id/compensation", controller.GetCompensationList())
+}
+
+func (r *fiberRouter) CompensationCreate(group fiber.Router, controller controller.CompensationController) fiber.Router {
+	return group.Post("/user/:id/compensation", controller.CreateCompensation())
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+// BankTransferFormat writes a bulk transfer batch in the layout a bank's
+// internet banking accepts. New banks plug in through bankTransferFormats.
+type BankTransferFormat interface {
+	Info() model.BankTransferFormatModel
+	Extension() string
+	ContentType() string
+	Write(batch model.BankTransferBatch) ([]byte, error)
+}
+
+var bankTransferFormats = map[string]BankTransferFormat{
+	"bca":     bcaFormat{},
+	"mandiri": mandiriMcmFormat{},
+	"bni":     bniFormat{},
+	"generic": genericCsvFormat{},
+}
+
+// Clearing code and account number length of the supported banks
+type bankInfo struct {
+	clearingCode string
+	minLength    int
+	maxLength    int
+}
+
+var banks = map[string]bankInfo{
+	"BCA":     {"014", 10, 10},
+	"MANDIRI": {"008", 13, 13},
+	"BNI":     {"009", 10, 10},
+	"BRI":     {"002", 15, 15},
+	"BSI":     {"451", 10, 10},
+	"CIMB":    {"022", 12, 14},
+	"PERMATA": {"013", 10, 10},
+	"DANAMON": {"011", 10, 12},
+	"BTN":     {"200", 16, 16},
+}
+
+// Sum of the account numbers modulo 10^15, the hash total banks use to
+// check that no line was altered
+func accountHashTotal(lines []model.BankTransferLine) int64 {
+	const modulo = 1_000_000_000_000_000
+	var total int64
+	for _, line := range lines {
+		n, _ := strconv.ParseInt(line.Bank_account_number, 10, 64)
+		total = (total + n%modulo) % modulo
+	}
+	return total
+}
+
+// Transfer remark, banks limit it to a short alphanumeric text. Salary
+// batches use the period when no remark is set.
+func transferRemark(batch model.BankTransferBatch) string {
+	if batch.Remark != "" {
+		return batch.Remark
+	}
+	return "GAJI " + strings.ReplaceAll(batch.Payment_period, "-", "")
+}
+
+func csvBytes(records [][]string, comma rune) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	w.UseCRLF = true
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Uppercased and cut to n characters, bank files reject longer names
+func bankText(s string, n int) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) > n {
+		s = s[:n]
+	}
+	return s
+}
+
+// KlikBCA Bisnis bulk payroll CSV. BCA accounts are credited in house,
+// other banks go through LLG with their clearing code.
+type bcaFormat struct{}
+
+func (bcaFormat) Info() model.BankTransferFormatModel {
+	return model.BankTransferFormatModel{Code: "bca", Name: "BCA KlikBCA Bisnis", Description: "Semicolon separated CSV with header, detail and trailer records"}
+}
+
+func (bcaFormat) Extension() string { return "csv" }
+
+func (bcaFormat) ContentType() string { return "text/csv" }
+
+func (bcaFormat) Write(batch model.BankTransferBatch) ([]byte, error) {
+	records := [][]string{{
+		"H",
+		bankText(batch.Company_code, 10),
+		batch.Debit_account,
+		batch.Transfer_date.Format("20060102"),
+		strconv.Itoa(len(batch.Lines)),
+		strconv.Itoa(batch.Total_amount),
+		transferRemark(batch),
+	}}
+	for i, line := range batch.Lines {
+		method := "BCA"
+		if line.Bank_code != "BCA" {
+			method = "LLG"
+		}
+		records = append(records, []string{
+			"D",
+			strconv.Itoa(i + 1),
+			line.Bank_account_number,
+			bankText(line.Bank_account_name, 35),
+			banks[line.Bank_code].clearingCode,
+			method,
+			strconv.Itoa(line.Amount),
+			transferRemark(batch),
+			line.Nik,
+		})
+	}
+	records = append(records, []string{
+		"T",
+		strconv.Itoa(len(batch.Lines)),
+		strconv.Itoa(batch.Total_amount),
+		strconv.FormatInt(accountHashTotal(batch.Lines), 10),
+	})
+	return csvBytes(records, ';')
+}
+
+// Mandiri Cash Management fixed width file, amounts carry two implied
+// decimals and every record is 200 characters long
+type mandiriMcmFormat struct{}
+
+const mandiriRecordLength = 200
+
+func (mandiriMcmFormat) Info() model.BankTransferFormatModel {
+	return model.BankTransferFormatModel{Code: "mandiri", Name: "Mandiri MCM", Description: "Fixed width records of 200 characters with header and trailer"}
+}
+
+func (mandiriMcmFormat) Extension() string { return "txt" }
+
+func (mandiriMcmFormat) ContentType() string { return "text/plain" }
+
+func (mandiriMcmFormat) Write(batch model.BankTransferBatch) ([]byte, error) {
+	var buf bytes.Buffer
+	record := func(fields ...string) {
+		line := strings.Join(fields, "")
+		if len(line) > mandiriRecordLength {
+			line = line[:mandiriRecordLength]
+		}
+		buf.WriteString(fmt.Sprintf("%-*s\r\n", mandiriRecordLength, line))
+	}
+
+	record(
+		"0",
+		fmt.Sprintf("%-10s", bankText(batch.Company_code, 10)),
+		fmt.Sprintf("%-20s", batch.Debit_account),
+		batch.Transfer_date.Format("20060102"),
+		fmt.Sprintf("%06d", len(batch.Lines)),
+		fmt.Sprintf("%017d", batch.Total_amount*100),
+		"IDR",
+		fmt.Sprintf("%-40s", transferRemark(batch)),
+	)
+	for i, line := range batch.Lines {
+		record(
+			"1",
+			fmt.Sprintf("%06d", i+1),
+			fmt.Sprintf("%-20s", line.Bank_account_number),
+			fmt.Sprintf("%-35s", bankText(line.Bank_account_name, 35)),
+			fmt.Sprintf("%-3s", banks[line.Bank_code].clearingCode),
+			fmt.Sprintf("%015d", line.Amount*100),
+			"IDR",
+			fmt.Sprintf("%-40s", transferRemark(batch)),
+			fmt.Sprintf("%-20s", bankText(line.Nik, 20)),
+		)
+	}
+	record(
+		"9",
+		fmt.Sprintf("%06d", len(batch.Lines)),
+		fmt.Sprintf("%017d", batch.Total_amount*100),
+		fmt.Sprintf("%020d", accountHashTotal(batch.Lines)),
+	)
+	return buf.Bytes(), nil
+}
+
+// BNI Direct bulk credit CSV
+type bniFormat struct{}
+
+func (bniFormat) Info() model.BankTransferFormatModel {
+	return model.BankTransferFormatModel{Code: "bni", Name: "BNI Direct", Description: "Comma separated CSV with header, detail and trailer records"}
+}
+
+func (bniFormat) Extension() string { return "csv" }
+
+func (bniFormat) ContentType() string { return "text/csv" }
+
+func (bniFormat) Write(batch model.BankTransferBatch) ([]byte, error) {
+	records := [][]string{{
+		"HEADER",
+		batch.Transfer_date.Format("2006/01/02"),
+		batch.Debit_account,
+		strconv.Itoa(len(batch.Lines)),
+		strconv.Itoa(batch.Total_amount),
+	}}
+	for _, line := range batch.Lines {
+		records = append(records, []string{
+			"DETAIL",
+			line.Bank_account_number,
+			bankText(line.Bank_account_name, 40),
+			strconv.Itoa(line.Amount),
+			"IDR",
+			banks[line.Bank_code].clearingCode,
+			transferRemark(batch),
+			line.Email,
+		})
+	}
+	records = append(records, []string{
+		"TRAILER",
+		strconv.Itoa(len(batch.Lines)),
+		strconv.Itoa(batch.Total_amount),
+		strconv.FormatInt(accountHashTotal(batch.Lines), 10),
+	})
+	return csvBytes(records, ',')
+}
+
+// Bank agnostic CSV for banks without a dedicated format
+type genericCsvFormat struct{}
+
+func (genericCsvFormat) Info() model.BankTransferFormatModel {
+	return model.BankTransferFormatModel{Code: "generic", Name: "Generic CSV", Description: "Comma separated CSV with column names, detail lines and a trailer"}
+}
+
+func (genericCsvFormat) Extension() string { return "csv" }
+
+func (genericCsvFormat) ContentType() string { return "text/csv" }
+
+func (genericCsvFormat) Write(batch model.BankTransferBatch) ([]byte, error) {
+	records := [][]string{
+		{"record_type", "period", "transfer_date", "debit_account", "line_count", "total_amount"},
+		{"H", batch.Payment_period, batch.Transfer_date.Format("2006-01-02"), batch.Debit_account, strconv.Itoa(len(batch.Lines)), strconv.Itoa(batch.Total_amount)},
+		{"record_type", "no", "nik", "name", "bank_code", "clearing_code", "account_number", "account_name", "amount", "remark"},
+	}
+	for i, line := range batch.Lines {
+		records = append(records, []string{
+			"D",
+			strconv.Itoa(i + 1),
+			line.Nik,
+			line.Name,
+			line.Bank_code,
+			banks[line.Bank_code].clearingCode,
+			line.Bank_account_number,
+			line.Bank_account_name,
+			strconv.Itoa(line.Amount),
+			transferRemark(batch),
+		})
+	}
+	records = append(records,
+		[]string{"record_type", "line_count", "total_amount", "account_hash_total"},
+		[]string{"T", strconv.Itoa(len(batch.Lines)), strconv.Itoa(batch.Total_amount), strconv.FormatInt(accountHashTotal(batch.Lines), 10)},
+	)
+	return csvBytes(records, ',')
+}
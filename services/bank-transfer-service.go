@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+)
+
+// A generated bulk transfer file
+type BankTransferFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+type BankTransferService interface {
+	GetBankTransferFormatList() []model.BankTransferFormatModel
+	ExportBankTransfer(ctx context.Context, period string, runType string, format string, transferDate string) (BankTransferFile, []model.BankTransferError, error)
+	ExportClaimTransfer(ctx context.Context, format string, transferDate string) (BankTransferFile, []model.BankTransferError, error)
+}
+
+type bankTransferService struct {
+	payrollRecordRepo repository.PayrollRecordRepo
+	claimRepo         repository.ClaimRepo
+	companyCode       string
+	debitAccount      string
+	timeoutContext    time.Duration
+}
+
+func NewBankTransferService(payrollRecordRepo repository.PayrollRecordRepo, claimRepo repository.ClaimRepo, companyCode string, debitAccount string, timeoutContext time.Duration) BankTransferService {
+	return &bankTransferService{
+		payrollRecordRepo: payrollRecordRepo,
+		claimRepo:         claimRepo,
+		companyCode:       companyCode,
+		debitAccount:      debitAccount,
+		timeoutContext:    timeoutContext,
+	}
+}
+
+// ValidateBankAccount checks the salary account of an employee against the
+// account number length of the bank
+func ValidateBankAccount(account model.BankAccount) error {
+	bank, ok := banks[account.Bank_code]
+	if !ok {
+		codes := make([]string, 0, len(banks))
+		for code := range banks {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		return errors.New("bank_code must be one of " + strings.Join(codes, ", "))
+	}
+	number := account.Bank_account_number
+	if number == "" || strings.IndexFunc(number, func(r rune) bool { return !unicode.IsDigit(r) }) >= 0 {
+		return errors.New("bank_account_number must only contain digits")
+	}
+	if len(number) < bank.minLength || len(number) > bank.maxLength {
+		if bank.minLength == bank.maxLength {
+			return fmt.Errorf("bank_account_number of %s must be %d digits", account.Bank_code, bank.minLength)
+		}
+		return fmt.Errorf("bank_account_number of %s must be %d to %d digits", account.Bank_code, bank.minLength, bank.maxLength)
+	}
+	if strings.TrimSpace(account.Bank_account_name) == "" {
+		return errors.New("bank_account_name is required")
+	}
+	return nil
+}
+
+func (s *bankTransferService) GetBankTransferFormatList() []model.BankTransferFormatModel {
+	list := make([]model.BankTransferFormatModel, 0, len(bankTransferFormats))
+	for _, format := range bankTransferFormats {
+		list = append(list, format.Info())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// Builds the bulk transfer file of the approved records of a run type in a
+// period, defaulting to the regular payroll. The export is refused as a
+// whole when any employee has invalid bank data.
+func (s *bankTransferService) ExportBankTransfer(ctx context.Context, period string, runType string, format string, transferDate string) (BankTransferFile, []model.BankTransferError, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		file    BankTransferFile
+		invalid = make([]model.BankTransferError, 0)
+		err     error
+	)
+
+	writer, date, err := s.transferOptions(format, transferDate)
+	if err != nil {
+		return file, invalid, err
+	}
+	if _, err = time.Parse(PaymentPeriodLayout, period); err != nil {
+		err = errors.New("period must be formatted as YYYY-MM")
+		return file, invalid, err
+	}
+	if runType == "" {
+		runType = model.PayrollRunRegular
+	}
+	if runType != model.PayrollRunRegular && runType != model.PayrollRunThr {
+		err = errors.New("run_type must be regular or thr")
+		return file, invalid, err
+	}
+
+	lines, err := s.payrollRecordRepo.GetBankTransferList(ctx, period, runType, model.PayrollRunApproved)
+	if err != nil {
+		utils.LogError("Services", "ExportBankTransfer get transfer list", err)
+		return file, invalid, err
+	}
+	if len(lines) == 0 {
+		err = errors.New("no approved " + runType + " payroll records found for " + period)
+		return file, invalid, err
+	}
+
+	batch := model.BankTransferBatch{
+		Company_code:   s.companyCode,
+		Debit_account:  s.debitAccount,
+		Payment_period: period,
+		Transfer_date:  date,
+		Lines:          lines,
+	}
+	if runType == model.PayrollRunThr {
+		batch.Remark = "THR " + strings.ReplaceAll(period, "-", "")
+	}
+	file.Content, invalid, err = writeBankTransfer(writer, batch, "net salary must be greater than zero")
+	if err != nil {
+		return file, invalid, err
+	}
+	file.Filename = fmt.Sprintf("transfer-%s-%s-%s.%s", runType, format, period, writer.Extension())
+	file.ContentType = writer.ContentType()
+	return file, invalid, err
+}
+
+// Builds the bulk transfer file of the approved claims paid apart from the
+// payroll, one line per employee. The claims stay approved until they are
+// marked paid.
+func (s *bankTransferService) ExportClaimTransfer(ctx context.Context, format string, transferDate string) (BankTransferFile, []model.BankTransferError, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		file    BankTransferFile
+		invalid = make([]model.BankTransferError, 0)
+		err     error
+	)
+
+	writer, date, err := s.transferOptions(format, transferDate)
+	if err != nil {
+		return file, invalid, err
+	}
+
+	lines, err := s.claimRepo.GetClaimTransferList(ctx)
+	if err != nil {
+		utils.LogError("Services", "ExportClaimTransfer get transfer list", err)
+		return file, invalid, err
+	}
+	if len(lines) == 0 {
+		err = errors.New("no approved claims to transfer")
+		return file, invalid, err
+	}
+
+	period := date.Format(PaymentPeriodLayout)
+	batch := model.BankTransferBatch{
+		Company_code:   s.companyCode,
+		Debit_account:  s.debitAccount,
+		Payment_period: period,
+		Transfer_date:  date,
+		Remark:         "REIMBURSE " + date.Format("20060102"),
+		Lines:          lines,
+	}
+	file.Content, invalid, err = writeBankTransfer(writer, batch, "claimed amount must be greater than zero")
+	if err != nil {
+		return file, invalid, err
+	}
+	file.Filename = fmt.Sprintf("reimbursement-%s-%s.%s", format, date.Format("2006-01-02"), writer.Extension())
+	file.ContentType = writer.ContentType()
+	return file, invalid, err
+}
+
+// Format and transfer date of an export, the date defaults to today
+func (s *bankTransferService) transferOptions(format string, transferDate string) (BankTransferFormat, time.Time, error) {
+	date := time.Now()
+	writer, ok := bankTransferFormats[format]
+	if !ok {
+		return writer, date, errors.New("unknown bank transfer format " + format)
+	}
+	if transferDate != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", transferDate)
+		if err != nil {
+			return writer, date, errors.New("transfer_date must be formatted as YYYY-MM-DD")
+		}
+	}
+	if s.debitAccount == "" {
+		return writer, date, errors.New("company debit account is not configured")
+	}
+	return writer, date, nil
+}
+
+// Validates the bank data of every line and writes the batch. Nothing is
+// written when any line is invalid.
+func writeBankTransfer(writer BankTransferFormat, batch model.BankTransferBatch, amountMessage string) ([]byte, []model.BankTransferError, error) {
+	invalid := make([]model.BankTransferError, 0)
+	for _, line := range batch.Lines {
+		message := ""
+		if err := ValidateBankAccount(line.BankAccount); err != nil {
+			message = err.Error()
+		} else if line.Amount <= 0 {
+			message = amountMessage
+		}
+		if message != "" {
+			invalid = append(invalid, model.BankTransferError{Payroll_id: line.Payroll_id, User_id: line.User_id, Name: line.Name, Nik: line.Nik, Message: message})
+		}
+		batch.Total_amount += line.Amount
+	}
+	if len(invalid) > 0 {
+		return nil, invalid, fmt.Errorf("bank data of %d employees is invalid", len(invalid))
+	}
+
+	content, err := writer.Write(batch)
+	if err != nil {
+		utils.LogError("Services", "writeBankTransfer", err)
+		return nil, invalid, err
+	}
+	return content, invalid, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestValidateBankAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		account model.BankAccount
+		wantErr string
+	}{
+		{"bca", model.BankAccount{Bank_code: "BCA", Bank_account_number: "1234567890", Bank_account_name: "Budi"}, ""},
+		{"cimb within the range", model.BankAccount{Bank_code: "CIMB", Bank_account_number: "1234567890123", Bank_account_name: "Budi"}, ""},
+		{"unknown bank", model.BankAccount{Bank_code: "XYZ", Bank_account_number: "1234567890", Bank_account_name: "Budi"}, "bank_code must be one of"},
+		{"letters in the number", model.BankAccount{Bank_code: "BCA", Bank_account_number: "12345ABCDE", Bank_account_name: "Budi"}, "must only contain digits"},
+		{"no number", model.BankAccount{Bank_code: "BCA", Bank_account_name: "Budi"}, "must only contain digits"},
+		{"fixed length", model.BankAccount{Bank_code: "MANDIRI", Bank_account_number: "1234567890", Bank_account_name: "Budi"}, "must be 13 digits"},
+		{"length range", model.BankAccount{Bank_code: "DANAMON", Bank_account_number: "123456789", Bank_account_name: "Budi"}, "must be 10 to 12 digits"},
+		{"no name", model.BankAccount{Bank_code: "BCA", Bank_account_number: "1234567890", Bank_account_name: " "}, "bank_account_name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBankAccount(tt.account)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ValidateBankAccount() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("ValidateBankAccount() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBankTransferFormats(t *testing.T) {
+	batch := func(remark string) model.BankTransferBatch {
+		return model.BankTransferBatch{
+			Company_code:   "acme",
+			Debit_account:  "0987654321",
+			Payment_period: "2024-03",
+			Transfer_date:  time.Date(2024, time.March, 25, 0, 0, 0, 0, time.UTC),
+			Remark:         remark,
+			Lines: []model.BankTransferLine{
+				{Name: "Budi Santoso", Nik: "001", Email: "budi@example.com", Amount: 5000000,
+					BankAccount: model.BankAccount{Bank_code: "BCA", Bank_account_number: "1234567890", Bank_account_name: "Budi Santoso"}},
+				{Name: "Siti Aminah", Nik: "002", Email: "siti@example.com", Amount: 7500000,
+					BankAccount: model.BankAccount{Bank_code: "MANDIRI", Bank_account_number: "1234567890123", Bank_account_name: "Siti Aminah"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		format string
+		remark string
+		want   []string
+	}{
+		{
+			format: "bca",
+			want: []string{
+				"H;ACME;0987654321;20240325;2;12500000;GAJI 202403",
+				"D;1;1234567890;BUDI SANTOSO;014;BCA;5000000;GAJI 202403;001",
+				"D;2;1234567890123;SITI AMINAH;008;LLG;7500000;GAJI 202403;002",
+				"T;2;12500000;1235802458013",
+			},
+		},
+		{
+			format: "bni",
+			remark: "THR 2024",
+			want: []string{
+				"HEADER,2024/03/25,0987654321,2,12500000",
+				"DETAIL,1234567890,BUDI SANTOSO,5000000,IDR,014,THR 2024,budi@example.com",
+				"DETAIL,1234567890123,SITI AMINAH,7500000,IDR,008,THR 2024,siti@example.com",
+				"TRAILER,2,12500000,1235802458013",
+			},
+		},
+		{
+			format: "generic",
+			want: []string{
+				"record_type,period,transfer_date,debit_account,line_count,total_amount",
+				"H,2024-03,2024-03-25,0987654321,2,12500000",
+				"record_type,no,nik,name,bank_code,clearing_code,account_number,account_name,amount,remark",
+				"D,1,001,Budi Santoso,BCA,014,1234567890,Budi Santoso,5000000,GAJI 202403",
+				"D,2,002,Siti Aminah,MANDIRI,008,1234567890123,Siti Aminah,7500000,GAJI 202403",
+				"record_type,line_count,total_amount,account_hash_total",
+				"T,2,12500000,1235802458013",
+			},
+		},
+		{
+			// Fixed width, the amounts carry two implied decimals
+			format: "mandiri",
+			want: []string{
+				"0ACME      0987654321          2024032500000200000001250000000IDRGAJI 202403",
+				"1000001" + "1234567890          " + "BUDI SANTOSO                       " + "014" + "000000500000000" + "IDR" + "GAJI 202403                             " + "001",
+				"1000002" + "1234567890123       " + "SITI AMINAH                        " + "008" + "000000750000000" + "IDR" + "GAJI 202403                             " + "002",
+				"900000200000001250000000" + "00000001235802458013",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			content, invalid, err := writeBankTransfer(bankTransferFormats[tt.format], batch(tt.remark), "net salary must be greater than zero")
+			if err != nil || len(invalid) > 0 {
+				t.Fatalf("writeBankTransfer() error = %v, invalid = %v", err, invalid)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(content), "\r\n"), "\r\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("writeBankTransfer() = %d lines, want %d:\n%s", len(lines), len(tt.want), content)
+			}
+			for i, line := range lines {
+				if tt.format == "mandiri" {
+					if len(line) != mandiriRecordLength {
+						t.Errorf("line %d is %d characters, want %d", i+1, len(line), mandiriRecordLength)
+					}
+					line = strings.TrimRight(line, " ")
+				}
+				if line != tt.want[i] {
+					t.Errorf("line %d = %q, want %q", i+1, line, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteBankTransferInvalidLines(t *testing.T) {
+	batch := model.BankTransferBatch{
+		Payment_period: "2024-03",
+		Lines: []model.BankTransferLine{
+			{Nik: "001", Amount: 5000000, BankAccount: model.BankAccount{Bank_code: "BCA", Bank_account_number: "1234567890", Bank_account_name: "Budi"}},
+			{Nik: "002", Amount: 5000000, BankAccount: model.BankAccount{Bank_code: "BCA", Bank_account_number: "123", Bank_account_name: "Siti"}},
+			{Nik: "003", Amount: 0, BankAccount: model.BankAccount{Bank_code: "BCA", Bank_account_number: "1234567891", Bank_account_name: "Andi"}},
+		},
+	}
+
+	content, invalid, err := writeBankTransfer(bankTransferFormats["generic"], batch, "net salary must be greater than zero")
+	if err == nil || content != nil {
+		t.Fatalf("writeBankTransfer() = %q, %v, want no file and an error", content, err)
+	}
+	want := map[string]string{"002": "bank_account_number of BCA must be 10 digits", "003": "net salary must be greater than zero"}
+	if len(invalid) != len(want) {
+		t.Fatalf("invalid = %v, want %d lines", invalid, len(want))
+	}
+	for _, line := range invalid {
+		if line.Message != want[line.Nik] {
+			t.Errorf("NIK %s message = %q, want %q", line.Nik, line.Message, want[line.Nik])
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+type BpjsService interface {
+	//Read
+	GetBpjsProgramList(ctx context.Context) ([]model.BpjsProgram, error)
+	//Update
+	UpdateBpjsProgram(ctx context.Context, code string, u model.UpdateBpjsProgramModel) (string, error)
+	//Calculation
+	Calculate(ctx context.Context, wage int, riskClass int) (model.BpjsBreakdown, error)
+}
+
+type bpjsService struct {
+	repository     repository.BpjsRepo
+	timeoutContext time.Duration
+	db             *sqlx.DB
+}
+
+func NewBpjsService(repository repository.BpjsRepo, timeoutContext time.Duration, db *sqlx.DB) BpjsService {
+	return &bpjsService{
+		repository:     repository,
+		timeoutContext: timeoutContext,
+		db:             db,
+	}
+}
+
+func (service *bpjsService) GetBpjsProgramList(ctx context.Context) ([]model.BpjsProgram, error) {
+	ctx, cancel := context.WithTimeout(ctx, service.timeoutContext)
+	defer cancel()
+
+	var (
+		list []model.BpjsProgram
+		err  error
+	)
+
+	list, err = service.repository.GetBpjsProgramList(ctx)
+	if err != nil {
+		utils.LogError("Services", "GetBpjsProgramList", err)
+		return list, err
+	}
+	return list, err
+}
+
+func (service *bpjsService) UpdateBpjsProgram(ctx context.Context, code string, u model.UpdateBpjsProgramModel) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, service.timeoutContext)
+	defer cancel()
+
+	var (
+		id  string
+		err error
+	)
+
+	if u.Employer_rate < 0 || u.Employer_rate > 1 || u.Employee_rate < 0 || u.Employee_rate > 1 {
+		err = errors.New("rates must be between 0 and 1")
+		utils.LogError("Services", "UpdateBpjsProgram", err)
+		return id, err
+	}
+	if u.Wage_cap < 0 {
+		err = errors.New("wage_cap cannot be negative")
+		utils.LogError("Services", "UpdateBpjsProgram", err)
+		return id, err
+	}
+
+	tx, err := service.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "UpdateBpjsProgram open tx", err)
+		return id, err
+	}
+
+	id, err = service.repository.UpdateBpjsProgram(ctx, tx, model.BpjsProgram{
+		Program_code:  code,
+		Employer_rate: u.Employer_rate,
+		Employee_rate: u.Employee_rate,
+		Wage_cap:      u.Wage_cap,
+	})
+	if err != nil {
+		utils.LogError("Services", "UpdateBpjsProgram", err)
+		utils.CommitOrRollback(tx, "Services UpdateBpjsProgram", err)
+		return id, err
+	}
+
+	utils.CommitOrRollback(tx, "Services UpdateBpjsProgram", err)
+	return id, err
+}
+
+// Calculate splits the contributions of every program into employer and
+// employee shares, applying each program's wage cap
+func (service *bpjsService) Calculate(ctx context.Context, wage int, riskClass int) (model.BpjsBreakdown, error) {
+	var (
+		breakdown model.BpjsBreakdown
+		err       error
+	)
+
+	programs, err := service.repository.GetBpjsProgramList(ctx)
+	if err != nil {
+		utils.LogError("Services", "Calculate BPJS get programs", err)
+		return breakdown, err
+	}
+
+	jkk, err := service.repository.GetJkkRate(ctx, riskClass)
+	if err != nil {
+		utils.LogError("Services", "Calculate BPJS get jkk rate", err)
+		return breakdown, err
+	}
+
+	breakdown.Items = make([]model.BpjsItem, 0, len(programs))
+	for _, program := range programs {
+		item := model.BpjsItem{
+			Program_code:  program.Program_code,
+			Base_wage:     wage,
+			Employer_rate: program.Employer_rate,
+			Employee_rate: program.Employee_rate,
+		}
+		if program.Wage_cap > 0 {
+			item.Base_wage = min(wage, program.Wage_cap)
+		}
+		if program.Program_code == model.BpjsJkk {
+			item.Employer_rate = jkk.Rate
+		}
+		item.Employer_amount = roundRupiah(float64(item.Base_wage) * item.Employer_rate)
+		item.Employee_amount = roundRupiah(float64(item.Base_wage) * item.Employee_rate)
+
+		breakdown.Employer_total += item.Employer_amount
+		breakdown.Employee_total += item.Employee_amount
+		switch program.Program_code {
+		case model.BpjsJkk, model.BpjsJkm, model.BpjsKesehatan:
+			breakdown.Employer_taxable += item.Employer_amount
+		case model.BpjsJht, model.BpjsJp:
+			breakdown.Employee_pension += item.Employee_amount
+		}
+		breakdown.Items = append(breakdown.Items, item)
+	}
+
+	return breakdown, err
+}
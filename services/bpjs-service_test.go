@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+)
+
+// Rates seeded by the BPJS migration
+type fakeBpjsRepo struct {
+	repository.BpjsRepo
+}
+
+func (fakeBpjsRepo) GetBpjsProgramList(ctx context.Context) ([]model.BpjsProgram, error) {
+	return []model.BpjsProgram{
+		{Program_code: model.BpjsJht, Employer_rate: 0.037, Employee_rate: 0.02},
+		{Program_code: model.BpjsJp, Employer_rate: 0.02, Employee_rate: 0.01, Wage_cap: 10042300},
+		{Program_code: model.BpjsJkk},
+		{Program_code: model.BpjsJkm, Employer_rate: 0.003},
+		{Program_code: model.BpjsKesehatan, Employer_rate: 0.04, Employee_rate: 0.01, Wage_cap: 12000000},
+	}, nil
+}
+
+func (fakeBpjsRepo) GetJkkRate(ctx context.Context, riskClass int) (model.BpjsJkkRate, error) {
+	rates := map[int]float64{1: 0.0024, 2: 0.0054, 3: 0.0089, 4: 0.0127, 5: 0.0174}
+	rate, ok := rates[riskClass]
+	if !ok {
+		return model.BpjsJkkRate{}, sql.ErrNoRows
+	}
+	return model.BpjsJkkRate{Risk_class: riskClass, Rate: rate}, nil
+}
+
+func TestBpjsCalculate(t *testing.T) {
+	type share struct{ employer, employee int }
+	tests := []struct {
+		name            string
+		wage            int
+		riskClass       int
+		programs        map[string]share
+		employerTotal   int
+		employeeTotal   int
+		employerTaxable int
+		employeePension int
+		wantErr         bool
+	}{
+		{
+			name: "below the caps", wage: 10000000, riskClass: 1,
+			programs: map[string]share{
+				model.BpjsJht:       {370000, 200000},
+				model.BpjsJp:        {200000, 100000},
+				model.BpjsJkk:       {24000, 0},
+				model.BpjsJkm:       {30000, 0},
+				model.BpjsKesehatan: {400000, 100000},
+			},
+			employerTotal: 1024000, employeeTotal: 400000, employerTaxable: 454000, employeePension: 300000,
+		},
+		{
+			// JP is capped at 10.042.300 and Kesehatan at 12.000.000
+			name: "above the caps", wage: 15000000, riskClass: 3,
+			programs: map[string]share{
+				model.BpjsJht:       {555000, 300000},
+				model.BpjsJp:        {200846, 100423},
+				model.BpjsJkk:       {133500, 0},
+				model.BpjsJkm:       {45000, 0},
+				model.BpjsKesehatan: {480000, 120000},
+			},
+			employerTotal: 1414346, employeeTotal: 520423, employerTaxable: 658500, employeePension: 400423,
+		},
+		{
+			name: "no wage", wage: 0, riskClass: 5,
+			programs: map[string]share{
+				model.BpjsJht: {}, model.BpjsJp: {}, model.BpjsJkk: {}, model.BpjsJkm: {}, model.BpjsKesehatan: {},
+			},
+		},
+		{name: "unknown risk class", wage: 10000000, riskClass: 6, wantErr: true},
+	}
+
+	s := NewBpjsService(fakeBpjsRepo{}, 0, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Calculate(context.Background(), tt.wage, tt.riskClass)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Calculate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Items) != len(tt.programs) {
+				t.Fatalf("Calculate() = %d items, want %d", len(got.Items), len(tt.programs))
+			}
+			for _, item := range got.Items {
+				want := tt.programs[item.Program_code]
+				if item.Employer_amount != want.employer || item.Employee_amount != want.employee {
+					t.Errorf("%s = %d employer, %d employee, want %d, %d", item.Program_code, item.Employer_amount, item.Employee_amount, want.employer, want.employee)
+				}
+			}
+			if got.Employer_total != tt.employerTotal || got.Employee_total != tt.employeeTotal {
+				t.Errorf("totals = %d employer, %d employee, want %d, %d", got.Employer_total, got.Employee_total, tt.employerTotal, tt.employeeTotal)
+			}
+			if got.Employer_taxable != tt.employerTaxable || got.Employee_pension != tt.employeePension {
+				t.Errorf("Employer_taxable = %d, Employee_pension = %d, want %d, %d", got.Employer_taxable, got.Employee_pension, tt.employerTaxable, tt.employeePension)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Largest receipt accepted, it keeps the upload under the default request
+// body limit of fiber
+const claimReceiptMaxSize = 3 << 20
+
+// Receipt types accepted, detected from the content rather than the name
+var claimReceiptTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+type ClaimService interface {
+	//Create
+	CreateClaimCategory(ctx context.Context, c model.CreateClaimCategoryModel) (string, error)
+	CreateClaim(ctx context.Context, c model.CreateClaimModel) (uuid.UUID, error)
+	CreateClaimReceipt(ctx context.Context, claimId uuid.UUID, filename string, content []byte) (uuid.UUID, error)
+	//Read
+	GetClaimCategoryList(ctx context.Context) ([]model.ClaimCategory, error)
+	GetClaimList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Claim, error)
+	GetClaimDetail(ctx context.Context, id uuid.UUID) (model.Claim, error)
+	GetClaimReceipt(ctx context.Context, claimId uuid.UUID, id uuid.UUID) (model.ClaimReceipt, error)
+	//Update
+	UpdateClaimStatus(ctx context.Context, id uuid.UUID, r model.UpdateClaimStatusModel) (uuid.UUID, error)
+}
+
+type claimService struct {
+	claimRepo         repository.ClaimRepo
+	statusRepo        repository.StatusRepo
+	payrollRunRepo    repository.PayrollRunRepo
+	payrollPeriodRepo repository.PayrollPeriodRepo
+	timeoutContext    time.Duration
+	db                *sqlx.DB
+}
+
+func NewClaimService(claimRepo repository.ClaimRepo, statusRepo repository.StatusRepo, payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, timeoutContext time.Duration, db *sqlx.DB) ClaimService {
+	return &claimService{
+		claimRepo:         claimRepo,
+		statusRepo:        statusRepo,
+		payrollRunRepo:    payrollRunRepo,
+		payrollPeriodRepo: payrollPeriodRepo,
+		timeoutContext:    timeoutContext,
+		db:                db,
+	}
+}
+
+func (s *claimService) GetClaimCategoryList(ctx context.Context) ([]model.ClaimCategory, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	list, err := s.claimRepo.GetClaimCategoryList(ctx)
+	if err != nil {
+		utils.LogError("Services", "GetClaimCategoryList", err)
+		return list, err
+	}
+	return list, err
+}
+
+func (s *claimService) CreateClaimCategory(ctx context.Context, c model.CreateClaimCategoryModel) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		code string
+		err  error
+	)
+
+	category := model.ClaimCategory{
+		Category_code: strings.ToUpper(strings.TrimSpace(c.Category_code)),
+		Name:          strings.TrimSpace(c.Name),
+		Yearly_limit:  c.Yearly_limit,
+	}
+	if category.Category_code == "" || category.Name == "" {
+		return code, errors.New("category_code and name are required")
+	}
+	if category.Yearly_limit != nil && *category.Yearly_limit <= 0 {
+		return code, errors.New("yearly_limit must be greater than zero, leave it empty for no limit")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateClaimCategory open tx", err)
+		return code, err
+	}
+	code, err = s.claimRepo.CreateClaimCategory(ctx, tx, category)
+	if err != nil {
+		utils.LogError("Services", "CreateClaimCategory", err)
+	}
+	utils.CommitOrRollback(tx, "Services CreateClaimCategory", err)
+	return code, err
+}
+
+// Claims of a single user when userId is set, of a single status when status
+// is set
+func (s *claimService) GetClaimList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Claim, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	switch status {
+	case "", model.ClaimPending, model.ClaimApproved, model.ClaimRejected, model.ClaimPaid:
+	default:
+		return make([]model.Claim, 0), errors.New("status must be pending, approved, rejected or paid")
+	}
+
+	list, err := s.claimRepo.GetClaimList(ctx, userId, status)
+	if err != nil {
+		utils.LogError("Services", "GetClaimList", err)
+		return list, err
+	}
+	return list, err
+}
+
+// Claim along with the receipts attached to it
+func (s *claimService) GetClaimDetail(ctx context.Context, id uuid.UUID) (model.Claim, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	detail, err := s.claimRepo.GetClaimDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetClaimDetail", err)
+		return detail, err
+	}
+	detail.Receipts, err = s.claimRepo.GetClaimReceiptList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetClaimDetail get receipts", err)
+		return detail, err
+	}
+	return detail, err
+}
+
+// Files an expense claim. Claims still pending count against the yearly
+// limit of the category so open claims cannot exceed it together.
+func (s *claimService) CreateClaim(ctx context.Context, c model.CreateClaimModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		id  uuid.UUID
+		err error
+	)
+
+	claim := model.Claim{
+		User_id:       c.User_id,
+		Category_code: c.Category_code,
+		Amount:        c.Amount,
+		Description:   c.Description,
+		Payout:        c.Payout,
+	}
+	if claim.User_id == uuid.Nil {
+		return id, errors.New("user_id is required")
+	}
+	claim.Claim_date, err = time.Parse("2006-01-02", c.Claim_date)
+	if err != nil {
+		return id, errors.New("claim_date must be formatted as YYYY-MM-DD")
+	}
+	if claim.Claim_date.After(time.Now()) {
+		return id, errors.New("claim_date cannot be in the future")
+	}
+	if claim.Amount <= 0 {
+		return id, errors.New("amount must be greater than zero")
+	}
+	switch claim.Payout {
+	case "":
+		claim.Payout = model.ClaimPayoutPayroll
+	case model.ClaimPayoutPayroll, model.ClaimPayoutTransfer:
+	default:
+		return id, errors.New("payout must be payroll or transfer")
+	}
+
+	category, err := s.claimRepo.GetClaimCategory(ctx, claim.Category_code)
+	if err != nil {
+		utils.LogError("Services", "CreateClaim get category", err)
+		return id, errors.New("unknown claim category " + claim.Category_code)
+	}
+	err = s.checkClaimLimit(ctx, category, claim, true)
+	if err != nil {
+		return id, err
+	}
+
+	status, err := s.statusRepo.GetStatusByName(ctx, model.ClaimPending)
+	if err != nil {
+		utils.LogError("Services", "CreateClaim get status", err)
+		return id, err
+	}
+	claim.Status_id = status.Status_id
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateClaim open tx", err)
+		return id, err
+	}
+	id, err = s.claimRepo.CreateClaim(ctx, tx, claim)
+	if err != nil {
+		utils.LogError("Services", "CreateClaim", err)
+	}
+	utils.CommitOrRollback(tx, "Services CreateClaim", err)
+	return id, err
+}
+
+// Moves a claim along its flow. A pending claim is approved or rejected, an
+// approved claim paid through payroll is booked in the next open payroll
+// period and one paid by transfer is marked paid once transferred.
+func (s *claimService) UpdateClaimStatus(ctx context.Context, id uuid.UUID, r model.UpdateClaimStatusModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		idResult uuid.UUID
+		err      error
+	)
+
+	claim, err := s.claimRepo.GetClaimDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "UpdateClaimStatus get claim", err)
+		return idResult, err
+	}
+
+	payrollPeriod := ""
+	switch {
+	case claim.Status == model.ClaimPending && r.Status == model.ClaimRejected:
+	case claim.Status == model.ClaimPending && r.Status == model.ClaimApproved:
+		category, err := s.claimRepo.GetClaimCategory(ctx, claim.Category_code)
+		if err != nil {
+			utils.LogError("Services", "UpdateClaimStatus get category", err)
+			return idResult, err
+		}
+		err = s.checkClaimLimit(ctx, category, claim, false)
+		if err != nil {
+			return idResult, err
+		}
+		if claim.Payout == model.ClaimPayoutPayroll {
+			now := time.Now()
+			payrollPeriod, _, err = openPayrollPeriod(ctx, s.payrollRunRepo, s.payrollPeriodRepo, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				return idResult, err
+			}
+		}
+	case claim.Status == model.ClaimApproved && r.Status == model.ClaimPaid:
+		if claim.Payout != model.ClaimPayoutTransfer {
+			return idResult, errors.New("claim is paid with the payroll of " + claim.Payroll_period)
+		}
+	case claim.Status == model.ClaimPending:
+		return idResult, errors.New("status must be approved or rejected")
+	case claim.Status == model.ClaimApproved && claim.Payout == model.ClaimPayoutTransfer:
+		return idResult, errors.New("an approved claim can only be marked paid")
+	default:
+		return idResult, errors.New("claim is already " + claim.Status)
+	}
+
+	status, err := s.statusRepo.GetStatusByName(ctx, r.Status)
+	if err != nil {
+		utils.LogError("Services", "UpdateClaimStatus get status", err)
+		return idResult, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "UpdateClaimStatus open tx", err)
+		return idResult, err
+	}
+	idResult, err = s.claimRepo.UpdateClaimStatus(ctx, tx, id, status.Status_id, payrollPeriod)
+	if err != nil {
+		utils.LogError("Services", "UpdateClaimStatus", err)
+	}
+	utils.CommitOrRollback(tx, "Services UpdateClaimStatus", err)
+	return idResult, err
+}
+
+// Attaches a receipt to a claim still waiting for approval
+func (s *claimService) CreateClaimReceipt(ctx context.Context, claimId uuid.UUID, filename string, content []byte) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		id  uuid.UUID
+		err error
+	)
+
+	if len(content) == 0 {
+		return id, errors.New("receipt file is empty")
+	}
+	if len(content) > claimReceiptMaxSize {
+		return id, errors.New("receipt file must not be larger than 3 MB")
+	}
+	contentType := strings.SplitN(http.DetectContentType(content), ";", 2)[0]
+	if !claimReceiptTypes[contentType] {
+		return id, errors.New("receipt must be a PDF, JPEG or PNG file")
+	}
+
+	claim, err := s.claimRepo.GetClaimDetail(ctx, claimId)
+	if err != nil {
+		utils.LogError("Services", "CreateClaimReceipt get claim", err)
+		return id, err
+	}
+	if claim.Status != model.ClaimPending {
+		return id, errors.New("claim is already " + claim.Status + ", receipts can no longer be attached")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateClaimReceipt open tx", err)
+		return id, err
+	}
+	id, err = s.claimRepo.CreateClaimReceipt(ctx, tx, model.ClaimReceipt{
+		Claim_id:     claimId,
+		Filename:     filename,
+		Content_type: contentType,
+		Content:      content,
+	})
+	if err != nil {
+		utils.LogError("Services", "CreateClaimReceipt", err)
+	}
+	utils.CommitOrRollback(tx, "Services CreateClaimReceipt", err)
+	return id, err
+}
+
+func (s *claimService) GetClaimReceipt(ctx context.Context, claimId uuid.UUID, id uuid.UUID) (model.ClaimReceipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	receipt, err := s.claimRepo.GetClaimReceipt(ctx, claimId, id)
+	if err != nil {
+		utils.LogError("Services", "GetClaimReceipt", err)
+		return receipt, err
+	}
+	return receipt, err
+}
+
+// Refuses a claim that would take the user over the yearly limit of its
+// category, counting the claims dated in the same year
+func (s *claimService) checkClaimLimit(ctx context.Context, category model.ClaimCategory, claim model.Claim, includePending bool) error {
+	if category.Yearly_limit == nil {
+		return nil
+	}
+	claimed, err := s.claimRepo.GetClaimedAmount(ctx, claim.User_id, category.Category_code, claim.Claim_date.Year(), includePending)
+	if err != nil {
+		utils.LogError("Services", "checkClaimLimit get claimed amount", err)
+		return err
+	}
+	if claimed+claim.Amount > *category.Yearly_limit {
+		return errors.New("claim exceeds the yearly limit of " + category.Name + ", " + utils.FormatRupiah(max(*category.Yearly_limit-claimed, 0)) + " left of " + utils.FormatRupiah(*category.Yearly_limit))
+	}
+	return nil
+}
+
+// Line note of a reimbursement, e.g. "Rawat Jalan claim of 2024-01-05"
+func claimNote(claim model.Claim) string {
+	return claim.Category_name + " claim of " + claim.Claim_date.Format("2006-01-02")
+}
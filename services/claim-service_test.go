@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/google/uuid"
+)
+
+// 1.500.000 approved and 1.000.000 pending in the year
+type fakeClaimRepo struct {
+	repository.ClaimRepo
+}
+
+func (fakeClaimRepo) GetClaimCategory(ctx context.Context, code string) (model.ClaimCategory, error) {
+	if code != "RAWAT_JALAN" {
+		return model.ClaimCategory{}, sql.ErrNoRows
+	}
+	limit := 3000000
+	return model.ClaimCategory{Category_code: code, Name: "Rawat Jalan", Yearly_limit: &limit}, nil
+}
+
+func (fakeClaimRepo) GetClaimedAmount(ctx context.Context, userId uuid.UUID, category string, year int, includePending bool) (int, error) {
+	if includePending {
+		return 2500000, nil
+	}
+	return 1500000, nil
+}
+
+func TestCheckClaimLimit(t *testing.T) {
+	limit := func(amount int) *int { return &amount }
+
+	tests := []struct {
+		name           string
+		limit          *int
+		amount         int
+		includePending bool
+		wantErr        string
+	}{
+		{name: "no limit", amount: 50000000, includePending: true},
+		{name: "up to the limit", limit: limit(3000000), amount: 500000, includePending: true},
+		{
+			name: "pending claims count when claiming", limit: limit(3000000), amount: 600000, includePending: true,
+			wantErr: "claim exceeds the yearly limit of Rawat Jalan, Rp 500.000 left of Rp 3.000.000",
+		},
+		{name: "approval only counts approved claims", limit: limit(3000000), amount: 1500000},
+		{
+			name: "limit used up", limit: limit(2000000), amount: 100000, includePending: true,
+			wantErr: "claim exceeds the yearly limit of Rawat Jalan, Rp 0 left of Rp 2.000.000",
+		},
+	}
+
+	s := &claimService{claimRepo: fakeClaimRepo{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category := model.ClaimCategory{Category_code: "RAWAT_JALAN", Name: "Rawat Jalan", Yearly_limit: tt.limit}
+			claim := model.Claim{User_id: uuid.New(), Claim_date: time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC), Amount: tt.amount}
+			err := s.checkClaimLimit(context.Background(), category, claim, tt.includePending)
+			if (tt.wantErr == "") != (err == nil) || err != nil && err.Error() != tt.wantErr {
+				t.Errorf("checkClaimLimit() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateClaimValidation(t *testing.T) {
+	valid := model.CreateClaimModel{User_id: uuid.New(), Category_code: "RAWAT_JALAN", Claim_date: "2024-03-05", Amount: 250000}
+
+	tests := []struct {
+		name    string
+		edit    func(c *model.CreateClaimModel)
+		wantErr string
+	}{
+		{"no user", func(c *model.CreateClaimModel) { c.User_id = uuid.Nil }, "user_id is required"},
+		{"bad date", func(c *model.CreateClaimModel) { c.Claim_date = "05-03-2024" }, "claim_date must be formatted as YYYY-MM-DD"},
+		{"future date", func(c *model.CreateClaimModel) { c.Claim_date = time.Now().AddDate(0, 0, 2).Format("2006-01-02") }, "claim_date cannot be in the future"},
+		{"no amount", func(c *model.CreateClaimModel) { c.Amount = 0 }, "amount must be greater than zero"},
+		{"unknown payout", func(c *model.CreateClaimModel) { c.Payout = "cash" }, "payout must be payroll or transfer"},
+		{"unknown category", func(c *model.CreateClaimModel) { c.Category_code = "DENTAL" }, "unknown claim category DENTAL"},
+		{
+			"over the limit", func(c *model.CreateClaimModel) { c.Amount = 600000 },
+			"claim exceeds the yearly limit of Rawat Jalan, Rp 500.000 left of Rp 3.000.000",
+		},
+	}
+
+	s := NewClaimService(fakeClaimRepo{}, nil, nil, nil, time.Second, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.edit(&c)
+			_, err := s.CreateClaim(context.Background(), c)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("CreateClaim() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
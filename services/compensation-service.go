@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CompensationService interface {
+	//Create
+	CreateCompensation(ctx context.Context, userId uuid.UUID, c model.CreateCompensationModel) (model.CompensationResult, error)
+	//Read
+	GetCompensationList(ctx context.Context, userId uuid.UUID) ([]model.Compensation, error)
+}
+
+type compensationService struct {
+	compensationRepo     repository.CompensationRepo
+	payrollComponentRepo repository.PayrollComponentRepo
+	payrollRecordRepo    repository.PayrollRecordRepo
+	retroPay             RetroPayService
+	timeoutContext       time.Duration
+	db                   *sqlx.DB
+}
+
+func NewCompensationService(compensationRepo repository.CompensationRepo, payrollComponentRepo repository.PayrollComponentRepo, payrollRecordRepo repository.PayrollRecordRepo, retroPay RetroPayService, timeoutContext time.Duration, db *sqlx.DB) CompensationService {
+	return &compensationService{
+		compensationRepo:     compensationRepo,
+		payrollComponentRepo: payrollComponentRepo,
+		payrollRecordRepo:    payrollRecordRepo,
+		retroPay:             retroPay,
+		timeoutContext:       timeoutContext,
+		db:                   db,
+	}
+}
+
+// Salary history of a user, oldest change first
+func (s *compensationService) GetCompensationList(ctx context.Context, userId uuid.UUID) ([]model.Compensation, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	list, err := s.compensationRepo.GetCompensationList(ctx, userId)
+	if err != nil {
+		utils.LogError("Services", "GetCompensationList", err)
+		return list, err
+	}
+	return list, err
+}
+
+// Records a salary change. The change in force before it ends the day
+// before it starts. A change reaching back into periods that were already
+// paid books the rapel of those periods in the current payroll run.
+func (s *compensationService) CreateCompensation(ctx context.Context, userId uuid.UUID, c model.CreateCompensationModel) (model.CompensationResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.CompensationResult
+		err    error
+	)
+
+	effectiveFrom, err := time.Parse("2006-01-02", c.Effective_from)
+	if err != nil {
+		return result, errors.New("effective_from must be formatted as YYYY-MM-DD")
+	}
+	if c.Basic_salary <= 0 {
+		return result, errors.New("basic_salary must be greater than zero")
+	}
+
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "CreateCompensation get payroll components", err)
+		return result, err
+	}
+	byCode := componentsByCode(components)
+	seen := make(map[string]bool, len(c.Allowances))
+	for _, allowance := range c.Allowances {
+		component, ok := byCode[allowance.Component_code]
+		switch {
+		case !ok:
+			return result, errors.New("unknown payroll component " + allowance.Component_code)
+		case component.Is_system, component.Is_one_off, component.Component_type != model.ComponentEarning:
+			return result, errors.New("payroll component " + allowance.Component_code + " is not a fixed allowance")
+		case allowance.Amount <= 0:
+			return result, errors.New("amount of " + allowance.Component_code + " must be greater than zero")
+		case seen[allowance.Component_code]:
+			return result, errors.New("payroll component " + allowance.Component_code + " is listed twice")
+		}
+		seen[allowance.Component_code] = true
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateCompensation open tx", err)
+		return result, err
+	}
+
+	result.Compensation_id, err = s.compensationRepo.CreateCompensation(ctx, tx, model.Compensation{
+		User_id:        userId,
+		Effective_from: effectiveFrom,
+		Basic_salary:   c.Basic_salary,
+		Allowances:     c.Allowances,
+		Pay_grade:      c.Pay_grade,
+		Reason:         c.Reason,
+	})
+	if err == nil {
+		err = s.compensationRepo.UpdateCompensationEffectiveTo(ctx, tx, userId)
+	}
+	if err != nil {
+		utils.LogError("Services", "CreateCompensation", err)
+		utils.CommitOrRollback(tx, "Services CreateCompensation", err)
+		return result, err
+	}
+	utils.CommitOrRollback(tx, "Services CreateCompensation", err)
+
+	// The change is saved either way, a rapel that cannot be booked yet is
+	// reported so it can be retried once the current run is open
+	effectivePeriod := effectiveFrom.Format(PaymentPeriodLayout)
+	paid, err := s.payrollRecordRepo.HasPaidPayrollRecordSince(ctx, userId, effectivePeriod)
+	if err != nil {
+		utils.LogError("Services", "CreateCompensation check paid payroll", err)
+		result.Retro_error = err.Error()
+		return result, nil
+	}
+	if !paid {
+		return result, nil
+	}
+
+	retro, err := s.retroPay.CreateRetroPay(ctx, model.CreateRetroPayModel{
+		User_id:          userId,
+		Effective_period: effectivePeriod,
+		Reason:           c.Reason,
+	})
+	if err != nil {
+		result.Retro_error = err.Error()
+		return result, nil
+	}
+	result.Retro = &retro
+	return result, nil
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -12,6 +14,9 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// Returned when a leave request itself is malformed
+var ErrInvalidLeaveRecord = errors.New("invalid leave record")
+
 type LeaveRecordService interface {
 	//Read
 	GetLeaveRecordDetail(ctx context.Context, req_id uuid.UUID, id uuid.UUID) (model.LeaveRecord, error)
@@ -23,13 +28,15 @@ type LeaveRecordService interface {
 
 type leaveRecordService struct {
 	leaveRecordRepository repository.LeaveRecordRepo
+	payrollPeriodRepo     repository.PayrollPeriodRepo
 	timeoutContext        time.Duration
 	db                    *sqlx.DB
 }
 
-func NewLeaveRecordService(leaveRecordRepo repository.LeaveRecordRepo, timeoutContext time.Duration, db *sqlx.DB) LeaveRecordService {
+func NewLeaveRecordService(leaveRecordRepo repository.LeaveRecordRepo, payrollPeriodRepo repository.PayrollPeriodRepo, timeoutContext time.Duration, db *sqlx.DB) LeaveRecordService {
 	return &leaveRecordService{
 		leaveRecordRepository: leaveRecordRepo,
+		payrollPeriodRepo:     payrollPeriodRepo,
 		timeoutContext:        timeoutContext,
 		db:                    db,
 	}
@@ -79,17 +86,21 @@ func (service *leaveRecordService) CreateLeaveRecord(ctx context.Context, b mode
 	ctx, cancel := context.WithTimeout(ctx, service.timeoutContext)
 	defer cancel()
 
-	tx, err := service.db.Beginx()
-	if err != nil {
-		utils.LogError("Services", "CreateLeaveRecord open tx", err)
-		return uuid.Nil, err
-	}
-
 	// Map model to model model
 	leaveRecord := model.LeaveRecord{}
 	leaveRecord.Request_on, _ = time.Parse(time.RFC3339, b.Request_on+"T00:00:00Z")
-	leaveRecord.From_date, _ = time.Parse(time.RFC3339, b.From_date+"T00:00:00Z")
-	leaveRecord.To_date, _ = time.Parse(time.RFC3339, b.To_date+"T00:00:00Z")
+	var err error
+	leaveRecord.From_date, err = time.Parse("2006-01-02", b.From_date)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: from_date must be formatted as YYYY-MM-DD", ErrInvalidLeaveRecord)
+	}
+	leaveRecord.To_date, err = time.Parse("2006-01-02", b.To_date)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: to_date must be formatted as YYYY-MM-DD", ErrInvalidLeaveRecord)
+	}
+	if leaveRecord.To_date.Before(leaveRecord.From_date) {
+		return uuid.Nil, fmt.Errorf("%w: to_date cannot be before from_date", ErrInvalidLeaveRecord)
+	}
 	leaveRecord.Return_date, _ = time.Parse(time.RFC3339, b.Return_date+"T00:00:00Z")
 	leaveRecord.Amount, _ = strconv.Atoi(b.Amount)
 	leaveRecord.Reason = b.Reason
@@ -98,6 +109,21 @@ func (service *leaveRecordService) CreateLeaveRecord(ctx context.Context, b mode
 	leaveRecord.Status_id = b.Status_id
 	leaveRecord.Leave_id = b.Leave_id
 	leaveRecord.User_id = b.User_id
+
+	// Leave of a closed payroll period would change what was already paid
+	from := leaveRecord.From_date
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(leaveRecord.To_date); month = month.AddDate(0, 1, 0) {
+		err = checkPayrollPeriodOpen(ctx, service.payrollPeriodRepo, month.Format(PaymentPeriodLayout))
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	tx, err := service.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateLeaveRecord open tx", err)
+		return uuid.Nil, err
+	}
 	// Forward to repo
 	record, err := service.leaveRecordRepository.CreateLeaveRecord(ctx, tx, leaveRecord)
 	if err != nil {
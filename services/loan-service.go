@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoanService interface {
+	//Create
+	CreateLoan(ctx context.Context, r model.CreateLoanModel) (uuid.UUID, error)
+	//Read
+	GetLoanList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Loan, error)
+	GetLoanDetail(ctx context.Context, id uuid.UUID) (model.Loan, error)
+	//Update
+	UpdateLoanStatus(ctx context.Context, id uuid.UUID, r model.UpdateLoanStatusModel) (uuid.UUID, error)
+}
+
+type loanService struct {
+	loanRepo       repository.LoanRepo
+	timeoutContext time.Duration
+	db             *sqlx.DB
+}
+
+func NewLoanService(loanRepo repository.LoanRepo, timeoutContext time.Duration, db *sqlx.DB) LoanService {
+	return &loanService{
+		loanRepo:       loanRepo,
+		timeoutContext: timeoutContext,
+		db:             db,
+	}
+}
+
+// Loans of a single user when userId is set, of a single status when status
+// is set
+func (s *loanService) GetLoanList(ctx context.Context, userId uuid.NullUUID, status string) ([]model.Loan, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	switch status {
+	case "", model.LoanPending, model.LoanApproved, model.LoanRejected:
+	default:
+		return make([]model.Loan, 0), errors.New("status must be pending, approved or rejected")
+	}
+
+	list, err := s.loanRepo.GetLoanList(ctx, userId, status)
+	if err != nil {
+		utils.LogError("Services", "GetLoanList", err)
+		return list, err
+	}
+	return list, err
+}
+
+func (s *loanService) GetLoanDetail(ctx context.Context, id uuid.UUID) (model.Loan, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	detail, err := s.loanRepo.GetLoanDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetLoanDetail", err)
+		return detail, err
+	}
+	return detail, err
+}
+
+// Files a loan or kasbon request. Once approved its installments are
+// deducted from the payroll from the start period on.
+func (s *loanService) CreateLoan(ctx context.Context, r model.CreateLoanModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		id  uuid.UUID
+		err error
+	)
+
+	loan := model.Loan{
+		User_id:           r.User_id,
+		Loan_type:         r.Loan_type,
+		Principal:         r.Principal,
+		Installment_count: r.Installment_count,
+		Start_period:      r.Start_period,
+		Reason:            r.Reason,
+	}
+	if loan.User_id == uuid.Nil {
+		return id, errors.New("user_id is required")
+	}
+	switch loan.Loan_type {
+	case "":
+		loan.Loan_type = model.LoanLoan
+	case model.LoanKasbon, model.LoanLoan:
+	default:
+		return id, errors.New("loan_type must be kasbon or loan")
+	}
+	if loan.Principal <= 0 {
+		return id, errors.New("principal must be greater than zero")
+	}
+	if loan.Installment_count == 0 && loan.Loan_type == model.LoanKasbon {
+		loan.Installment_count = 1
+	}
+	if loan.Installment_count <= 0 {
+		return id, errors.New("installment_count must be greater than zero")
+	}
+	if _, err = time.Parse(PaymentPeriodLayout, loan.Start_period); err != nil {
+		return id, errors.New("start_period must be formatted as YYYY-MM")
+	}
+	loan.Installment_amount = loanInstallmentAmount(loan.Principal, loan.Installment_count)
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateLoan open tx", err)
+		return id, err
+	}
+	id, err = s.loanRepo.CreateLoan(ctx, tx, loan)
+	if err != nil {
+		utils.LogError("Services", "CreateLoan", err)
+	}
+	utils.CommitOrRollback(tx, "Services CreateLoan", err)
+	return id, err
+}
+
+// Approves or rejects a pending loan request
+func (s *loanService) UpdateLoanStatus(ctx context.Context, id uuid.UUID, r model.UpdateLoanStatusModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		idResult uuid.UUID
+		err      error
+	)
+
+	if r.Status != model.LoanApproved && r.Status != model.LoanRejected {
+		return idResult, errors.New("status must be approved or rejected")
+	}
+
+	loan, err := s.loanRepo.GetLoanDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "UpdateLoanStatus get loan", err)
+		return idResult, err
+	}
+	if loan.Status != model.LoanPending {
+		return idResult, errors.New("loan request is already " + loan.Status)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "UpdateLoanStatus open tx", err)
+		return idResult, err
+	}
+	idResult, err = s.loanRepo.UpdateLoanStatus(ctx, tx, id, r.Status)
+	if err != nil {
+		utils.LogError("Services", "UpdateLoanStatus", err)
+	}
+	utils.CommitOrRollback(tx, "Services UpdateLoanStatus", err)
+	return idResult, err
+}
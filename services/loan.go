@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+)
+
+// Loan repayment deducted in a payroll period
+type loanDeduction struct {
+	loan_id uuid.UUID
+	amount  int
+	note    string
+}
+
+// Installments of the outstanding loans due in a period, oldest loan first.
+// The final pay settles the whole balance, whether the installments started
+// or not. Repayments never take the net pay below zero, whatever is left
+// stays outstanding.
+func loanInstallments(loans []model.Loan, period string, finalPay bool, available int) []loanDeduction {
+	deductions := make([]loanDeduction, 0, len(loans))
+	for _, loan := range loans {
+		if !finalPay && loan.Start_period > period {
+			continue
+		}
+		amount := min(loan.Installment_amount, loan.Remaining_balance)
+		if finalPay {
+			amount = loan.Remaining_balance
+		}
+		amount = min(amount, available)
+		if amount <= 0 {
+			continue
+		}
+		available -= amount
+
+		balance := loan.Remaining_balance - amount
+		note := fmt.Sprintf("%s installment %d of %d, balance %s", loanTypeName(loan.Loan_type), loan.Repaid/loan.Installment_amount+1, loan.Installment_count, utils.FormatRupiah(balance))
+		if finalPay {
+			note = fmt.Sprintf("%s settled from the final pay, balance %s", loanTypeName(loan.Loan_type), utils.FormatRupiah(balance))
+		}
+		deductions = append(deductions, loanDeduction{loan_id: loan.Loan_id, amount: amount, note: note})
+	}
+	return deductions
+}
+
+func loanTypeName(loanType string) string {
+	if loanType == model.LoanKasbon {
+		return "Kasbon"
+	}
+	return "Loan"
+}
+
+// Even installments rounded up to the rupiah, the last one takes what is left
+func loanInstallmentAmount(principal int, count int) int {
+	return (principal + count - 1) / count
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/google/uuid"
+)
+
+func TestLoanInstallmentAmount(t *testing.T) {
+	tests := []struct {
+		principal, count, want int
+	}{
+		{1000000, 1, 1000000},
+		{1000000, 4, 250000},
+		{10000000, 3, 3333334},
+		{100, 7, 15},
+	}
+
+	for _, tt := range tests {
+		if got := loanInstallmentAmount(tt.principal, tt.count); got != tt.want {
+			t.Errorf("loanInstallmentAmount(%d, %d) = %d, want %d", tt.principal, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestLoanInstallments(t *testing.T) {
+	// Oldest first: the last installment of a loan, a kasbon due this month
+	// and a loan starting in May
+	loans := []model.Loan{
+		{Loan_id: uuid.New(), Loan_type: model.LoanLoan, Principal: 10000000, Installment_count: 3, Installment_amount: 3333334, Start_period: "2024-01", Repaid: 6666668, Remaining_balance: 3333332},
+		{Loan_id: uuid.New(), Loan_type: model.LoanKasbon, Principal: 1000000, Installment_count: 1, Installment_amount: 1000000, Start_period: "2024-03", Remaining_balance: 1000000},
+		{Loan_id: uuid.New(), Loan_type: model.LoanLoan, Principal: 6000000, Installment_count: 6, Installment_amount: 1000000, Start_period: "2024-05", Remaining_balance: 6000000},
+	}
+
+	tests := []struct {
+		name      string
+		period    string
+		finalPay  bool
+		available int
+		want      []loanDeduction
+	}{
+		{
+			name: "due installments", period: "2024-03", available: 10000000,
+			want: []loanDeduction{
+				{loans[0].Loan_id, 3333332, "Loan installment 3 of 3, balance Rp 0"},
+				{loans[1].Loan_id, 1000000, "Kasbon installment 1 of 1, balance Rp 0"},
+			},
+		},
+		{
+			name: "kasbon not due yet", period: "2024-02", available: 10000000,
+			want: []loanDeduction{{loans[0].Loan_id, 3333332, "Loan installment 3 of 3, balance Rp 0"}},
+		},
+		{
+			name: "net pay runs out", period: "2024-03", available: 4000000,
+			want: []loanDeduction{
+				{loans[0].Loan_id, 3333332, "Loan installment 3 of 3, balance Rp 0"},
+				{loans[1].Loan_id, 666668, "Kasbon installment 1 of 1, balance Rp 333.332"},
+			},
+		},
+		{name: "no net pay", period: "2024-03", available: 0, want: []loanDeduction{}},
+		{
+			name: "final pay settles every loan", period: "2024-03", finalPay: true, available: 20000000,
+			want: []loanDeduction{
+				{loans[0].Loan_id, 3333332, "Loan settled from the final pay, balance Rp 0"},
+				{loans[1].Loan_id, 1000000, "Kasbon settled from the final pay, balance Rp 0"},
+				{loans[2].Loan_id, 6000000, "Loan settled from the final pay, balance Rp 0"},
+			},
+		},
+		{
+			name: "final pay short of the balance", period: "2024-03", finalPay: true, available: 8000000,
+			want: []loanDeduction{
+				{loans[0].Loan_id, 3333332, "Loan settled from the final pay, balance Rp 0"},
+				{loans[1].Loan_id, 1000000, "Kasbon settled from the final pay, balance Rp 0"},
+				{loans[2].Loan_id, 3666668, "Loan settled from the final pay, balance Rp 2.333.332"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loanInstallments(loans, tt.period, tt.finalPay, tt.available)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loanInstallments() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type OvertimeService interface {
+	//Create
+	CreateOvertimeRecord(ctx context.Context, r model.CreateOvertimeRecordModel) (uuid.UUID, error)
+	//Read
+	GetOvertimeRecordList(ctx context.Context, userId uuid.NullUUID, period string) ([]model.OvertimeRecord, error)
+	GetOvertimeRecordDetail(ctx context.Context, id uuid.UUID) (model.OvertimeRecord, error)
+	//Update
+	UpdateOvertimeStatus(ctx context.Context, id uuid.UUID, r model.UpdateOvertimeStatusModel) (uuid.UUID, error)
+}
+
+type overtimeService struct {
+	overtimeRepo   repository.OvertimeRepo
+	payrollRunRepo repository.PayrollRunRepo
+	workDays       int
+	timeoutContext time.Duration
+	db             *sqlx.DB
+}
+
+func NewOvertimeService(overtimeRepo repository.OvertimeRepo, payrollRunRepo repository.PayrollRunRepo, workDays int, timeoutContext time.Duration, db *sqlx.DB) OvertimeService {
+	if workDays != 6 {
+		workDays = 5
+	}
+	return &overtimeService{
+		overtimeRepo:   overtimeRepo,
+		payrollRunRepo: payrollRunRepo,
+		workDays:       workDays,
+		timeoutContext: timeoutContext,
+		db:             db,
+	}
+}
+
+// Overtime worked in a payment period, of a single user when userId is set
+func (s *overtimeService) GetOvertimeRecordList(ctx context.Context, userId uuid.NullUUID, period string) ([]model.OvertimeRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	start, err := time.Parse(PaymentPeriodLayout, period)
+	if err != nil {
+		return make([]model.OvertimeRecord, 0), errors.New("period must be formatted as YYYY-MM")
+	}
+
+	list, err := s.overtimeRepo.GetOvertimeRecordList(ctx, userId, start, start.AddDate(0, 1, -1))
+	if err != nil {
+		utils.LogError("Services", "GetOvertimeRecordList", err)
+		return list, err
+	}
+	return list, err
+}
+
+func (s *overtimeService) GetOvertimeRecordDetail(ctx context.Context, id uuid.UUID) (model.OvertimeRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	detail, err := s.overtimeRepo.GetOvertimeRecordDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetOvertimeRecordDetail", err)
+		return detail, err
+	}
+	return detail, err
+}
+
+// Files an overtime request, it is paid once approved
+func (s *overtimeService) CreateOvertimeRecord(ctx context.Context, r model.CreateOvertimeRecordModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		id  uuid.UUID
+		err error
+	)
+
+	record := model.OvertimeRecord{
+		User_id:  r.User_id,
+		Hours:    r.Hours,
+		Day_type: r.Day_type,
+		Reason:   r.Reason,
+	}
+	if record.User_id == uuid.Nil {
+		return id, errors.New("user_id is required")
+	}
+	record.Overtime_date, err = time.Parse("2006-01-02", r.Overtime_date)
+	if err != nil {
+		return id, errors.New("overtime_date must be formatted as YYYY-MM-DD")
+	}
+	if record.Hours <= 0 || record.Hours > 24 {
+		return id, errors.New("hours must be greater than zero and at most 24")
+	}
+	switch record.Day_type {
+	case "":
+		record.Day_type = overtimeDayType(record.Overtime_date, s.workDays)
+	case model.OvertimeWorkday, model.OvertimeRestDay, model.OvertimePublicHoliday:
+	default:
+		return id, errors.New("day_type must be workday, rest_day or public_holiday")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateOvertimeRecord open tx", err)
+		return id, err
+	}
+	id, err = s.overtimeRepo.CreateOvertimeRecord(ctx, tx, record)
+	if err != nil {
+		utils.LogError("Services", "CreateOvertimeRecord", err)
+	}
+	utils.CommitOrRollback(tx, "Services CreateOvertimeRecord", err)
+	return id, err
+}
+
+// Approves or rejects a pending overtime request. Approval is refused once
+// the payroll of the overtime's period is locked, it could not be paid anymore.
+func (s *overtimeService) UpdateOvertimeStatus(ctx context.Context, id uuid.UUID, r model.UpdateOvertimeStatusModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		idResult uuid.UUID
+		err      error
+	)
+
+	if r.Status != model.OvertimeApproved && r.Status != model.OvertimeRejected {
+		return idResult, errors.New("status must be approved or rejected")
+	}
+
+	record, err := s.overtimeRepo.GetOvertimeRecordDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "UpdateOvertimeStatus get record", err)
+		return idResult, err
+	}
+	if record.Status != model.OvertimePending {
+		return idResult, errors.New("overtime request is already " + record.Status)
+	}
+
+	if r.Status == model.OvertimeApproved {
+		period := record.Overtime_date.Format(PaymentPeriodLayout)
+		run, err := s.payrollRunRepo.GetPayrollRunByPeriod(ctx, period, model.PayrollRunRegular)
+		if err == nil && IsPayrollRunLocked(run.Status) {
+			return idResult, errors.New("payroll run of " + period + " is " + run.Status + ", the overtime can no longer be paid")
+		}
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "UpdateOvertimeStatus open tx", err)
+		return idResult, err
+	}
+	idResult, err = s.overtimeRepo.UpdateOvertimeStatus(ctx, tx, id, r.Status)
+	if err != nil {
+		utils.LogError("Services", "UpdateOvertimeStatus", err)
+	}
+	utils.CommitOrRollback(tx, "Services UpdateOvertimeStatus", err)
+	return idResult, err
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+)
+
+// Kepmenaker 102/2004 article 11, an hourly wage is 1/173 of the monthly wage
+const overtimeMonthlyHours = 173
+
+// PP 35/2021 article 26, overtime is at most 4 hours a day
+const overtimeDailyHours = 4
+
+// Consecutive overtime hours paid at the same multiplier, the last band
+// also covers every hour after it
+type overtimeBand struct {
+	hours      float64
+	multiplier float64
+}
+
+var (
+	// Workday: the first hour at 1.5x, every next hour at 2x
+	overtimeWorkdayScale = []overtimeBand{{1, 1.5}, {math.Inf(1), 2}}
+	// Rest day or public holiday in a 5 day week: 8 hours at 2x, the 9th
+	// at 3x, the 10th and 11th at 4x
+	overtimeFiveDayScale = []overtimeBand{{8, 2}, {1, 3}, {math.Inf(1), 4}}
+	// Rest day or public holiday in a 6 day week: 7 hours at 2x, the 8th
+	// at 3x, the 9th and 10th at 4x
+	overtimeSixDayScale = []overtimeBand{{7, 2}, {1, 3}, {math.Inf(1), 4}}
+	// Public holiday on the shortest workday of a 6 day week: 5 hours at 2x,
+	// the 6th at 3x, the 7th and 8th at 4x
+	overtimeShortDayScale = []overtimeBand{{5, 2}, {1, 3}, {math.Inf(1), 4}}
+)
+
+// Overtime pay of a single overtime record
+type overtimePay struct {
+	paidHours float64
+	amount    int
+	note      string
+}
+
+// Applies the statutory multipliers to the hours of an overtime record.
+// wage is the monthly wage the hourly rate is derived from and workDays the
+// length of the company's work week, which picks the rest day scale.
+func calculateOvertime(record model.OvertimeRecord, wage int, workDays int) overtimePay {
+	scale := overtimeWorkdayScale
+	if record.Day_type != model.OvertimeWorkday {
+		scale = overtimeFiveDayScale
+		if workDays == 6 {
+			scale = overtimeSixDayScale
+			// Saturday is the short day of a 6 day week
+			if record.Day_type == model.OvertimePublicHoliday && record.Overtime_date.Weekday() == time.Saturday {
+				scale = overtimeShortDayScale
+			}
+		}
+	}
+
+	paidHours, left := 0.0, record.Hours
+	for _, band := range scale {
+		hours := math.Min(left, band.hours)
+		paidHours += hours * band.multiplier
+		left -= hours
+		if left <= 0 {
+			break
+		}
+	}
+
+	hourly := float64(wage) / overtimeMonthlyHours
+	return overtimePay{
+		paidHours: paidHours,
+		amount:    roundRupiah(paidHours * hourly),
+		note: fmt.Sprintf("%s %s, %g hours paid as %g x 1/173 of %s",
+			record.Overtime_date.Format("02 Jan"), overtimeDayName(record.Day_type), record.Hours, paidHours, utils.FormatRupiah(wage)),
+	}
+}
+
+// Payroll lines of the overtime worked in a period, one per overtime record
+func overtimeItems(byCode map[string]model.PayrollComponent, overtime []model.OvertimeRecord, wage int, workDays int) []model.PayrollItem {
+	items := make([]model.PayrollItem, 0, len(overtime))
+	for _, record := range overtime {
+		pay := calculateOvertime(record, wage, workDays)
+		item := systemItem(byCode, model.ComponentOvertime, pay.amount)
+		item.Note = pay.note
+		item.Reference_id = uuid.NullUUID{UUID: record.Overtime_id, Valid: true}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Overtime records of the monthly hours of a payroll input sheet. The sheet
+// has no dates, so the hours count as workday overtime split into days of the
+// statutory maximum and a shorter last day, each paying its first hour at
+// 1.5x, all dated on the given date. The ids are set here so the lines of the
+// record saved with the import refer to the overtime saved with it.
+func importedOvertime(userId uuid.UUID, period string, date time.Time, hours float64) []model.OvertimeRecord {
+	list := make([]model.OvertimeRecord, 0)
+	// Hundredths of an hour, the precision overtime hours are stored with
+	left := int(math.Round(hours * 100))
+	for left > 0 {
+		day := min(left, overtimeDailyHours*100)
+		list = append(list, model.OvertimeRecord{
+			Overtime_id:   uuid.New(),
+			User_id:       userId,
+			Overtime_date: date,
+			Hours:         float64(day) / 100,
+			Day_type:      model.OvertimeWorkday,
+			Reason:        "Imported with the payroll input of " + period,
+			Status:        model.OvertimeApproved,
+			Import_period: period,
+		})
+		left -= day
+	}
+	return list
+}
+
+// Monthly wage behind the hourly overtime rate: basic salary plus fixed
+// allowances, but at least 75% of the whole recurring pay when the fixed part
+// is smaller than that
+func overtimeWage(items []model.PayrollItem) int {
+	fixed, total := 0, 0
+	for _, item := range items {
+		if item.Component_type != model.ComponentEarning || item.Is_one_off {
+			continue
+		}
+		total += item.Amount
+		if item.Is_bpjs_base {
+			fixed += item.Amount
+		}
+	}
+	return max(fixed, roundRupiah(float64(total)*0.75))
+}
+
+// Day type of an overtime date when the request does not name one
+func overtimeDayType(date time.Time, workDays int) string {
+	switch {
+	case date.Weekday() == time.Sunday:
+		return model.OvertimeRestDay
+	case date.Weekday() == time.Saturday && workDays == 5:
+		return model.OvertimeRestDay
+	default:
+		return model.OvertimeWorkday
+	}
+}
+
+func overtimeDayName(dayType string) string {
+	switch dayType {
+	case model.OvertimeRestDay:
+		return "rest day"
+	case model.OvertimePublicHoliday:
+		return "public holiday"
+	default:
+		return "workday"
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestCalculateOvertime(t *testing.T) {
+	var (
+		monday   = time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+		saturday = time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC)
+		sunday   = time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+	)
+	// 1/173 of the wage is an hourly rate of 10.000
+	const wage = 1730000
+
+	tests := []struct {
+		name      string
+		date      time.Time
+		dayType   string
+		hours     float64
+		workDays  int
+		paidHours float64
+	}{
+		{"workday first half hour", monday, model.OvertimeWorkday, 0.5, 5, 0.75},
+		{"workday first hour", monday, model.OvertimeWorkday, 1, 5, 1.5},
+		{"workday next hours at 2x", monday, model.OvertimeWorkday, 3, 5, 5.5},
+		{"workday same in a 6 day week", monday, model.OvertimeWorkday, 3, 6, 5.5},
+
+		{"5 day week rest day 8 hours", sunday, model.OvertimeRestDay, 8, 5, 16},
+		{"5 day week rest day 9th hour at 3x", sunday, model.OvertimeRestDay, 9, 5, 19},
+		{"5 day week rest day 10th and 11th hour at 4x", sunday, model.OvertimeRestDay, 11, 5, 27},
+		{"5 day week Saturday holiday", saturday, model.OvertimePublicHoliday, 9, 5, 19},
+		{"5 day week weekday holiday", monday, model.OvertimePublicHoliday, 10, 5, 23},
+
+		{"6 day week rest day 7 hours", sunday, model.OvertimeRestDay, 7, 6, 14},
+		{"6 day week rest day 8th hour at 3x", sunday, model.OvertimeRestDay, 8, 6, 17},
+		{"6 day week rest day 9th and 10th hour at 4x", sunday, model.OvertimeRestDay, 10, 6, 25},
+		{"6 day week weekday holiday", monday, model.OvertimePublicHoliday, 8, 6, 17},
+		{"6 day week holiday on the short day 5 hours", saturday, model.OvertimePublicHoliday, 5, 6, 10},
+		{"6 day week holiday on the short day 6th hour at 3x", saturday, model.OvertimePublicHoliday, 6, 6, 13},
+		{"6 day week holiday on the short day 7th and 8th hour at 4x", saturday, model.OvertimePublicHoliday, 8, 6, 21},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateOvertime(model.OvertimeRecord{
+				Overtime_date: tt.date,
+				Day_type:      tt.dayType,
+				Hours:         tt.hours,
+			}, wage, tt.workDays)
+			if got.paidHours != tt.paidHours {
+				t.Errorf("paidHours = %v, want %v", got.paidHours, tt.paidHours)
+			}
+			if want := roundRupiah(tt.paidHours * 10000); got.amount != want {
+				t.Errorf("amount = %d, want %d", got.amount, want)
+			}
+		})
+	}
+}
+
+func TestOvertimeWage(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []model.PayrollItem
+		wage  int
+	}{
+		{
+			name: "basic salary and fixed allowances",
+			items: []model.PayrollItem{
+				{Component_type: model.ComponentEarning, Amount: 8000000, Is_bpjs_base: true},
+				{Component_type: model.ComponentEarning, Amount: 1000000, Is_bpjs_base: true},
+			},
+			wage: 9000000,
+		},
+		{
+			name: "at least 75% of the recurring pay",
+			items: []model.PayrollItem{
+				{Component_type: model.ComponentEarning, Amount: 5000000, Is_bpjs_base: true},
+				{Component_type: model.ComponentEarning, Amount: 3000000},
+			},
+			wage: 6000000,
+		},
+		{
+			name: "one-off earnings and deductions left out",
+			items: []model.PayrollItem{
+				{Component_type: model.ComponentEarning, Amount: 8000000, Is_bpjs_base: true},
+				{Component_type: model.ComponentEarning, Amount: 4000000, Is_one_off: true},
+				{Component_type: model.ComponentDeduction, Amount: 500000},
+			},
+			wage: 8000000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overtimeWage(tt.items); got != tt.wage {
+				t.Errorf("overtimeWage() = %d, want %d", got, tt.wage)
+			}
+		})
+	}
+}
+
+func TestOvertimeDayType(t *testing.T) {
+	tests := []struct {
+		date     time.Time
+		workDays int
+		dayType  string
+	}{
+		{time.Date(2024, time.March, 8, 0, 0, 0, 0, time.UTC), 5, model.OvertimeWorkday},
+		{time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC), 5, model.OvertimeRestDay},
+		{time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC), 6, model.OvertimeWorkday},
+		{time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC), 6, model.OvertimeRestDay},
+	}
+
+	for _, tt := range tests {
+		if got := overtimeDayType(tt.date, tt.workDays); got != tt.dayType {
+			t.Errorf("overtimeDayType(%s, %d) = %q, want %q", tt.date.Format("Mon 02 Jan"), tt.workDays, got, tt.dayType)
+		}
+	}
+}
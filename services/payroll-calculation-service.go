@@ -0,0 +1,530 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Payment period layout accepted by the calculation engine, e.g. 2024-01
+const PaymentPeriodLayout = "2006-01"
+
+type PayrollCalculationService interface {
+	Calculate(ctx context.Context, in model.PayrollCalculationInput) (model.PayrollCalculationResult, error)
+	CalculateIrregular(ctx context.Context, in model.PayrollIrregularInput) (model.PayrollCalculationResult, error)
+	Simulate(ctx context.Context, in model.PayrollSimulationModel) (model.PayrollSimulationResult, error)
+}
+
+type payrollCalculationService struct {
+	userRepo             repository.UserRepo
+	payrollRecordRepo    repository.PayrollRecordRepo
+	payrollComponentRepo repository.PayrollComponentRepo
+	leaveRecordRepo      repository.LeaveRecordRepo
+	compensationRepo     repository.CompensationRepo
+	overtimeRepo         repository.OvertimeRepo
+	retroPayRepo         repository.RetroPayRepo
+	loanRepo             repository.LoanRepo
+	claimRepo            repository.ClaimRepo
+	pph21                Pph21Service
+	bpjs                 BpjsService
+	prorationMethod      string
+	overtimeWorkDays     int
+	timeoutContext       time.Duration
+	db                   *sqlx.DB
+}
+
+func NewPayrollCalculationService(userRepo repository.UserRepo, payrollRecordRepo repository.PayrollRecordRepo, payrollComponentRepo repository.PayrollComponentRepo, leaveRecordRepo repository.LeaveRecordRepo, compensationRepo repository.CompensationRepo, overtimeRepo repository.OvertimeRepo, retroPayRepo repository.RetroPayRepo, loanRepo repository.LoanRepo, claimRepo repository.ClaimRepo, pph21 Pph21Service, bpjs BpjsService, prorationMethod string, overtimeWorkDays int, timeoutContext time.Duration, db *sqlx.DB) PayrollCalculationService {
+	if prorationMethod == "" {
+		prorationMethod = model.ProrationCalendarDay
+	}
+	if overtimeWorkDays != 6 {
+		overtimeWorkDays = 5
+	}
+	return &payrollCalculationService{
+		userRepo:             userRepo,
+		payrollRecordRepo:    payrollRecordRepo,
+		payrollComponentRepo: payrollComponentRepo,
+		leaveRecordRepo:      leaveRecordRepo,
+		compensationRepo:     compensationRepo,
+		overtimeRepo:         overtimeRepo,
+		retroPayRepo:         retroPayRepo,
+		loanRepo:             loanRepo,
+		claimRepo:            claimRepo,
+		pph21:                pph21,
+		bpjs:                 bpjs,
+		prorationMethod:      prorationMethod,
+		overtimeWorkDays:     overtimeWorkDays,
+		timeoutContext:       timeoutContext,
+		db:                   db,
+	}
+}
+
+func (s *payrollCalculationService) Calculate(ctx context.Context, in model.PayrollCalculationInput) (model.PayrollCalculationResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.PayrollCalculationResult
+		err    error
+	)
+
+	err = validateCalculationInput(in)
+	if err != nil {
+		utils.LogError("Services", "Calculate validate input", err)
+		return result, err
+	}
+
+	// The employee's PTKP status drives the PPh 21 category and the
+	// position's risk class drives the JKK rate
+	user, err := s.userRepo.GetUserDetail(ctx, in.User_id)
+	if err != nil {
+		utils.LogError("Services", "Calculate get user", err)
+		return result, err
+	}
+	period, _ := time.Parse(PaymentPeriodLayout, in.Payment_period)
+
+	// Joiners and leavers only earn part of the month
+	proration, employedFrom, employedTo, err := prorate(s.prorationMethod, period, user.Join_date, user.Termination_date)
+	if err != nil {
+		utils.LogError("Services", "Calculate prorate", err)
+		return result, err
+	}
+	result.Proration = proration
+
+	// The salary in force at the end of the employed days of the period
+	compensation, err := s.compensationRepo.GetCompensationInForce(ctx, in.User_id, employedTo)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errors.New("user has no compensation in force in " + in.Payment_period)
+		return result, err
+	}
+	if err != nil {
+		utils.LogError("Services", "Calculate get compensation", err)
+		return result, err
+	}
+
+	// Leave of the whole year so far is needed to know when a quota ran out
+	leaves, err := s.leaveRecordRepo.GetDeductibleLeaveList(ctx, in.User_id, time.Date(period.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), employedTo)
+	if err != nil {
+		utils.LogError("Services", "Calculate get deductible leave", err)
+		return result, err
+	}
+
+	overtime, err := s.overtimeRepo.GetApprovedOvertimeList(ctx, in.User_id, employedFrom, employedTo)
+	if err != nil {
+		utils.LogError("Services", "Calculate get approved overtime", err)
+		return result, err
+	}
+	// A sheet being imported replaces the overtime of an earlier import
+	if in.Overtime != nil {
+		requested := make([]model.OvertimeRecord, 0, len(overtime)+len(in.Overtime))
+		for _, record := range overtime {
+			if record.Import_period != in.Payment_period {
+				requested = append(requested, record)
+			}
+		}
+		overtime = append(requested, in.Overtime...)
+	}
+
+	// Rapel of closed periods recomputed after a back-dated salary change
+	retros, err := s.retroPayRepo.GetRetroPayList(ctx, uuid.NullUUID{UUID: in.User_id, Valid: true}, in.Payment_period)
+	if err != nil {
+		utils.LogError("Services", "Calculate get retro pay", err)
+		return result, err
+	}
+	retros = append(retros, in.Retro_pays...)
+
+	loans, err := s.loanRepo.GetOutstandingLoanList(ctx, in.User_id, in.Payment_period)
+	if err != nil {
+		utils.LogError("Services", "Calculate get outstanding loans", err)
+		return result, err
+	}
+
+	claims, err := s.claimRepo.GetPayrollClaimList(ctx, in.User_id, in.Payment_period)
+	if err != nil {
+		utils.LogError("Services", "Calculate get payroll claims", err)
+		return result, err
+	}
+
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "Calculate get payroll components", err)
+		return result, err
+	}
+
+	result.User_id = in.User_id
+	result.Payment_period = in.Payment_period
+	result.Basic_salary = compensation.Basic_salary
+
+	result.Items, err = buildPayrollItems(compensation, in.Items, components)
+	if err != nil {
+		utils.LogError("Services", "Calculate build payroll items", err)
+		return result, err
+	}
+	byCode := componentsByCode(components)
+
+	// The overtime rate follows the full monthly wage, before proration
+	wage := overtimeWage(result.Items)
+
+	// Recurring earnings follow the employed days, one-off payments are kept
+	// whole. The monthly amount is also the base of the leave deductions.
+	monthly := 0
+	for i, item := range result.Items {
+		if item.Component_type == model.ComponentEarning && !item.Is_one_off {
+			monthly += item.Amount
+			result.Items[i].Amount = prorateAmount(item.Amount, result.Proration)
+		}
+	}
+
+	// Unpaid leave and leave over quota, one line per leave request
+	for _, deduction := range leaveDeductions(s.prorationMethod, employedFrom, employedTo, leaves) {
+		item := systemItem(byCode, model.ComponentUnpaidLeave, roundRupiah(float64(monthly)*float64(deduction.days)/float64(result.Proration.Period_days)))
+		item.Note = deduction.note
+		item.Reference_id = uuid.NullUUID{UUID: deduction.request_id, Valid: true}
+		result.Items = append(result.Items, item)
+		result.Proration.Unpaid_days += deduction.days
+	}
+	result.Proration.Paid_days = result.Proration.Employed_days - result.Proration.Unpaid_days
+
+	// Approved overtime worked in the period, one line per overtime request
+	result.Items = append(result.Items, overtimeItems(byCode, overtime, wage, s.overtimeWorkDays)...)
+
+	// Approved expense claims booked in the period, one line per claim
+	for _, claim := range claims {
+		item := systemItem(byCode, model.ComponentReimbursement, claim.Amount)
+		item.Note = claimNote(claim)
+		item.Reference_id = uuid.NullUUID{UUID: claim.Claim_id, Valid: true}
+		result.Items = append(result.Items, item)
+	}
+
+	var bpjsBase, taxableIncome int
+	result.Gross_salary, bpjsBase, taxableIncome = payrollBases(result.Items)
+
+	result.Bpjs_detail, err = s.bpjs.Calculate(ctx, max(bpjsBase, 0), user.Jkk_risk_class)
+	if err != nil {
+		utils.LogError("Services", "Calculate bpjs", err)
+		return result, err
+	}
+	result.Bpjs = result.Bpjs_detail.Employee_total
+	result.Bpjs_employer = result.Bpjs_detail.Employer_total
+
+	taxInput := model.Pph21Input{
+		Ptkp_status:       user.Ptkp_status,
+		Method:            in.Tax_method,
+		Period:            period,
+		Gross_income:      max(taxableIncome, 0),
+		Employer_premiums: result.Bpjs_detail.Employer_taxable,
+		Pension_deduction: result.Bpjs_detail.Employee_pension,
+	}
+	// December needs what was already withheld this year for the true-up
+	if period.Month() == time.December {
+		ytd, err := s.payrollRecordRepo.GetPph21YearToDate(ctx, in.User_id, in.Payment_period)
+		if err != nil {
+			utils.LogError("Services", "Calculate get pph21 year to date", err)
+			return result, err
+		}
+		taxInput.Ytd_gross_income = ytd.Gross_income
+		taxInput.Ytd_pension_deduction = ytd.Pension_deduction
+		taxInput.Ytd_tax = ytd.Tax
+		taxInput.Ytd_months = ytd.Months
+	}
+	for _, retro := range retros {
+		if retroInTrueUp(retro, period) {
+			taxInput.Gross_income += retro.Taxable_difference - retro.Employer_premiums_difference
+			taxInput.Employer_premiums += retro.Employer_premiums_difference
+			taxInput.Pension_deduction += retro.Pension_difference
+		}
+	}
+
+	result.Tax_detail, err = s.pph21.CalculateMonthly(taxInput)
+	if err != nil {
+		utils.LogError("Services", "Calculate pph21", err)
+		return result, err
+	}
+
+	// Rapel is taxed at the rates of the period it was earned in, the
+	// December true-up already took the ones of its own year into account
+	for _, retro := range retros {
+		item := systemItem(byCode, model.ComponentRapel, retro.Earning_difference)
+		item.Note = retroPayNote(retro)
+		item.Reference_id = uuid.NullUUID{UUID: retro.Retro_id, Valid: true}
+		result.Items = append(result.Items, item)
+		result.Gross_salary += item.Amount
+
+		mergeRetroBpjs(&result.Bpjs_detail, retro)
+		if !retroInTrueUp(retro, period) {
+			addRetroTax(&result.Tax_detail, retro)
+		}
+	}
+	result.Bpjs = result.Bpjs_detail.Employee_total
+	result.Bpjs_employer = result.Bpjs_detail.Employer_total
+	result.Tax_method = result.Tax_detail.Method
+	result.Tax_allowance = result.Tax_detail.Tax_allowance
+	result.Tax = result.Tax_detail.Tax
+
+	// Loan installments come out of what is left after BPJS and PPh 21, the
+	// final pay of a leaver settles the loans in full
+	available := result.Tax_allowance - result.Bpjs - result.Tax_detail.Employee_tax
+	for _, item := range result.Items {
+		if item.Component_type == model.ComponentEarning {
+			available += item.Amount
+		} else {
+			available -= item.Amount
+		}
+	}
+	finalPay := user.Termination_date != nil && user.Termination_date.Format(PaymentPeriodLayout) == in.Payment_period
+	for _, deduction := range loanInstallments(loans, in.Payment_period, finalPay, available) {
+		item := systemItem(byCode, model.ComponentLoanInstallment, deduction.amount)
+		item.Note = deduction.note
+		item.Reference_id = uuid.NullUUID{UUID: deduction.loan_id, Valid: true}
+		result.Items = append(result.Items, item)
+	}
+
+	settlePayrollItems(&result, byCode)
+	return result, err
+}
+
+// Gross earnings of the items along with the wage BPJS and PPh 21 are
+// computed on, deductions reduce both bases
+func payrollBases(items []model.PayrollItem) (int, int, int) {
+	var gross, bpjsBase, taxableIncome int
+	for _, item := range items {
+		sign := 1
+		if item.Component_type == model.ComponentDeduction {
+			sign = -1
+		} else {
+			gross += item.Amount
+		}
+		if item.Is_bpjs_base {
+			bpjsBase += sign * item.Amount
+		}
+		if item.Is_taxable {
+			taxableIncome += sign * item.Amount
+		}
+	}
+	return gross, bpjsBase, taxableIncome
+}
+
+// Adds the statutory lines so the net pay can be derived from the items
+// alone, then totals the items into the take home pay
+func settlePayrollItems(result *model.PayrollCalculationResult, byCode map[string]model.PayrollComponent) {
+	if result.Tax_allowance != 0 {
+		result.Items = append(result.Items, systemItem(byCode, model.ComponentTaxAllowance, result.Tax_allowance))
+		result.Gross_salary += result.Tax_allowance
+	}
+	if result.Bpjs != 0 {
+		result.Items = append(result.Items, systemItem(byCode, model.ComponentBpjsEmployee, result.Bpjs))
+	}
+	if result.Tax_detail.Employee_tax != 0 {
+		result.Items = append(result.Items, systemItem(byCode, model.ComponentPph21, result.Tax_detail.Employee_tax))
+	}
+
+	for _, item := range result.Items {
+		switch {
+		case item.Component_type == model.ComponentEarning:
+			result.Total_salary += item.Amount
+			if item.Component_code != model.ComponentBasicSalary && item.Component_code != model.ComponentTaxAllowance {
+				result.Allowance += item.Amount
+			}
+		default:
+			result.Total_salary -= item.Amount
+			if item.Component_code != model.ComponentBpjsEmployee && item.Component_code != model.ComponentPph21 {
+				result.Deduction += item.Amount
+			}
+		}
+	}
+}
+
+// Computes a payment made outside of the regular payroll, such as THR. It
+// carries no BPJS and its PPh 21 is the extra tax it causes on top of the
+// month's regular income.
+func (s *payrollCalculationService) CalculateIrregular(ctx context.Context, in model.PayrollIrregularInput) (model.PayrollCalculationResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.PayrollCalculationResult
+		err    error
+	)
+
+	if in.User_id == uuid.Nil {
+		return result, errors.New("user_id is required")
+	}
+	period, err := time.Parse(PaymentPeriodLayout, in.Payment_period)
+	if err != nil {
+		return result, errors.New("payment_period must be formatted as YYYY-MM")
+	}
+	if in.Amount <= 0 {
+		return result, errors.New("amount of " + in.Component_code + " must be greater than zero")
+	}
+
+	user, err := s.userRepo.GetUserDetail(ctx, in.User_id)
+	if err != nil {
+		utils.LogError("Services", "CalculateIrregular get user", err)
+		return result, err
+	}
+
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "CalculateIrregular get payroll components", err)
+		return result, err
+	}
+	byCode := componentsByCode(components)
+	if _, ok := byCode[in.Component_code]; !ok {
+		return result, errors.New("unknown payroll component " + in.Component_code)
+	}
+
+	// The regular record of the month is the income the payment comes on top of
+	taxInput := model.Pph21Input{
+		Ptkp_status:    user.Ptkp_status,
+		Method:         in.Tax_method,
+		Period:         period,
+		Gross_income:   in.Amount,
+		Regular_income: in.Regular_income,
+	}
+	regular, err := s.payrollRecordRepo.GetPayrollRecordByPeriod(ctx, in.User_id, in.Payment_period, model.PayrollRunRegular)
+	switch {
+	case err == nil:
+		taxInput.Regular_income = regular.Tax_detail.Taxable_income
+		taxInput.Pension_deduction = regular.Tax_detail.Pension_deduction
+	case !errors.Is(err, sql.ErrNoRows):
+		utils.LogError("Services", "CalculateIrregular get regular record", err)
+		return result, err
+	}
+	if period.Month() == time.December {
+		ytd, err := s.payrollRecordRepo.GetPph21YearToDate(ctx, in.User_id, in.Payment_period)
+		if err != nil {
+			utils.LogError("Services", "CalculateIrregular get pph21 year to date", err)
+			return result, err
+		}
+		taxInput.Ytd_gross_income = ytd.Gross_income
+		taxInput.Ytd_pension_deduction = ytd.Pension_deduction
+		taxInput.Ytd_tax = ytd.Tax
+		taxInput.Ytd_months = ytd.Months
+	}
+
+	result.Tax_detail, err = s.pph21.CalculateIrregular(taxInput)
+	if err != nil {
+		utils.LogError("Services", "CalculateIrregular pph21", err)
+		return result, err
+	}
+
+	result.User_id = in.User_id
+	result.Payment_period = in.Payment_period
+	result.Tax_method = result.Tax_detail.Method
+	result.Tax_allowance = result.Tax_detail.Tax_allowance
+	result.Tax = result.Tax_detail.Tax
+
+	item := systemItem(byCode, in.Component_code, in.Amount)
+	item.Note = in.Note
+	result.Items = []model.PayrollItem{item}
+	if result.Tax_allowance != 0 {
+		result.Items = append(result.Items, systemItem(byCode, model.ComponentTaxAllowance, result.Tax_allowance))
+	}
+	if result.Tax_detail.Employee_tax != 0 {
+		result.Items = append(result.Items, systemItem(byCode, model.ComponentPph21, result.Tax_detail.Employee_tax))
+	}
+
+	for _, item := range result.Items {
+		if item.Component_type == model.ComponentEarning {
+			result.Gross_salary += item.Amount
+			result.Total_salary += item.Amount
+			if item.Component_code != model.ComponentTaxAllowance {
+				result.Allowance += item.Amount
+			}
+		} else {
+			result.Total_salary -= item.Amount
+		}
+	}
+
+	return result, err
+}
+
+func validateCalculationInput(in model.PayrollCalculationInput) error {
+	if in.User_id == uuid.Nil {
+		return errors.New("user_id is required")
+	}
+	if _, err := time.Parse(PaymentPeriodLayout, in.Payment_period); err != nil {
+		return errors.New("payment_period must be formatted as YYYY-MM")
+	}
+	for _, item := range in.Items {
+		if item.Amount <= 0 {
+			return errors.New("amount of " + item.Component_code + " must be greater than zero")
+		}
+	}
+	return nil
+}
+
+// Turns the compensation and client items into typed payroll items, copying
+// the component flags onto every line. Client items of a component the
+// compensation pays are left out, the compensation decides those amounts.
+func buildPayrollItems(compensation model.Compensation, inputs []model.PayrollItemInput, components []model.PayrollComponent) ([]model.PayrollItem, error) {
+	byCode := componentsByCode(components)
+	items := make([]model.PayrollItem, 0, len(compensation.Allowances)+len(inputs)+4)
+	items = append(items, systemItem(byCode, model.ComponentBasicSalary, compensation.Basic_salary))
+
+	fixed := make(map[string]bool, len(compensation.Allowances))
+	all := make([]model.PayrollItemInput, 0, len(compensation.Allowances)+len(inputs))
+	for _, allowance := range compensation.Allowances {
+		fixed[allowance.Component_code] = true
+		all = append(all, allowance)
+	}
+	for _, input := range inputs {
+		if !fixed[input.Component_code] {
+			all = append(all, input)
+		}
+	}
+
+	for _, input := range all {
+		component, ok := byCode[input.Component_code]
+		if !ok {
+			return items, errors.New("unknown payroll component " + input.Component_code)
+		}
+		if component.Is_system {
+			return items, errors.New("payroll component " + input.Component_code + " is computed by the system")
+		}
+		items = append(items, model.PayrollItem{
+			Component_code: component.Component_code,
+			Name:           component.Name,
+			Component_type: component.Component_type,
+			Amount:         input.Amount,
+			Is_taxable:     component.Is_taxable,
+			Is_bpjs_base:   component.Is_bpjs_base,
+			Is_one_off:     component.Is_one_off,
+			Note:           input.Note,
+		})
+	}
+	return items, nil
+}
+
+func componentsByCode(components []model.PayrollComponent) map[string]model.PayrollComponent {
+	byCode := make(map[string]model.PayrollComponent, len(components))
+	for _, c := range components {
+		byCode[c.Component_code] = c
+	}
+	return byCode
+}
+
+func systemItem(byCode map[string]model.PayrollComponent, code string, amount int) model.PayrollItem {
+	component := byCode[code]
+	return model.PayrollItem{
+		Component_code: code,
+		Name:           component.Name,
+		Component_type: component.Component_type,
+		Amount:         amount,
+		Is_taxable:     component.Is_taxable,
+		Is_bpjs_base:   component.Is_bpjs_base,
+		Is_one_off:     component.Is_one_off,
+	}
+}
+
+func roundRupiah(amount float64) int {
+	return int(math.Round(amount))
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+type PayrollComponentService interface {
+	//Insert
+	CreatePayrollComponent(ctx context.Context, c model.PayrollComponent) (string, error)
+	//Read
+	GetPayrollComponentList(ctx context.Context) ([]model.PayrollComponent, error)
+}
+
+type payrollComponentService struct {
+	repository     repository.PayrollComponentRepo
+	timeoutContext time.Duration
+	db             *sqlx.DB
+}
+
+func NewPayrollComponentService(repository repository.PayrollComponentRepo, timeoutContext time.Duration, db *sqlx.DB) PayrollComponentService {
+	return &payrollComponentService{
+		repository:     repository,
+		timeoutContext: timeoutContext,
+		db:             db,
+	}
+}
+
+func (service *payrollComponentService) GetPayrollComponentList(ctx context.Context) ([]model.PayrollComponent, error) {
+	ctx, cancel := context.WithTimeout(ctx, service.timeoutContext)
+	defer cancel()
+
+	var (
+		list []model.PayrollComponent
+		err  error
+	)
+
+	list, err = service.repository.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollComponentList", err)
+		return list, err
+	}
+	return list, err
+}
+
+func (service *payrollComponentService) CreatePayrollComponent(ctx context.Context, c model.PayrollComponent) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, service.timeoutContext)
+	defer cancel()
+
+	var (
+		code string
+		err  error
+	)
+
+	c.Component_code = strings.ToUpper(strings.TrimSpace(c.Component_code))
+	if c.Component_code == "" || c.Name == "" {
+		err = errors.New("component_code and name are required")
+		utils.LogError("Services", "CreatePayrollComponent", err)
+		return code, err
+	}
+	if c.Component_type != model.ComponentEarning && c.Component_type != model.ComponentDeduction {
+		err = errors.New("component_type must be earning or deduction")
+		utils.LogError("Services", "CreatePayrollComponent", err)
+		return code, err
+	}
+
+	tx, err := service.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollComponent open tx", err)
+		return code, err
+	}
+
+	code, err = service.repository.CreatePayrollComponent(ctx, tx, c)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollComponent", err)
+		utils.CommitOrRollback(tx, "Services CreatePayrollComponent", err)
+		return code, err
+	}
+
+	utils.CommitOrRollback(tx, "Services CreatePayrollComponent", err)
+	return code, err
+}
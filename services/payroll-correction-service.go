@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PayrollCorrectionService interface {
+	//Create
+	CreatePayrollCorrection(ctx context.Context, id uuid.UUID, r model.CreatePayrollCorrectionModel) (model.PayrollCorrectionResult, error)
+	//Read
+	GetPayrollCorrectionList(ctx context.Context, id uuid.UUID) ([]model.PayrollRecordListModel, error)
+}
+
+type payrollCorrectionService struct {
+	payrollRecordRepo  repository.PayrollRecordRepo
+	payrollItemRepo    repository.PayrollItemRepo
+	payrollRunRepo     repository.PayrollRunRepo
+	payrollPeriodRepo  repository.PayrollPeriodRepo
+	bpjsRepo           repository.BpjsRepo
+	statusRepo         repository.StatusRepo
+	payrollCalculation PayrollCalculationService
+	timeoutContext     time.Duration
+	db                 *sqlx.DB
+}
+
+func NewPayrollCorrectionService(payrollRecordRepo repository.PayrollRecordRepo, payrollItemRepo repository.PayrollItemRepo, payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, bpjsRepo repository.BpjsRepo, statusRepo repository.StatusRepo, calc PayrollCalculationService, timeoutContext time.Duration, db *sqlx.DB) PayrollCorrectionService {
+	return &payrollCorrectionService{
+		payrollRecordRepo:  payrollRecordRepo,
+		payrollItemRepo:    payrollItemRepo,
+		payrollRunRepo:     payrollRunRepo,
+		payrollPeriodRepo:  payrollPeriodRepo,
+		bpjsRepo:           bpjsRepo,
+		statusRepo:         statusRepo,
+		payrollCalculation: calc,
+		timeoutContext:     timeoutContext,
+		db:                 db,
+	}
+}
+
+// Audit trail of a payroll record, the reversal and adjustment made for it
+func (s *payrollCorrectionService) GetPayrollCorrectionList(ctx context.Context, id uuid.UUID) ([]model.PayrollRecordListModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	list, err := s.payrollRecordRepo.GetPayrollCorrectionList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollCorrectionList", err)
+		return list, err
+	}
+	return list, err
+}
+
+// Corrects a locked payroll record. The record itself is left as it was
+// paid: a reversal cancels every line of it and an adjustment carries the
+// figures recalculated for the period the salary was earned in. Both are
+// booked in the next period whose payroll is still open, so the difference
+// is settled with that period's salary, but count for PPh 21 in the tax
+// year of the period they correct.
+func (s *payrollCorrectionService) CreatePayrollCorrection(ctx context.Context, id uuid.UUID, r model.CreatePayrollCorrectionModel) (model.PayrollCorrectionResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.PayrollCorrectionResult
+		err    error
+	)
+	result.Payroll_id = id
+
+	if r.Reason == "" {
+		return result, errors.New("reason is required")
+	}
+
+	detail, err := s.payrollRecordRepo.GetPayrollRecordDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection get record", err)
+		return result, err
+	}
+	if detail.Record_type == model.PayrollRecordReversal {
+		return result, errors.New("a reversal cannot be corrected")
+	}
+	if !IsPayrollRunLocked(detail.Status_name) {
+		return result, errors.New("payroll record is " + detail.Status_name + ", update it instead of correcting it")
+	}
+	if detail.Run_id.Valid {
+		run, err := s.payrollRunRepo.GetPayrollRunDetail(ctx, detail.Run_id.UUID)
+		if err != nil {
+			utils.LogError("Services", "CreatePayrollCorrection get run", err)
+			return result, err
+		}
+		if run.Run_type != model.PayrollRunRegular {
+			return result, errors.New("records of a " + run.Run_type + " run cannot be corrected")
+		}
+	}
+
+	corrections, err := s.payrollRecordRepo.GetPayrollCorrectionList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection get corrections", err)
+		return result, err
+	}
+	for _, c := range corrections {
+		if c.Record_type == model.PayrollRecordAdjustment {
+			return result, errors.New("payroll record was already corrected by " + c.Payroll_id.String() + ", update or correct that adjustment instead")
+		}
+	}
+
+	original, err := s.payrollRecordRepo.GetPayrollRecord(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection get record", err)
+		return result, err
+	}
+	earnedPeriod, err := earnedPayrollPeriod(ctx, s.payrollRecordRepo, original)
+	if err != nil {
+		return result, err
+	}
+
+	period, run, err := s.nextOpenPeriod(ctx, original.Payment_period)
+	if err != nil {
+		return result, err
+	}
+	result.Payment_period = period
+
+	statusName := model.PayrollRunDraft
+	var paymentDate time.Time
+	if run != nil {
+		statusName = run.Status
+		paymentDate = run.Payment_date
+	} else {
+		start, _ := time.Parse(PaymentPeriodLayout, period)
+		paymentDate = start.AddDate(0, 1, -1)
+	}
+	if r.Payment_date != "" {
+		paymentDate, err = time.Parse("2006-01-02", r.Payment_date)
+		if err != nil {
+			return result, errors.New("payment_date must be formatted as YYYY-MM-DD")
+		}
+	}
+	status, err := s.statusRepo.GetStatusByName(ctx, statusName)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection get status", err)
+		return result, err
+	}
+
+	// What should have been paid, computed for the period it was earned in
+	calculation, err := s.payrollCalculation.Calculate(ctx, model.PayrollCalculationInput{
+		User_id:        original.User_id,
+		Payment_period: earnedPeriod,
+		Items:          r.Items,
+		Tax_method:     r.Tax_method,
+	})
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection calculate", err)
+		return result, err
+	}
+
+	items, err := s.payrollItemRepo.GetPayrollItemList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection get items", err)
+		return result, err
+	}
+	bpjsItems, err := s.bpjsRepo.GetBpjsItemList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection get bpjs items", err)
+		return result, err
+	}
+
+	runId := uuid.NullUUID{}
+	if run != nil {
+		runId = uuid.NullUUID{UUID: run.Run_id, Valid: true}
+	}
+	reversal, reversalLines := reversePayrollRecord(original, items, bpjsItems)
+	adjustment := payrollRecordFromResult(calculation, paymentDate, status.Status_id)
+	for _, record := range []*model.PayrollRecord{&reversal, &adjustment} {
+		record.Payment_period = period
+		record.Payment_date = paymentDate
+		record.Status_id = status.Status_id
+		record.Run_id = runId
+		record.Corrects_payroll_id = uuid.NullUUID{UUID: id, Valid: true}
+		record.Correction_reason = r.Reason
+		record.Earned_period = earnedPeriod
+	}
+	adjustment.Record_type = model.PayrollRecordAdjustment
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection open tx", err)
+		return result, err
+	}
+
+	result.Reversal_id, err = s.payrollRecordRepo.CreatePayrollRecord(ctx, tx, reversal)
+	if err == nil {
+		err = saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, result.Reversal_id, reversalLines)
+	}
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection create reversal", err)
+		utils.CommitOrRollback(tx, "Services CreatePayrollCorrection", err)
+		return result, err
+	}
+
+	result.Adjustment_id, err = s.payrollRecordRepo.CreatePayrollRecord(ctx, tx, adjustment)
+	if err == nil {
+		err = saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, result.Adjustment_id, calculation)
+	}
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollCorrection create adjustment", err)
+		utils.CommitOrRollback(tx, "Services CreatePayrollCorrection", err)
+		return result, err
+	}
+
+	result.Difference = adjustment.Total_salary - original.Total_salary
+	utils.CommitOrRollback(tx, "Services CreatePayrollCorrection", err)
+	return result, err
+}
+
+// Period the salary of a record was earned in, an adjustment is booked
+// later than that so the chain is followed back to the regular record
+func earnedPayrollPeriod(ctx context.Context, payrollRecordRepo repository.PayrollRecordRepo, record model.PayrollRecord) (string, error) {
+	for record.Record_type != model.PayrollRecordRegular && record.Corrects_payroll_id.Valid {
+		var err error
+		record, err = payrollRecordRepo.GetPayrollRecord(ctx, record.Corrects_payroll_id.UUID)
+		if err != nil {
+			utils.LogError("Services", "earnedPayrollPeriod get corrected record", err)
+			return "", err
+		}
+	}
+	return record.Payment_period, nil
+}
+
+// First period after the given one whose regular payroll is not locked yet,
+// along with its run when it is already open
+func (s *payrollCorrectionService) nextOpenPeriod(ctx context.Context, after string) (string, *model.PayrollRun, error) {
+	month, err := time.Parse(PaymentPeriodLayout, after)
+	if err != nil {
+		return "", nil, errors.New("payment_period of the record is not formatted as YYYY-MM")
+	}
+	return openPayrollPeriod(ctx, s.payrollRunRepo, s.payrollPeriodRepo, month.AddDate(0, 1, 0))
+}
+
+// First period from the given month on that is not closed and whose regular
+// payroll is not locked yet, along with its run when it is already open
+func openPayrollPeriod(ctx context.Context, payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, month time.Time) (string, *model.PayrollRun, error) {
+	for ; ; month = month.AddDate(0, 1, 0) {
+		period := month.Format(PaymentPeriodLayout)
+
+		closed, err := payrollPeriodClosed(ctx, payrollPeriodRepo, period)
+		if err != nil {
+			return "", nil, err
+		}
+		if closed {
+			continue
+		}
+
+		run, err := payrollRunRepo.GetPayrollRunByPeriod(ctx, period, model.PayrollRunRegular)
+		if errors.Is(err, sql.ErrNoRows) {
+			return period, nil, nil
+		}
+		if err != nil {
+			utils.LogError("Services", "openPayrollPeriod get run by period", err)
+			return "", nil, err
+		}
+		if !IsPayrollRunLocked(run.Status) {
+			return period, &run, nil
+		}
+	}
+}
+
+// Mirror image of a payroll record, every amount and line negated so the
+// pair sums to zero in tax and cost totals
+func reversePayrollRecord(original model.PayrollRecord, items []model.PayrollItem, bpjsItems []model.BpjsItem) (model.PayrollRecord, model.PayrollCalculationResult) {
+	var lines model.PayrollCalculationResult
+
+	reversal := original
+	reversal.Record_type = model.PayrollRecordReversal
+	reversal.Basic_salary = -original.Basic_salary
+	reversal.Allowance = -original.Allowance
+	reversal.Bpjs = -original.Bpjs
+	reversal.Tax = -original.Tax
+	reversal.Total_salary = -original.Total_salary
+
+	detail := &reversal.Tax_detail
+	detail.Gross_income = -detail.Gross_income
+	detail.Employer_premiums = -detail.Employer_premiums
+	detail.Tax_allowance = -detail.Tax_allowance
+	detail.Taxable_income = -detail.Taxable_income
+	detail.Pension_deduction = -detail.Pension_deduction
+	detail.Regular_income = -detail.Regular_income
+	detail.Tax = -detail.Tax
+	detail.Employee_tax = -detail.Employee_tax
+	detail.Employer_tax = -detail.Employer_tax
+
+	lines.Items = make([]model.PayrollItem, 0, len(items))
+	for _, item := range items {
+		item.Amount = -item.Amount
+		lines.Items = append(lines.Items, item)
+	}
+	lines.Bpjs_detail.Items = make([]model.BpjsItem, 0, len(bpjsItems))
+	for _, item := range bpjsItems {
+		item.Base_wage = -item.Base_wage
+		item.Employer_amount = -item.Employer_amount
+		item.Employee_amount = -item.Employee_amount
+		lines.Bpjs_detail.Items = append(lines.Bpjs_detail.Items, item)
+	}
+	return reversal, lines
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+)
+
+// Columns of a payroll input sheet besides the component codes. Name is only
+// there for the people filling the sheet and is not checked.
+const (
+	importColumnNik           = "nik"
+	importColumnName          = "name"
+	importColumnTaxMethod     = "tax_method"
+	importColumnOvertimeHours = "overtime_hours"
+)
+
+type PayrollImportService interface {
+	//Create
+	ImportPayrollInput(ctx context.Context, p model.PayrollImportModel, content []byte, dryRun bool) (model.PayrollImportResult, error)
+}
+
+type payrollImportService struct {
+	payrollRecordService PayrollRecordService
+	userRepo             repository.UserRepo
+	payrollComponentRepo repository.PayrollComponentRepo
+	payrollRunRepo       repository.PayrollRunRepo
+	payrollPeriodRepo    repository.PayrollPeriodRepo
+	timeoutContext       time.Duration
+}
+
+func NewPayrollImportService(payrollRecordService PayrollRecordService, userRepo repository.UserRepo, payrollComponentRepo repository.PayrollComponentRepo, payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, timeoutContext time.Duration) PayrollImportService {
+	return &payrollImportService{
+		payrollRecordService: payrollRecordService,
+		userRepo:             userRepo,
+		payrollComponentRepo: payrollComponentRepo,
+		payrollRunRepo:       payrollRunRepo,
+		payrollPeriodRepo:    payrollPeriodRepo,
+		timeoutContext:       timeoutContext,
+	}
+}
+
+// Imports the variable payroll inputs of a period from a CSV or XLSX sheet.
+// Rows are matched to users by NIK and every other column is a component
+// code whose cells are the amounts, besides an optional overtime_hours
+// column paid at the statutory overtime rates. The rows are saved through
+// SavePayrollRecordList, so an employee who already has a record in the
+// draft run gets it recomputed with the sheet's amounts replacing those of
+// the same components, the preview shows
+// the computed take home pay and nothing is saved unless every row is valid.
+func (s *payrollImportService) ImportPayrollInput(ctx context.Context, p model.PayrollImportModel, content []byte, dryRun bool) (model.PayrollImportResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.PayrollImportResult
+		err    error
+	)
+	result.Dry_run = dryRun
+	result.Payment_period = p.Payment_period
+	result.Rows = make([]model.PayrollImportRow, 0)
+	result.Errors = make([]model.PayrollImportErr, 0)
+
+	month, err := time.Parse(PaymentPeriodLayout, p.Payment_period)
+	if err != nil {
+		return result, errors.New("payment_period must be formatted as YYYY-MM")
+	}
+	if err = checkPayrollPeriodOpen(ctx, s.payrollPeriodRepo, p.Payment_period); err != nil {
+		return result, err
+	}
+	run, err := s.payrollRunRepo.GetPayrollRunByPeriod(ctx, p.Payment_period, model.PayrollRunRegular)
+	if err == nil && IsPayrollRunLocked(run.Status) {
+		return result, errors.New("payroll run of " + p.Payment_period + " is " + run.Status + " and can no longer be changed")
+	}
+	if err == nil && run.Status != model.PayrollRunDraft {
+		return result, errors.New("payroll run of " + p.Payment_period + " is " + run.Status + ", send it back to draft before importing")
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.LogError("Services", "ImportPayrollInput get payroll run", err)
+		return result, err
+	}
+
+	// Imported overtime and the payment date follow the period's own dates
+	// when it was created, the end of the calendar month otherwise
+	cutoff := month.AddDate(0, 1, -1)
+	paymentDate := cutoff
+	period, err := s.payrollPeriodRepo.GetPayrollPeriod(ctx, p.Payment_period)
+	if err == nil {
+		cutoff, paymentDate = period.Cutoff_date, period.Payment_date
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		utils.LogError("Services", "ImportPayrollInput get payroll period", err)
+		return result, err
+	}
+	result.Payment_date = paymentDate.Format("2006-01-02")
+	if p.Payment_date != "" {
+		if _, err = time.Parse("2006-01-02", p.Payment_date); err != nil {
+			return result, errors.New("payment_date must be formatted as YYYY-MM-DD")
+		}
+		result.Payment_date = p.Payment_date
+	}
+
+	sheet, err := readPayrollInputSheet(p.Filename, content)
+	if err != nil {
+		return result, err
+	}
+
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "ImportPayrollInput get payroll components", err)
+		return result, err
+	}
+	columns, err := importColumns(sheet[0], componentsByCode(components))
+	if err != nil {
+		return result, err
+	}
+	for _, column := range columns {
+		if column != "" {
+			result.Columns = append(result.Columns, column)
+		}
+	}
+
+	users, err := s.userRepo.GetActiveUserList(ctx)
+	if err != nil {
+		utils.LogError("Services", "ImportPayrollInput get users", err)
+		return result, err
+	}
+	byNik := make(map[string]model.User, len(users))
+	for _, user := range users {
+		if user.Nik != "" {
+			byNik[user.Nik] = user
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, cells := range sheet[1:] {
+		line := i + 2
+		if blankRow(cells) {
+			continue
+		}
+		row, rowErrors := parsePayrollInputRow(line, cells, columns)
+		if row.Nik != "" {
+			user, ok := byNik[row.Nik]
+			switch {
+			case !ok:
+				rowErrors = append(rowErrors, model.PayrollImportErr{Row: line, Nik: row.Nik, Column: importColumnNik, Error: "no employee has NIK " + row.Nik})
+			case seen[row.Nik] > 0:
+				rowErrors = append(rowErrors, model.PayrollImportErr{Row: line, Nik: row.Nik, Column: importColumnNik, Error: fmt.Sprintf("NIK %s is already on row %d", row.Nik, seen[row.Nik])})
+			default:
+				row.User_id = user.User_id
+				row.Name = user.Name
+				seen[row.Nik] = line
+			}
+		}
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if len(result.Rows) == 0 && len(result.Errors) == 0 {
+		return result, errors.New("sheet has no rows to import")
+	}
+
+	// The rows already valid are still computed so the preview is complete,
+	// but the batch is only saved when the whole sheet is valid
+	if len(result.Errors) > 0 {
+		result.Dry_run = true
+	}
+	if len(result.Rows) > 0 {
+		records := make([]model.CreatePayrollRecordModel, len(result.Rows))
+		for i, row := range result.Rows {
+			records[i] = model.CreatePayrollRecordModel{
+				User_id:        row.User_id,
+				Payment_period: p.Payment_period,
+				Payment_date:   result.Payment_date,
+				Items:          row.Items,
+				Tax_method:     row.Tax_method,
+			}
+			// With the column the sheet holds the whole overtime of the
+			// period, a blank cell clears an earlier import. It is dated on
+			// the cutoff, or on the last day of a leaver.
+			if slices.Contains(columns, importColumnOvertimeHours) {
+				date := cutoff
+				if user := byNik[row.Nik]; user.Termination_date != nil && user.Termination_date.Before(date) {
+					date = *user.Termination_date
+				}
+				records[i].Overtime = importedOvertime(row.User_id, p.Payment_period, date, row.Overtime_hours)
+			}
+		}
+		batch, batchErr := s.payrollRecordService.SavePayrollRecordList(ctx, records, result.Dry_run)
+		for _, computed := range batch.Rows {
+			result.Rows[computed.Index].Total_salary = computed.Total_salary
+			result.Rows[computed.Index].Payroll_id = computed.Payroll_id
+			result.Rows[computed.Index].Updated = computed.Updated
+		}
+		for _, failed := range batch.Errors {
+			row := result.Rows[failed.Index]
+			result.Errors = append(result.Errors, model.PayrollImportErr{Row: row.Row, Nik: row.Nik, Error: failed.Error})
+		}
+		if batchErr != nil && len(batch.Errors) == 0 {
+			return result, batchErr
+		}
+	}
+
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+	return result, err
+}
+
+// Maps the header row onto the fixed columns and the components. Amounts
+// of system components are computed by the calculation engine and cannot be
+// imported, overtime comes in as hours and is paid at the statutory rates.
+// Blank headers give an empty column that is skipped.
+func importColumns(header []string, components map[string]model.PayrollComponent) ([]string, error) {
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	hasNik := false
+	for i, cell := range header {
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(cell)), " ", "_")
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			return nil, errors.New("column " + cell + " appears twice")
+		}
+		seen[name] = true
+
+		switch name {
+		case importColumnNik:
+			hasNik = true
+			fallthrough
+		case importColumnName, importColumnTaxMethod, importColumnOvertimeHours:
+			columns[i] = name
+			continue
+		}
+
+		component, ok := components[strings.ToUpper(name)]
+		switch {
+		case !ok:
+			return nil, errors.New("column " + cell + " is neither a payroll component code nor one of nik, name, tax_method, overtime_hours")
+		case component.Component_code == model.ComponentOvertime:
+			return nil, errors.New("overtime pay is computed from the hours, use an overtime_hours column instead")
+		case component.Is_system:
+			return nil, errors.New("column " + cell + " is computed by the payroll engine and cannot be imported")
+		}
+		columns[i] = component.Component_code
+	}
+	if !hasNik {
+		return nil, errors.New("sheet has no nik column")
+	}
+	return columns, nil
+}
+
+func parsePayrollInputRow(line int, cells []string, columns []string) (model.PayrollImportRow, []model.PayrollImportErr) {
+	row := model.PayrollImportRow{Row: line, Items: make([]model.PayrollItemInput, 0)}
+	var rowErrors []model.PayrollImportErr
+	fail := func(column string, msg string) {
+		rowErrors = append(rowErrors, model.PayrollImportErr{Row: line, Nik: row.Nik, Column: column, Error: msg})
+	}
+
+	for i, column := range columns {
+		if column == importColumnNik && i < len(cells) {
+			row.Nik = importNik(cells[i])
+		}
+	}
+	if row.Nik == "" {
+		fail(importColumnNik, "nik is required")
+	}
+
+	for i, column := range columns {
+		if column == "" || i >= len(cells) {
+			continue
+		}
+		cell := strings.TrimSpace(cells[i])
+		if cell == "" {
+			continue
+		}
+		switch column {
+		case importColumnNik, importColumnName:
+		case importColumnTaxMethod:
+			row.Tax_method = strings.ToLower(cell)
+		case importColumnOvertimeHours:
+			hours, err := strconv.ParseFloat(cell, 64)
+			if err != nil || hours < 0 || hours > overtimeMonthlyHours || math.Abs(hours*100-math.Round(hours*100)) > 1e-6 {
+				fail(column, "overtime_hours must be a number of hours between 0 and 173 with at most two decimals, got "+cell)
+				continue
+			}
+			row.Overtime_hours = hours
+		default:
+			amount, err := importAmount(cell)
+			if err != nil {
+				fail(column, err.Error())
+				continue
+			}
+			if amount == 0 {
+				continue
+			}
+			row.Items = append(row.Items, model.PayrollItemInput{Component_code: column, Amount: amount})
+		}
+	}
+	return row, rowErrors
+}
+
+// A NIK typed into a number cell is stored by Excel in scientific notation
+// once it is long enough, it is turned back into its digits
+func importNik(cell string) string {
+	nik := strings.TrimSpace(cell)
+	if strings.ContainsAny(nik, "eE") {
+		if n, err := strconv.ParseFloat(nik, 64); err == nil && n == math.Trunc(n) {
+			return strconv.FormatFloat(n, 'f', 0, 64)
+		}
+	}
+	return nik
+}
+
+// Whole rupiah amount of a cell. Spreadsheets keep numbers without
+// separators, an Rp prefix is tolerated for values typed as text.
+func importAmount(cell string) (int, error) {
+	value := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(cell, "Rp"), "rp"))
+	amount, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil, math.IsNaN(amount), math.IsInf(amount, 0):
+		return 0, errors.New("amount must be a number, got " + cell)
+	case amount < 0:
+		return 0, errors.New("amount cannot be negative, deductions are columns of their own")
+	case amount != math.Trunc(amount):
+		return 0, errors.New("amount must be whole rupiah, got " + cell)
+	case amount > math.MaxInt32:
+		return 0, errors.New("amount is too large, got " + cell)
+	}
+	return int(amount), nil
+}
+
+// Reads an XLSX or CSV sheet. CSV may be separated by commas or by the
+// semicolons Excel writes under the Indonesian locale.
+func readPayrollInputSheet(filename string, content []byte) ([][]string, error) {
+	var (
+		rows [][]string
+		err  error
+	)
+	switch {
+	case bytes.HasPrefix(content, []byte("PK\x03\x04")):
+		rows, err = utils.ReadXLSX(content)
+	case strings.EqualFold(filepath.Ext(filename), ".xlsx"):
+		return nil, errors.New("file is not a valid xlsx workbook")
+	case strings.EqualFold(filepath.Ext(filename), ".xls"):
+		return nil, errors.New("xls workbooks are not supported, save the sheet as xlsx or csv")
+	default:
+		content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+		r := csv.NewReader(bytes.NewReader(content))
+		header, _, _ := bytes.Cut(content, []byte("\n"))
+		if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+			r.Comma = ';'
+		}
+		r.FieldsPerRecord = -1
+		rows, err = r.ReadAll()
+		if err != nil {
+			err = errors.New("file is not a valid csv: " + err.Error())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || blankRow(rows[0]) {
+		return nil, errors.New("first row of the sheet has to be the column header")
+	}
+	return rows, nil
+}
+
+func blankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/google/uuid"
+)
+
+func TestImportOvertimeHours(t *testing.T) {
+	components := componentsByCode([]model.PayrollComponent{
+		{Component_code: model.ComponentOvertime, Name: "Overtime", Component_type: model.ComponentEarning, Is_taxable: true, Is_system: true},
+	})
+	columns, err := importColumns([]string{"NIK", "Name", "Overtime Hours"}, components)
+	if err != nil {
+		t.Fatalf("importColumns() error = %v", err)
+	}
+	cutoff := time.Date(2024, time.March, 25, 0, 0, 0, 0, time.UTC)
+	// 1/173 of the wage is an hourly rate of 10.000
+	const wage = 1730000
+
+	tests := []struct {
+		name    string
+		cell    string
+		days    int
+		amounts []int
+		wantErr bool
+	}{
+		{"half an hour", "0.5", 1, []int{7500}, false},
+		{"one day", "4", 1, []int{75000}, false},
+		// 4 + 4 + 2 hours, every day pays its first hour at 1.5x
+		{"split into days", "10", 3, []int{75000, 75000, 35000}, false},
+		{"decimal hours", "4.25", 2, []int{75000, 3750}, false},
+		{"blank clears the overtime", "", 0, []int{}, false},
+		{"zero clears the overtime", "0", 0, []int{}, false},
+		{"negative", "-1", 0, nil, true},
+		{"three decimals", "1.255", 0, nil, true},
+		{"not a number", "ten", 0, nil, true},
+		{"more than a month", "200", 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, rowErrors := parsePayrollInputRow(2, []string{"3201010101010001", "Budi", tt.cell}, columns)
+			if (len(rowErrors) > 0) != tt.wantErr {
+				t.Fatalf("parsePayrollInputRow() errors = %v, wantErr %v", rowErrors, tt.wantErr)
+			}
+			if tt.wantErr {
+				if rowErrors[0].Column != importColumnOvertimeHours {
+					t.Errorf("error column = %q, want %q", rowErrors[0].Column, importColumnOvertimeHours)
+				}
+				return
+			}
+
+			userId := uuid.New()
+			overtime := importedOvertime(userId, "2024-03", cutoff, row.Overtime_hours)
+			if overtime == nil || len(overtime) != tt.days {
+				t.Fatalf("importedOvertime() = %d records, want %d", len(overtime), tt.days)
+			}
+			for _, record := range overtime {
+				if record.User_id != userId || record.Import_period != "2024-03" || !record.Overtime_date.Equal(cutoff) ||
+					record.Day_type != model.OvertimeWorkday || record.Status != model.OvertimeApproved {
+					t.Errorf("importedOvertime() record = %+v, want approved workday overtime of 2024-03 on the cutoff", record)
+				}
+			}
+
+			items := overtimeItems(components, overtime, wage, 5)
+			if len(items) != len(tt.amounts) {
+				t.Fatalf("overtimeItems() = %d items, want %d", len(items), len(tt.amounts))
+			}
+			for i, item := range items {
+				if item.Component_code != model.ComponentOvertime || item.Amount != tt.amounts[i] {
+					t.Errorf("item %d = %s %d, want %s %d", i, item.Component_code, item.Amount, model.ComponentOvertime, tt.amounts[i])
+				}
+				if item.Reference_id.UUID != overtime[i].Overtime_id {
+					t.Errorf("item %d refers to %v, want overtime %v", i, item.Reference_id.UUID, overtime[i].Overtime_id)
+				}
+			}
+		})
+	}
+}
+
+func TestImportColumns(t *testing.T) {
+	components := componentsByCode([]model.PayrollComponent{
+		{Component_code: model.ComponentBasicSalary, Component_type: model.ComponentEarning, Is_system: true},
+		{Component_code: model.ComponentOvertime, Component_type: model.ComponentEarning, Is_system: true},
+		{Component_code: "BONUS", Component_type: model.ComponentEarning, Is_one_off: true},
+		{Component_code: "ABSENCE", Component_type: model.ComponentDeduction},
+	})
+
+	tests := []struct {
+		name    string
+		header  []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "components and fixed columns",
+			header: []string{" NIK ", "Name", "bonus", "", "Absence", "Tax Method", "overtime hours"},
+			want:   []string{"nik", "name", "BONUS", "", "ABSENCE", "tax_method", "overtime_hours"},
+		},
+		{name: "no nik", header: []string{"Name", "BONUS"}, wantErr: "sheet has no nik column"},
+		{name: "twice", header: []string{"NIK", "Bonus", "BONUS"}, wantErr: "column BONUS appears twice"},
+		{name: "unknown", header: []string{"NIK", "Commission"}, wantErr: "column Commission is neither a payroll component code nor one of nik, name, tax_method, overtime_hours"},
+		{name: "system component", header: []string{"NIK", "Basic"}, wantErr: "column Basic is computed by the payroll engine and cannot be imported"},
+		{name: "overtime amount", header: []string{"NIK", "OVERTIME"}, wantErr: "overtime pay is computed from the hours, use an overtime_hours column instead"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importColumns(tt.header, components)
+			if (tt.wantErr == "") != (err == nil) || err != nil && err.Error() != tt.wantErr {
+				t.Fatalf("importColumns() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("importColumns() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePayrollInputRow(t *testing.T) {
+	columns := []string{"nik", "name", "BONUS", "", "ABSENCE", "tax_method"}
+
+	tests := []struct {
+		name       string
+		cells      []string
+		want       model.PayrollImportRow
+		errColumns []string
+	}{
+		{
+			name:  "amounts and tax method",
+			cells: []string{"3201010101010001", "Budi", "1500000", "ignored", "Rp 250000", "NET"},
+			want: model.PayrollImportRow{Row: 2, Nik: "3201010101010001", Tax_method: "net", Items: []model.PayrollItemInput{
+				{Component_code: "BONUS", Amount: 1500000}, {Component_code: "ABSENCE", Amount: 250000},
+			}},
+		},
+		{
+			// Zero and blank amounts clear the component, short rows are padded
+			name:  "zero and missing cells",
+			cells: []string{"3.20101010101E+15", "Budi", "0"},
+			want:  model.PayrollImportRow{Row: 2, Nik: "3201010101010000", Items: []model.PayrollItemInput{}},
+		},
+		{
+			name:       "every bad cell is reported",
+			cells:      []string{"", "Budi", "1.500.000", "", "-1"},
+			want:       model.PayrollImportRow{Row: 2, Items: []model.PayrollItemInput{}},
+			errColumns: []string{"nik", "BONUS", "ABSENCE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rowErrors := parsePayrollInputRow(2, tt.cells, columns)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePayrollInputRow() = %+v, want %+v", got, tt.want)
+			}
+			errColumns := make([]string, 0)
+			for _, e := range rowErrors {
+				errColumns = append(errColumns, e.Column)
+			}
+			if len(errColumns) != len(tt.errColumns) || len(tt.errColumns) > 0 && !reflect.DeepEqual(errColumns, tt.errColumns) {
+				t.Errorf("parsePayrollInputRow() errors in %v, want %v", errColumns, tt.errColumns)
+			}
+		})
+	}
+}
+
+func TestImportAmount(t *testing.T) {
+	tests := []struct {
+		cell    string
+		want    int
+		wantErr string
+	}{
+		{"1500000", 1500000, ""},
+		{"Rp 250000", 250000, ""},
+		{"rp75000", 75000, ""},
+		{"1.5E+6", 1500000, ""},
+		{"1500000.00", 1500000, ""},
+		{"1.500.000", 0, "amount must be a number, got 1.500.000"},
+		{"NaN", 0, "amount must be a number, got NaN"},
+		{"-50000", 0, "amount cannot be negative, deductions are columns of their own"},
+		{"1500.5", 0, "amount must be whole rupiah, got 1500.5"},
+		{"3000000000", 0, "amount is too large, got 3000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cell, func(t *testing.T) {
+			got, err := importAmount(tt.cell)
+			if (tt.wantErr == "") != (err == nil) || err != nil && err.Error() != tt.wantErr {
+				t.Fatalf("importAmount() error = %v, want %q", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("importAmount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportNik(t *testing.T) {
+	tests := []struct {
+		cell, want string
+	}{
+		{" 3201010101010001 ", "3201010101010001"},
+		{"3.20101010101E+15", "3201010101010000"},
+		{"EMP-001", "EMP-001"},
+		{"1.5e0", "1.5e0"},
+	}
+
+	for _, tt := range tests {
+		if got := importNik(tt.cell); got != tt.want {
+			t.Errorf("importNik(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestReadPayrollInputSheet(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     [][]string
+		wantErr  string
+	}{
+		{
+			name: "comma separated", filename: "input.csv",
+			content: "nik,name,BONUS\n3201010101010001,Budi,1500000\n",
+			want:    [][]string{{"nik", "name", "BONUS"}, {"3201010101010001", "Budi", "1500000"}},
+		},
+		{
+			// Excel under the Indonesian locale, with a byte order mark and
+			// a row shorter than the header
+			name: "semicolon separated", filename: "input.csv",
+			content: "\xef\xbb\xbfnik;name;BONUS\r\n3201010101010001;Budi, S.Kom\r\n",
+			want:    [][]string{{"nik", "name", "BONUS"}, {"3201010101010001", "Budi, S.Kom"}},
+		},
+		{name: "broken workbook", filename: "input.xlsx", content: "nik,name", wantErr: "file is not a valid xlsx workbook"},
+		{name: "old workbook", filename: "input.XLS", content: "\xd0\xcf\x11\xe0", wantErr: "xls workbooks are not supported, save the sheet as xlsx or csv"},
+		{name: "no header", filename: "input.csv", content: ",,\n3201010101010001,Budi,1500000\n", wantErr: "first row of the sheet has to be the column header"},
+		{name: "empty", filename: "input.csv", content: "", wantErr: "first row of the sheet has to be the column header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPayrollInputSheet(tt.filename, []byte(tt.content))
+			if (tt.wantErr == "") != (err == nil) || err != nil && err.Error() != tt.wantErr {
+				t.Fatalf("readPayrollInputSheet() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readPayrollInputSheet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrPayrollPeriodClosed is wrapped by the errors of writes refused because
+// their period is closed
+var ErrPayrollPeriodClosed = errors.New("payroll period is closed")
+
+type PayrollPeriodService interface {
+	//Create
+	CreatePayrollPeriod(ctx context.Context, p model.CreatePayrollPeriodModel) (string, error)
+	//Read
+	GetPayrollPeriodList(ctx context.Context) ([]model.PayrollPeriod, error)
+	GetPayrollPeriodDetail(ctx context.Context, period string) (model.PayrollPeriodDetailModel, error)
+	//Update
+	ClosePayrollPeriod(ctx context.Context, period string, r model.UpdatePayrollPeriodStatusModel) (string, error)
+	ReopenPayrollPeriod(ctx context.Context, period string, r model.UpdatePayrollPeriodStatusModel) (string, error)
+}
+
+type payrollPeriodService struct {
+	payrollPeriodRepo repository.PayrollPeriodRepo
+	userRepo          repository.UserRepo
+	reopenRoles       map[string]bool
+	timeoutContext    time.Duration
+	db                *sqlx.DB
+}
+
+// reopenRoles lists the role names allowed to reopen a closed period,
+// comma separated
+func NewPayrollPeriodService(payrollPeriodRepo repository.PayrollPeriodRepo, userRepo repository.UserRepo, reopenRoles string, timeoutContext time.Duration, db *sqlx.DB) PayrollPeriodService {
+	roles := make(map[string]bool)
+	for _, role := range strings.Split(reopenRoles, ",") {
+		if role = strings.ToLower(strings.TrimSpace(role)); role != "" {
+			roles[role] = true
+		}
+	}
+	return &payrollPeriodService{
+		payrollPeriodRepo: payrollPeriodRepo,
+		userRepo:          userRepo,
+		reopenRoles:       roles,
+		timeoutContext:    timeoutContext,
+		db:                db,
+	}
+}
+
+// payrollPeriodClosed reports whether a period was closed. A period that
+// was never created is open.
+func payrollPeriodClosed(ctx context.Context, payrollPeriodRepo repository.PayrollPeriodRepo, period string) (bool, error) {
+	payrollPeriod, err := payrollPeriodRepo.GetPayrollPeriod(ctx, period)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		utils.LogError("Services", "payrollPeriodClosed get period", err)
+		return false, err
+	}
+	return payrollPeriod.Status == model.PayrollPeriodClosed, nil
+}
+
+// checkPayrollPeriodOpen refuses a write to the payroll or leave of a closed
+// period
+func checkPayrollPeriodOpen(ctx context.Context, payrollPeriodRepo repository.PayrollPeriodRepo, period string) error {
+	closed, err := payrollPeriodClosed(ctx, payrollPeriodRepo, period)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return fmt.Errorf("%w: %s has to be reopened before it can be changed", ErrPayrollPeriodClosed, period)
+	}
+	return nil
+}
+
+func (s *payrollPeriodService) GetPayrollPeriodList(ctx context.Context) ([]model.PayrollPeriod, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	list, err := s.payrollPeriodRepo.GetPayrollPeriodList(ctx)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollPeriodList", err)
+		return list, err
+	}
+	return list, err
+}
+
+// Period along with its close and reopen history
+func (s *payrollPeriodService) GetPayrollPeriodDetail(ctx context.Context, period string) (model.PayrollPeriodDetailModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		detail model.PayrollPeriodDetailModel
+		err    error
+	)
+
+	detail.PayrollPeriod, err = s.payrollPeriodRepo.GetPayrollPeriod(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollPeriodDetail", err)
+		return detail, err
+	}
+	detail.Logs, err = s.payrollPeriodRepo.GetPayrollPeriodLogList(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollPeriodDetail get logs", err)
+		return detail, err
+	}
+	return detail, err
+}
+
+// Creates a period, by default the calendar month paid on its last day
+func (s *payrollPeriodService) CreatePayrollPeriod(ctx context.Context, p model.CreatePayrollPeriodModel) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		period string
+		err    error
+	)
+
+	month, err := time.Parse(PaymentPeriodLayout, p.Period)
+	if err != nil {
+		return period, errors.New("period must be formatted as YYYY-MM")
+	}
+	payrollPeriod := model.PayrollPeriod{
+		Period:     p.Period,
+		Start_date: month,
+		End_date:   month.AddDate(0, 1, -1),
+	}
+	dates := []struct {
+		name  string
+		value string
+		date  *time.Time
+	}{
+		{"start_date", p.Start_date, &payrollPeriod.Start_date},
+		{"end_date", p.End_date, &payrollPeriod.End_date},
+		{"cutoff_date", p.Cutoff_date, &payrollPeriod.Cutoff_date},
+		{"payment_date", p.Payment_date, &payrollPeriod.Payment_date},
+	}
+	for _, d := range dates {
+		if d.value == "" {
+			continue
+		}
+		*d.date, err = time.Parse("2006-01-02", d.value)
+		if err != nil {
+			return period, errors.New(d.name + " must be formatted as YYYY-MM-DD")
+		}
+	}
+	if p.Cutoff_date == "" {
+		payrollPeriod.Cutoff_date = payrollPeriod.End_date
+	}
+	if p.Payment_date == "" {
+		payrollPeriod.Payment_date = payrollPeriod.End_date
+	}
+	switch {
+	case payrollPeriod.End_date.Before(payrollPeriod.Start_date):
+		return period, errors.New("end_date cannot be before start_date")
+	case payrollPeriod.Cutoff_date.Before(payrollPeriod.Start_date), payrollPeriod.Cutoff_date.After(payrollPeriod.End_date):
+		return period, errors.New("cutoff_date must be between start_date and end_date")
+	case payrollPeriod.Payment_date.Before(payrollPeriod.Start_date):
+		return period, errors.New("payment_date cannot be before start_date")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollPeriod open tx", err)
+		return period, err
+	}
+	period, err = s.payrollPeriodRepo.CreatePayrollPeriod(ctx, tx, payrollPeriod)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollPeriod", err)
+	}
+	utils.CommitOrRollback(tx, "Services CreatePayrollPeriod", err)
+	return period, err
+}
+
+// Closes a period once every run of it is paid and none of its records is
+// still being edited. From then on its payroll records and the leave dated
+// in it can no longer be written.
+func (s *payrollPeriodService) ClosePayrollPeriod(ctx context.Context, period string, r model.UpdatePayrollPeriodStatusModel) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		updated string
+		err     error
+	)
+
+	if r.User_id == uuid.Nil {
+		return updated, errors.New("user_id of the user closing the period is required")
+	}
+
+	payrollPeriod, err := s.payrollPeriodRepo.GetPayrollPeriod(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "ClosePayrollPeriod get period", err)
+		return updated, err
+	}
+	if payrollPeriod.Status == model.PayrollPeriodClosed {
+		return updated, errors.New("payroll period " + period + " is already closed")
+	}
+
+	runs, records, err := s.payrollPeriodRepo.CountUnsettledPayroll(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "ClosePayrollPeriod count unsettled payroll", err)
+		return updated, err
+	}
+	if runs > 0 {
+		return updated, fmt.Errorf("%d payroll runs of %s are not paid yet", runs, period)
+	}
+	if records > 0 {
+		return updated, fmt.Errorf("%d payroll records of %s are not approved yet", records, period)
+	}
+
+	return s.updatePayrollPeriodStatus(ctx, period, model.PayrollPeriodClosed, model.PayrollPeriodLog{
+		Period:  period,
+		Action:  model.PayrollPeriodActionClose,
+		User_id: uuid.NullUUID{UUID: r.User_id, Valid: true},
+		Reason:  r.Reason,
+	})
+}
+
+// Reopens a closed period. Only users of a privileged role can, and the
+// reason is kept in the period's history.
+func (s *payrollPeriodService) ReopenPayrollPeriod(ctx context.Context, period string, r model.UpdatePayrollPeriodStatusModel) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		updated string
+		err     error
+	)
+
+	if r.User_id == uuid.Nil {
+		return updated, errors.New("user_id of the user reopening the period is required")
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		return updated, errors.New("reason is required")
+	}
+
+	payrollPeriod, err := s.payrollPeriodRepo.GetPayrollPeriod(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "ReopenPayrollPeriod get period", err)
+		return updated, err
+	}
+	if payrollPeriod.Status != model.PayrollPeriodClosed {
+		return updated, errors.New("payroll period " + period + " is not closed")
+	}
+
+	user, err := s.userRepo.GetUserDetail(ctx, r.User_id)
+	if err != nil {
+		utils.LogError("Services", "ReopenPayrollPeriod get user", err)
+		return updated, err
+	}
+	if !s.reopenRoles[strings.ToLower(user.Role_name)] {
+		return updated, errors.New("role " + user.Role_name + " is not allowed to reopen a payroll period")
+	}
+
+	return s.updatePayrollPeriodStatus(ctx, period, model.PayrollPeriodOpen, model.PayrollPeriodLog{
+		Period:  period,
+		Action:  model.PayrollPeriodActionReopen,
+		User_id: uuid.NullUUID{UUID: r.User_id, Valid: true},
+		Reason:  r.Reason,
+	})
+}
+
+func (s *payrollPeriodService) updatePayrollPeriodStatus(ctx context.Context, period string, status string, log model.PayrollPeriodLog) (string, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollPeriodStatus open tx", err)
+		return "", err
+	}
+	updated, err := s.payrollPeriodRepo.UpdatePayrollPeriodStatus(ctx, tx, period, status)
+	if err == nil {
+		_, err = s.payrollPeriodRepo.CreatePayrollPeriodLog(ctx, tx, log)
+	}
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollPeriodStatus", err)
+	}
+	utils.CommitOrRollback(tx, "Services UpdatePayrollPeriodStatus", err)
+	return updated, err
+}
@@ -2,6 +2,11 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dafiqarba/be-payroll/model"
@@ -11,50 +16,142 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+const (
+	// Calculations running at once while a bulk creation is validated
+	bulkCreateWorkers = 4
+
+	payrollListDefaultPageSize = 20
+	payrollListMaxPageSize     = 100
+)
+
+var payrollRecordSortable = map[string]bool{
+	"payment_period": true,
+	"payment_date":   true,
+	"name":           true,
+	"status":         true,
+	"total_salary":   true,
+	"created_at":     true,
+}
+
 type PayrollRecordService interface {
 	//Read
-	GetPayrollRecordList(ctx context.Context) ([]model.PayrollRecordListModel, error)
+	GetPayrollRecordList(ctx context.Context, f model.PayrollRecordFilter) (model.PayrollRecordPage, error)
 	GetPayrollRecordDetail(ctx context.Context, id uuid.UUID) (model.PayrollRecordDetailModel, error)
 	//Update
-	UpdatePayrollRecord(ctx context.Context, id uuid.UUID, p model.PayrollRecord) (uuid.UUID, error)
+	UpdatePayrollRecord(ctx context.Context, id uuid.UUID, p model.UpdatePayrollRecordModel) (uuid.UUID, error)
 	// UpdatePayrollRecord(ctx context.Context, id uuid.UUID, p model.PayrollRecord) (model.PayrollRecord, error)
 	//Create
 	// CreatePayrollRecord(ctx context.Context, p model.PayrollRecord) (model.PayrollRecord, error)
-	CreatePayrollRecord(ctx context.Context, p model.PayrollRecord) (uuid.UUID, error)
-	// CreatePayrollRecordList(ctx context.Context, p []model.PayrollRecord) ([]model.PayrollRecord, error)
-	CreatePayrollRecordList(ctx context.Context, p []model.PayrollRecord) ([]uuid.UUID, error)
+	CreatePayrollRecord(ctx context.Context, p model.CreatePayrollRecordModel) (uuid.UUID, error)
+	CreatePayrollRecordList(ctx context.Context, p []model.CreatePayrollRecordModel, dryRun bool) (model.PayrollRecordBatchResult, error)
+	SavePayrollRecordList(ctx context.Context, p []model.CreatePayrollRecordModel, dryRun bool) (model.PayrollRecordBatchResult, error)
 }
 
 type payrollRecordService struct {
-	payrollRecordRepo repository.PayrollRecordRepo
-	timeoutContext    time.Duration
-	db                *sqlx.DB
+	payrollRecordRepo    repository.PayrollRecordRepo
+	bpjsRepo             repository.BpjsRepo
+	payrollItemRepo      repository.PayrollItemRepo
+	payrollRunRepo       repository.PayrollRunRepo
+	payrollPeriodRepo    repository.PayrollPeriodRepo
+	statusRepo           repository.StatusRepo
+	overtimeRepo         repository.OvertimeRepo
+	payrollComponentRepo repository.PayrollComponentRepo
+	payrollCalculation   PayrollCalculationService
+	timeoutContext       time.Duration
+	db                   *sqlx.DB
 }
 
-func NewPayrollRecordService(r repository.PayrollRecordRepo, timeoutContext time.Duration, db *sqlx.DB) PayrollRecordService {
+func NewPayrollRecordService(r repository.PayrollRecordRepo, bpjsRepo repository.BpjsRepo, payrollItemRepo repository.PayrollItemRepo, payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, statusRepo repository.StatusRepo, overtimeRepo repository.OvertimeRepo, payrollComponentRepo repository.PayrollComponentRepo, calc PayrollCalculationService, timeoutContext time.Duration, db *sqlx.DB) PayrollRecordService {
 	return &payrollRecordService{
-		payrollRecordRepo: r,
-		timeoutContext:    timeoutContext,
-		db:                db,
+		payrollRecordRepo:    r,
+		bpjsRepo:             bpjsRepo,
+		payrollItemRepo:      payrollItemRepo,
+		payrollRunRepo:       payrollRunRepo,
+		payrollPeriodRepo:    payrollPeriodRepo,
+		statusRepo:           statusRepo,
+		overtimeRepo:         overtimeRepo,
+		payrollComponentRepo: payrollComponentRepo,
+		payrollCalculation:   calc,
+		timeoutContext:       timeoutContext,
+		db:                   db,
 	}
 }
 
-func (s *payrollRecordService) GetPayrollRecordList(ctx context.Context) ([]model.PayrollRecordListModel, error) {
+// Page of the payroll list, an empty page when nothing matches
+func (s *payrollRecordService) GetPayrollRecordList(ctx context.Context, f model.PayrollRecordFilter) (model.PayrollRecordPage, error) {
 	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
 	defer cancel()
 
 	var (
-		list []model.PayrollRecordListModel
+		page model.PayrollRecordPage
 		err  error
 	)
+	page.Items = make([]model.PayrollRecordListModel, 0)
 
-	list, err = s.payrollRecordRepo.GetPayrollRecordList(ctx)
+	f, err = normalizePayrollRecordFilter(f)
+	if err != nil {
+		return page, err
+	}
+	page.Page = f.Page
+	page.Page_size = f.Page_size
 
+	page.Items, page.Total, err = s.payrollRecordRepo.GetPayrollRecordList(ctx, f)
 	if err != nil {
-		utils.LogError("Services", "GetLeaveBalance", err)
-		return list, err
+		utils.LogError("Services", "GetPayrollRecordList", err)
+		return page, err
+	}
+	page.Total_pages = (page.Total + f.Page_size - 1) / f.Page_size
+	return page, err
+}
+
+// Validates the filter and fills in the defaults: newest period first, 20
+// rows a page
+func normalizePayrollRecordFilter(f model.PayrollRecordFilter) (model.PayrollRecordFilter, error) {
+	for _, period := range []string{f.Period_from, f.Period_to} {
+		if _, err := time.Parse(PaymentPeriodLayout, period); period != "" && err != nil {
+			return f, errors.New("period_from and period_to must be formatted as YYYY-MM")
+		}
+	}
+	for _, date := range []string{f.Payment_date_from, f.Payment_date_to} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return f, errors.New("payment_date_from and payment_date_to must be formatted as YYYY-MM-DD")
+		}
+	}
+
+	switch {
+	case f.Sort == "":
+		f.Sort = "payment_period"
+	case !payrollRecordSortable[f.Sort]:
+		return f, errors.New("sort must be one of payment_period, payment_date, name, status, total_salary, created_at")
+	}
+	switch strings.ToLower(f.Order) {
+	case "":
+		f.Order = "DESC"
+		if f.Sort == "name" || f.Sort == "status" {
+			f.Order = "ASC"
+		}
+	case "asc":
+		f.Order = "ASC"
+	case "desc":
+		f.Order = "DESC"
+	default:
+		return f, errors.New("order must be asc or desc")
+	}
+
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.Page_size == 0 {
+		f.Page_size = payrollListDefaultPageSize
+	}
+	if f.Page < 1 {
+		return f, errors.New("page must be greater than zero")
+	}
+	if f.Page_size < 1 || f.Page_size > payrollListMaxPageSize {
+		return f, fmt.Errorf("page_size must be between 1 and %d", payrollListMaxPageSize)
 	}
-	return list, err
+	f.Search = strings.TrimSpace(f.Search)
+	return f, nil
 }
 
 func (s *payrollRecordService) GetPayrollRecordDetail(ctx context.Context, id uuid.UUID) (model.PayrollRecordDetailModel, error) {
@@ -72,6 +169,18 @@ func (s *payrollRecordService) GetPayrollRecordDetail(ctx context.Context, id uu
 		utils.LogError("Services", "GetPayrollRecordDetail", err)
 		return detail, err
 	}
+
+	detail.Bpjs_items, err = s.bpjsRepo.GetBpjsItemList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollRecordDetail get bpjs items", err)
+		return detail, err
+	}
+
+	detail.Items, err = s.payrollItemRepo.GetPayrollItemList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollRecordDetail get payroll items", err)
+		return detail, err
+	}
 	return detail, err
 }
 
@@ -89,7 +198,7 @@ func (s *payrollRecordService) GetPayrollRecordDetail(ctx context.Context, id uu
 // 	return s.payrollRecordRepo.CreatePayrollRecord(p)
 // }
 
-func (s *payrollRecordService) CreatePayrollRecord(ctx context.Context, p model.PayrollRecord) (uuid.UUID, error) {
+func (s *payrollRecordService) CreatePayrollRecord(ctx context.Context, p model.CreatePayrollRecordModel) (uuid.UUID, error) {
 	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
 	defer cancel()
 
@@ -98,90 +207,308 @@ func (s *payrollRecordService) CreatePayrollRecord(ctx context.Context, p model.
 		err error
 	)
 
+	payrollRecord, result, err := s.preparePayrollRecord(ctx, p)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRecord calculate", err)
+		return id, err
+	}
+
 	tx, err := s.db.Beginx()
 	if err != nil {
 		utils.LogError("Services", "CreatePayrollRecord open tx", err)
 		return id, err
 	}
 
-	// var payrollRecord = model.PayrollRecord{}
-	// payrollRecord.Payment_period = p.Payment_period
-	// payrollRecord.Payment_date, _ = p.Payment_date, time.RFC822
-	// payrollRecord.Basic_salary = p.Basic_salary
-	// payrollRecord.Bpjs = p.Bpjs
-	// payrollRecord.Tax = p.Tax
-	// payrollRecord.Total_salary = p.Total_salary
-	// payrollRecord.Status_id = p.Status_id
-	// payrollRecord.User_id = p.User_id
-
-	id, err = s.payrollRecordRepo.CreatePayrollRecord(ctx, tx, p)
-
+	id, err = s.insertPayrollRecord(ctx, tx, payrollRecord, result)
 	if err != nil {
 		utils.LogError("Services", "CreatePayrollRecord", err)
 		utils.CommitOrRollback(tx, "Services CreatePayrollRecord", err)
 		return id, err
 	}
 
-	utils.CommitOrRollback(tx, "Services UpdateLeaveBalance", err)
+	utils.CommitOrRollback(tx, "Services CreatePayrollRecord", err)
 	return id, err
 }
 
-// Go Routine for Form Update List Payroll
-// func (s *payrollRecordService) CreatePayrollRecordList(ctx context.Context, p []model.PayrollRecord) ([]uuid.UUID, error) {
-// 	n := len(p) / 2
-// 	channel := make(chan model.PayrollRecord)
+// Validates a new record and computes its figures without saving anything.
+// A new record is always a draft, it joins the draft run of its period when
+// there is one and is adopted by the run generated later otherwise, so it
+// only gets approved through the run's review. A user is paid once per
+// period, whichever path the record comes in through.
+func (s *payrollRecordService) preparePayrollRecord(ctx context.Context, p model.CreatePayrollRecordModel) (model.PayrollRecord, model.PayrollCalculationResult, error) {
+	if p.User_id == uuid.Nil {
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, errors.New("user_id is required")
+	}
+	if p.Status_id != uuid.Nil {
+		err := errors.New("status_id cannot be set, a payroll record follows the status of its payroll run")
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	err := checkPayrollPeriodOpen(ctx, s.payrollPeriodRepo, p.Payment_period)
+	if err != nil {
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	_, err = s.payrollRecordRepo.GetPayrollRecordByPeriod(ctx, p.User_id, p.Payment_period, model.PayrollRunRegular)
+	if err == nil {
+		err = errors.New("user already has a payroll record for " + p.Payment_period)
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		utils.LogError("Services", "preparePayrollRecord get existing record", err)
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
 
-// 	go addList(0, n, p, channel, s)
-// 	go addList(n, len(p), p, channel, s)
+	runId, err := s.draftPayrollRun(ctx, p.Payment_period)
+	if err != nil {
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
 
-// 	var result []int
-// 	for i := 0; i < len(p); i++ {
-// 		payrollCreateList := <-channel
-// 		result = append(result, payrollCreateList.Payroll_id)
-// 	}
+	draft, err := s.statusRepo.GetStatusByName(ctx, model.PayrollRunDraft)
+	if err != nil {
+		utils.LogError("Services", "preparePayrollRecord get draft status", err)
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
 
-// 	return result, nil
-// }
+	// Salary figures are never taken from the client
+	record, result, err := computePayrollRecord(ctx, s.payrollCalculation, model.PayrollCalculationInput{
+		User_id:        p.User_id,
+		Payment_period: p.Payment_period,
+		Items:          p.Items,
+		Tax_method:     p.Tax_method,
+		Overtime:       p.Overtime,
+	}, p.Payment_date, draft.Status_id)
+	record.Run_id = runId
+	return record, result, err
+}
 
-func (s *payrollRecordService) CreatePayrollRecordList(ctx context.Context, p []model.PayrollRecord) ([]uuid.UUID, error) {
+// Recomputes the regular record a user already has in the period with new
+// inputs, keeping its status, run and the items the inputs leave out, or
+// prepares a new record when there is none yet
+func (s *payrollRecordService) prepareSavedPayrollRecord(ctx context.Context, p model.CreatePayrollRecordModel) (model.PayrollRecord, model.PayrollCalculationResult, error) {
+	existing, err := s.payrollRecordRepo.GetPayrollRecordByPeriod(ctx, p.User_id, p.Payment_period, model.PayrollRunRegular)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.preparePayrollRecord(ctx, p)
+	}
+	if err != nil {
+		utils.LogError("Services", "prepareSavedPayrollRecord get existing record", err)
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	if p.Status_id != uuid.Nil {
+		err = errors.New("status_id cannot be set, a payroll record follows the status of its payroll run")
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	if err = checkPayrollPeriodOpen(ctx, s.payrollPeriodRepo, p.Payment_period); err != nil {
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	if _, err = s.draftPayrollRun(ctx, p.Payment_period); err != nil {
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	status, err := s.statusRepo.GetStatusDetail(ctx, existing.Status_id)
+	if err != nil {
+		utils.LogError("Services", "prepareSavedPayrollRecord get record status", err)
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	if IsPayrollRunLocked(status.Name) {
+		err = errors.New("payroll record of " + p.Payment_period + " is " + status.Name + " and can no longer be changed, create a correction instead")
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
 
-	var (
-		result []uuid.UUID
-		err    error
-	)
+	// The inputs only replace the components they name, the record keeps
+	// the rest of its items and its tax method
+	savedItems, err := s.payrollItemRepo.GetPayrollItemList(ctx, existing.Payroll_id)
+	if err != nil {
+		utils.LogError("Services", "prepareSavedPayrollRecord get existing items", err)
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "prepareSavedPayrollRecord get payroll components", err)
+		return model.PayrollRecord{}, model.PayrollCalculationResult{}, err
+	}
+	if p.Tax_method == "" {
+		p.Tax_method = existing.Tax_method
+	}
+
+	record, result, err := computePayrollRecord(ctx, s.payrollCalculation, model.PayrollCalculationInput{
+		User_id:        p.User_id,
+		Payment_period: p.Payment_period,
+		Items:          replaceItemInputs(retroInputs(savedItems, existing.Proration, componentsByCode(components)), p.Items),
+		Tax_method:     p.Tax_method,
+		Overtime:       p.Overtime,
+	}, p.Payment_date, existing.Status_id)
+	record.Payroll_id = existing.Payroll_id
+	record.Run_id = existing.Run_id
+	return record, result, err
+}
 
-	n := len(p) / 2
-	channel := make(chan uuid.UUID)
+// Inputs of a saved record with the components named by the new inputs
+// replaced, a component without a new input keeps its saved lines
+func replaceItemInputs(saved []model.PayrollItemInput, inputs []model.PayrollItemInput) []model.PayrollItemInput {
+	replaced := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		replaced[input.Component_code] = true
+	}
+	merged := make([]model.PayrollItemInput, 0, len(saved)+len(inputs))
+	for _, input := range saved {
+		if !replaced[input.Component_code] {
+			merged = append(merged, input)
+		}
+	}
+	return append(merged, inputs...)
+}
 
-	go addList(ctx, 0, n, p, channel, s)
-	go addList(ctx, n, len(p), p, channel, s)
+// Regular run of a period new or recomputed records join, if any. Approved
+// periods are immutable, further records go through a new run, and a run
+// under review has to go back to draft first.
+func (s *payrollRecordService) draftPayrollRun(ctx context.Context, period string) (uuid.NullUUID, error) {
+	run, err := s.payrollRunRepo.GetPayrollRunByPeriod(ctx, period, model.PayrollRunRegular)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return uuid.NullUUID{}, nil
+	case err != nil:
+		utils.LogError("Services", "draftPayrollRun get payroll run", err)
+		return uuid.NullUUID{}, err
+	case IsPayrollRunLocked(run.Status):
+		return uuid.NullUUID{}, errors.New("payroll run of " + period + " is " + run.Status + " and can no longer be changed")
+	case run.Status != model.PayrollRunDraft:
+		return uuid.NullUUID{}, errors.New("payroll run of " + period + " is " + run.Status + ", send it back to draft before changing its records")
+	}
+	return uuid.NullUUID{UUID: run.Run_id, Valid: true}, nil
+}
 
-	for i := 0; i < len(p); i++ {
-		payrollCreateList := <-channel
-		result = append(result, payrollCreateList)
+func (s *payrollRecordService) insertPayrollRecord(ctx context.Context, tx *sqlx.Tx, payrollRecord model.PayrollRecord, result model.PayrollCalculationResult) (uuid.UUID, error) {
+	id, err := s.payrollRecordRepo.CreatePayrollRecord(ctx, tx, payrollRecord)
+	if err != nil {
+		return id, err
 	}
+	return id, saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, id, result)
+}
 
-	return result, err
+// Creates a batch of payroll records all or nothing. Every row is validated
+// and computed first, in a bounded pool since each calculation makes its own
+// queries, and the batch is only inserted, in a single transaction, when
+// none of the rows failed.
+func (s *payrollRecordService) CreatePayrollRecordList(ctx context.Context, p []model.CreatePayrollRecordModel, dryRun bool) (model.PayrollRecordBatchResult, error) {
+	return s.savePayrollRecordList(ctx, p, dryRun, s.preparePayrollRecord)
 }
 
-// func (s *payrollRecordService) CreatePayrollRecordList(ctx context.Context, p []model.PayrollRecord) ([]model.PayrollRecord error) {
-// 	n := len(p) / 2
-// 	channel := make(chan model.PayrollRecord)
+// Saves a batch of payroll inputs all or nothing like CreatePayrollRecordList,
+// but a user who already has a regular record of the period, in the draft
+// run or waiting for one, gets that record recomputed instead of a second one
+func (s *payrollRecordService) SavePayrollRecordList(ctx context.Context, p []model.CreatePayrollRecordModel, dryRun bool) (model.PayrollRecordBatchResult, error) {
+	return s.savePayrollRecordList(ctx, p, dryRun, s.prepareSavedPayrollRecord)
+}
 
-// 	go addList(0, n, p, channel, s)
-// 	go addList(n, len(p), p, channel, s)
+// A prepared record with a Payroll_id updates that record, others are new.
+// Overtime imported with a row is saved in the same transaction.
+func (s *payrollRecordService) savePayrollRecordList(ctx context.Context, p []model.CreatePayrollRecordModel, dryRun bool, prepare func(context.Context, model.CreatePayrollRecordModel) (model.PayrollRecord, model.PayrollCalculationResult, error)) (model.PayrollRecordBatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
 
-// 	var result []model.PayrollRecord
-// 	for i := 0; i < len(p); i++ {
-// 		payrollCreateList := <-channel
+	var (
+		result model.PayrollRecordBatchResult
+		err    error
+	)
+	result.Dry_run = dryRun
+	result.Rows = make([]model.PayrollRecordBatchRow, 0, len(p))
+	result.Errors = make([]model.PayrollRecordBatchErr, 0)
 
-// 		result = append(result, payrollCreateList)
-// 	}
+	if len(p) == 0 {
+		return result, errors.New("no payroll records to create")
+	}
 
-// 	return result, nil
-// }
+	type prepared struct {
+		record      model.PayrollRecord
+		calculation model.PayrollCalculationResult
+		err         error
+	}
+	rows := make([]prepared, len(p))
+
+	// A user is paid once per period, within the batch and against the
+	// records already saved
+	seen := make(map[string]int, len(p))
+	for i, row := range p {
+		key := row.User_id.String() + "/" + row.Payment_period
+		if first, ok := seen[key]; ok {
+			rows[i].err = fmt.Errorf("user already has a record for %s at index %d", row.Payment_period, first)
+			continue
+		}
+		seen[key] = i
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(bulkCreateWorkers, len(p)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				rows[i].record, rows[i].calculation, rows[i].err = prepare(ctx, p[i])
+			}
+		}()
+	}
+	for i := range p {
+		if rows[i].err == nil {
+			indexes <- i
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, row := range rows {
+		if row.err != nil {
+			result.Errors = append(result.Errors, model.PayrollRecordBatchErr{Index: i, User_id: p[i].User_id, Error: row.err.Error()})
+			continue
+		}
+		batchRow := model.PayrollRecordBatchRow{
+			Index:          i,
+			User_id:        row.record.User_id,
+			Payment_period: row.record.Payment_period,
+			Total_salary:   row.record.Total_salary,
+		}
+		if row.record.Payroll_id != uuid.Nil {
+			batchRow.Updated = true
+			batchRow.Payroll_id = &rows[i].record.Payroll_id
+		}
+		result.Rows = append(result.Rows, batchRow)
+	}
+	if len(result.Errors) > 0 || dryRun {
+		return result, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "savePayrollRecordList open tx", err)
+		return result, err
+	}
 
-func (s *payrollRecordService) UpdatePayrollRecord(ctx context.Context, id uuid.UUID, p model.PayrollRecord) (uuid.UUID, error) {
+	for i := range result.Rows {
+		row := rows[result.Rows[i].Index]
+		id := row.record.Payroll_id
+		if result.Rows[i].Updated {
+			_, err = s.payrollRecordRepo.UpdatePayrollRecord(ctx, tx, id, row.record)
+			if err == nil {
+				err = saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, id, row.calculation)
+			}
+		} else {
+			id, err = s.insertPayrollRecord(ctx, tx, row.record, row.calculation)
+		}
+		if overtime := p[result.Rows[i].Index].Overtime; err == nil && overtime != nil {
+			err = s.overtimeRepo.ReplaceImportedOvertime(ctx, tx, row.record.User_id, row.record.Payment_period, overtime)
+		}
+		if err != nil {
+			utils.LogError("Services", "savePayrollRecordList", err)
+			utils.CommitOrRollback(tx, "Services savePayrollRecordList", err)
+			result.Errors = append(result.Errors, model.PayrollRecordBatchErr{Index: result.Rows[i].Index, User_id: row.record.User_id, Error: err.Error()})
+			return result, err
+		}
+		result.Rows[i].Payroll_id = &id
+	}
+
+	utils.CommitOrRollback(tx, "Services savePayrollRecordList", err)
+	return result, err
+}
+
+func (s *payrollRecordService) UpdatePayrollRecord(ctx context.Context, id uuid.UUID, p model.UpdatePayrollRecordModel) (uuid.UUID, error) {
 	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
 	defer cancel()
 
@@ -190,32 +517,173 @@ func (s *payrollRecordService) UpdatePayrollRecord(ctx context.Context, id uuid.
 		err      error
 	)
 
+	existing, err := s.payrollRecordRepo.GetPayrollRecord(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRecord get record", err)
+		return idResult, err
+	}
+	detail, err := s.payrollRecordRepo.GetPayrollRecordDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRecord get record status", err)
+		return idResult, err
+	}
+	// Paid figures are never overwritten, they are corrected by a reversal
+	// and an adjustment instead
+	if IsPayrollRunLocked(detail.Status_name) {
+		err = errors.New("payroll record is " + detail.Status_name + " and can no longer be changed, create a correction instead")
+		return idResult, err
+	}
+	if existing.Record_type == model.PayrollRecordReversal {
+		err = errors.New("a reversal mirrors the record it cancels and cannot be edited")
+		return idResult, err
+	}
+	// An open adjustment is recalculated for the period the salary was
+	// earned in and stays booked in its own period
+	calcPeriod := p.Payment_period
+	if existing.Record_type == model.PayrollRecordAdjustment {
+		calcPeriod, err = earnedPayrollPeriod(ctx, s.payrollRecordRepo, existing)
+		if err != nil {
+			return idResult, err
+		}
+		p.Payment_period = existing.Payment_period
+	}
+	// A record always stays with the user it was created for
+	if p.User_id != uuid.Nil && p.User_id != existing.User_id {
+		err = errors.New("user_id of a payroll record cannot be changed")
+		return idResult, err
+	}
+	p.User_id = existing.User_id
+	// Neither the period the record is in nor the one it moves to may be closed
+	for _, period := range []string{existing.Payment_period, p.Payment_period} {
+		err = checkPayrollPeriodOpen(ctx, s.payrollPeriodRepo, period)
+		if err != nil {
+			return idResult, err
+		}
+	}
+	if existing.Run_id.Valid {
+		run, err := s.payrollRunRepo.GetPayrollRunDetail(ctx, existing.Run_id.UUID)
+		if err != nil {
+			utils.LogError("Services", "UpdatePayrollRecord get run", err)
+			return idResult, err
+		}
+		if IsPayrollRunLocked(run.Status) {
+			err = errors.New("payroll record belongs to a " + run.Status + " run and can no longer be changed")
+			utils.LogError("Services", "UpdatePayrollRecord run locked", err)
+			return idResult, err
+		}
+		if run.Run_type != model.PayrollRunRegular {
+			err = errors.New("records of a " + run.Run_type + " run are generated by the run and cannot be edited")
+			return idResult, err
+		}
+		// Records of a run follow the run's status and period
+		if p.Payment_period != run.Payment_period {
+			err = errors.New("payment_period of a payroll run record cannot be changed")
+			return idResult, err
+		}
+	}
+	// Statuses only move with the run, never through an edit
+	p.Status_id = existing.Status_id
+
+	payrollRecord, result, err := computePayrollRecord(ctx, s.payrollCalculation, model.PayrollCalculationInput{
+		User_id:        p.User_id,
+		Payment_period: calcPeriod,
+		Items:          p.Items,
+		Tax_method:     p.Tax_method,
+	}, p.Payment_date, p.Status_id)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRecord calculate", err)
+		return idResult, err
+	}
+	payrollRecord.Payment_period = p.Payment_period
+
 	tx, err := s.db.Beginx()
 	if err != nil {
 		utils.LogError("Services", "UpdatePayrollRecord open tx", err)
 		return idResult, err
 	}
-	// var payrollRecord = model.PayrollRecord{}
-	// payrollRecord.Payment_period = p.Payment_period
-	// payrollRecord.Payment_date, _ = p.Payment_date, time.RFC822
-	// payrollRecord.Basic_salary = p.Basic_salary
-	// payrollRecord.Bpjs = p.Bpjs
-	// payrollRecord.Tax = p.Tax
-	// payrollRecord.Total_salary = p.Total_salary
-	// payrollRecord.Status_id = p.Status_id
-	// payrollRecord.User_id = p.User_id
 
-	idResult, err = s.payrollRecordRepo.UpdatePayrollRecord(ctx, tx, id, p)
+	idResult, err = s.payrollRecordRepo.UpdatePayrollRecord(ctx, tx, id, payrollRecord)
 	if err != nil {
 		utils.LogError("Services", "UpdatePayrollRecord", err)
 		utils.CommitOrRollback(tx, "Services UpdatePayrollRecord", err)
 		return idResult, err
 	}
 
-	utils.CommitOrRollback(tx, "Services UpdateLeaveBalance", err)
+	err = saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, id, result)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRecord save line items", err)
+		utils.CommitOrRollback(tx, "Services UpdatePayrollRecord", err)
+		return idResult, err
+	}
+
+	utils.CommitOrRollback(tx, "Services UpdatePayrollRecord", err)
 	return idResult, err
 }
 
+// Runs the calculation engine and maps its result into a payroll record
+func computePayrollRecord(ctx context.Context, calc PayrollCalculationService, in model.PayrollCalculationInput, paymentDate string, statusId uuid.UUID) (model.PayrollRecord, model.PayrollCalculationResult, error) {
+	var (
+		payrollRecord model.PayrollRecord
+		result        model.PayrollCalculationResult
+	)
+
+	date, err := time.Parse(time.RFC3339, paymentDate+"T00:00:00Z")
+	if err != nil {
+		err = errors.New("payment_date must be formatted as YYYY-MM-DD")
+		return payrollRecord, result, err
+	}
+
+	result, err = calc.Calculate(ctx, in)
+	if err != nil {
+		return payrollRecord, result, err
+	}
+
+	return payrollRecordFromResult(result, date, statusId), result, err
+}
+
+// Maps a calculation result onto the payroll_records columns
+func payrollRecordFromResult(result model.PayrollCalculationResult, paymentDate time.Time, statusId uuid.UUID) model.PayrollRecord {
+	var payrollRecord model.PayrollRecord
+
+	payrollRecord.User_id = result.User_id
+	payrollRecord.Payment_period = result.Payment_period
+	payrollRecord.Payment_date = paymentDate
+	payrollRecord.Basic_salary = result.Basic_salary
+	payrollRecord.Proration = result.Proration
+	payrollRecord.Allowance = result.Allowance
+	payrollRecord.Bpjs = result.Bpjs
+	payrollRecord.Tax = result.Tax
+	payrollRecord.Tax_method = result.Tax_method
+	payrollRecord.Tax_detail = result.Tax_detail
+	payrollRecord.Total_salary = result.Total_salary
+	payrollRecord.Status_id = statusId
+	return payrollRecord
+}
+
+// Replaces the earning, deduction and BPJS lines of a payroll record
+// with the ones computed by the calculation engine
+func saveLineItems(ctx context.Context, tx *sqlx.Tx, payrollItemRepo repository.PayrollItemRepo, bpjsRepo repository.BpjsRepo, payrollId uuid.UUID, result model.PayrollCalculationResult) error {
+	if err := payrollItemRepo.DeletePayrollItems(ctx, tx, payrollId); err != nil {
+		return err
+	}
+	if err := bpjsRepo.DeleteBpjsItems(ctx, tx, payrollId); err != nil {
+		return err
+	}
+	for _, item := range result.Items {
+		item.Payroll_id = payrollId
+		if _, err := payrollItemRepo.CreatePayrollItem(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+	for _, item := range result.Bpjs_detail.Items {
+		item.Payroll_id = payrollId
+		if _, err := bpjsRepo.CreateBpjsItem(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // func (s *payrollRecordService) UpdatePayrollRecord(ctx context.Context, id uuid.UUID, p model.PayrollRecord) (model.PayrollRecord, error) {
 // 	// var payrollRecord = model.PayrollRecord{}
 // 	// payrollRecord.Payment_period = p.Payment_period
@@ -229,19 +697,3 @@ func (s *payrollRecordService) UpdatePayrollRecord(ctx context.Context, id uuid.
 
 // 	return s.payrollRecordRepo.UpdatePayrollRecord(id, p)
 // }
-
-// Go Routine for Form Create List Payroll
-// func addList(start int, end int, createList []model.PayrollRecord, channel chan model.PayrollRecord, s *payrollRecordService) {
-// 	for i := start; i < end; i++ {
-// 		payrollList, _ := s.CreatePayrollRecord(createList[i])
-// 		channel <- payrollList
-// 	}
-// }
-
-func addList(ctx context.Context, start int, end int, createList []model.PayrollRecord, channel chan uuid.UUID, s *payrollRecordService) {
-
-	for i := start; i < end; i++ {
-		payrollList, _ := s.CreatePayrollRecord(ctx, createList[i])
-		channel <- payrollList
-	}
-}
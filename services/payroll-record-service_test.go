@@ -0,0 +1,49 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestReplaceItemInputs(t *testing.T) {
+	saved := []model.PayrollItemInput{
+		{Component_code: "TRANSPORT", Amount: 500000, Note: "carried forward"},
+		{Component_code: "BONUS", Amount: 1000000, Note: "Q1"},
+		{Component_code: "BONUS", Amount: 250000, Note: "referral"},
+	}
+
+	tests := []struct {
+		name   string
+		inputs []model.PayrollItemInput
+		want   []model.PayrollItemInput
+	}{
+		{
+			name:   "no inputs keep the saved items",
+			inputs: nil,
+			want:   saved,
+		},
+		{
+			name:   "a new component is added",
+			inputs: []model.PayrollItemInput{{Component_code: "MEAL", Amount: 300000}},
+			want:   append(append([]model.PayrollItemInput{}, saved...), model.PayrollItemInput{Component_code: "MEAL", Amount: 300000}),
+		},
+		{
+			name:   "every saved line of a component is replaced",
+			inputs: []model.PayrollItemInput{{Component_code: "BONUS", Amount: 2000000}},
+			want: []model.PayrollItemInput{
+				{Component_code: "TRANSPORT", Amount: 500000, Note: "carried forward"},
+				{Component_code: "BONUS", Amount: 2000000},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceItemInputs(saved, tt.inputs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replaceItemInputs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Allowed moves of the payroll run state machine, a reviewed run can be
+// sent back to draft for corrections
+var payrollRunTransitions = map[string][]string{
+	model.PayrollRunDraft:    {model.PayrollRunReviewed},
+	model.PayrollRunReviewed: {model.PayrollRunDraft, model.PayrollRunApproved},
+	model.PayrollRunApproved: {model.PayrollRunPaid},
+	model.PayrollRunPaid:     {model.PayrollRunClosed},
+}
+
+// IsPayrollRunLocked reports whether the records of a run are immutable
+func IsPayrollRunLocked(status string) bool {
+	switch status {
+	case model.PayrollRunApproved, model.PayrollRunPaid, model.PayrollRunClosed:
+		return true
+	}
+	return false
+}
+
+// Record computed while a run is generated. Payroll_id is set when an
+// existing record of the period is adopted rather than a new one created.
+type payrollRunDraft struct {
+	payroll_id uuid.UUID
+	record     model.PayrollRecord
+	result     model.PayrollCalculationResult
+}
+
+type PayrollRunService interface {
+	//Create
+	CreatePayrollRun(ctx context.Context, r model.CreatePayrollRunModel) (model.PayrollRunGenerateResult, error)
+	//Read
+	GetPayrollRunList(ctx context.Context) ([]model.PayrollRun, error)
+	GetPayrollRunDetail(ctx context.Context, id uuid.UUID) (model.PayrollRunDetailModel, error)
+	//Update
+	UpdatePayrollRunStatus(ctx context.Context, id uuid.UUID, r model.UpdatePayrollRunStatusModel) (uuid.UUID, error)
+}
+
+type payrollRunService struct {
+	payrollRunRepo       repository.PayrollRunRepo
+	payrollPeriodRepo    repository.PayrollPeriodRepo
+	payrollRecordRepo    repository.PayrollRecordRepo
+	payrollItemRepo      repository.PayrollItemRepo
+	payrollComponentRepo repository.PayrollComponentRepo
+	bpjsRepo             repository.BpjsRepo
+	userRepo             repository.UserRepo
+	statusRepo           repository.StatusRepo
+	compensationRepo     repository.CompensationRepo
+	payrollCalculation   PayrollCalculationService
+	timeoutContext       time.Duration
+	db                   *sqlx.DB
+}
+
+func NewPayrollRunService(payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, payrollRecordRepo repository.PayrollRecordRepo, payrollItemRepo repository.PayrollItemRepo, payrollComponentRepo repository.PayrollComponentRepo, bpjsRepo repository.BpjsRepo, userRepo repository.UserRepo, statusRepo repository.StatusRepo, compensationRepo repository.CompensationRepo, calc PayrollCalculationService, timeoutContext time.Duration, db *sqlx.DB) PayrollRunService {
+	return &payrollRunService{
+		payrollRunRepo:       payrollRunRepo,
+		payrollPeriodRepo:    payrollPeriodRepo,
+		payrollRecordRepo:    payrollRecordRepo,
+		payrollItemRepo:      payrollItemRepo,
+		payrollComponentRepo: payrollComponentRepo,
+		bpjsRepo:             bpjsRepo,
+		userRepo:             userRepo,
+		statusRepo:           statusRepo,
+		compensationRepo:     compensationRepo,
+		payrollCalculation:   calc,
+		timeoutContext:       timeoutContext,
+		db:                   db,
+	}
+}
+
+func (s *payrollRunService) GetPayrollRunList(ctx context.Context) ([]model.PayrollRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	list, err := s.payrollRunRepo.GetPayrollRunList(ctx)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollRunList", err)
+		return list, err
+	}
+	return list, err
+}
+
+func (s *payrollRunService) GetPayrollRunDetail(ctx context.Context, id uuid.UUID) (model.PayrollRunDetailModel, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		detail model.PayrollRunDetailModel
+		err    error
+	)
+
+	detail.PayrollRun, err = s.payrollRunRepo.GetPayrollRunDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollRunDetail", err)
+		return detail, err
+	}
+
+	detail.Records, err = s.payrollRecordRepo.GetPayrollRecordListByRun(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollRunDetail get records", err)
+		return detail, err
+	}
+	return detail, err
+}
+
+// Opens a run for a period and generates a draft record for every active
+// user. Each draft is computed from the compensation in force in the period
+// and carries forward the recurring items of the user's latest record, if
+// any, besides the salary. Users the calculation fails for, such as those
+// without a compensation, are reported as skipped. A record already created
+// for the period is moved into the run and recomputed instead, so nobody is
+// paid twice for the same month.
+func (s *payrollRunService) CreatePayrollRun(ctx context.Context, r model.CreatePayrollRunModel) (model.PayrollRunGenerateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.PayrollRunGenerateResult
+		err    error
+	)
+	result.Created = make([]uuid.UUID, 0)
+	result.Adopted = make([]uuid.UUID, 0)
+	result.Skipped = make([]model.PayrollRunSkippedUser, 0)
+
+	if _, err = time.Parse(PaymentPeriodLayout, r.Payment_period); err != nil {
+		err = errors.New("payment_period must be formatted as YYYY-MM")
+		return result, err
+	}
+	paymentDate, err := time.Parse(time.RFC3339, r.Payment_date+"T00:00:00Z")
+	if err != nil {
+		err = errors.New("payment_date must be formatted as YYYY-MM-DD")
+		return result, err
+	}
+	err = checkPayrollPeriodOpen(ctx, s.payrollPeriodRepo, r.Payment_period)
+	if err != nil {
+		return result, err
+	}
+
+	_, err = s.payrollRunRepo.GetPayrollRunByPeriod(ctx, r.Payment_period, model.PayrollRunRegular)
+	if err == nil {
+		err = errors.New("payroll run of " + r.Payment_period + " already exists")
+		return result, err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		utils.LogError("Services", "CreatePayrollRun get run by period", err)
+		return result, err
+	}
+
+	draft, err := s.statusRepo.GetStatusByName(ctx, model.PayrollRunDraft)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun get draft status", err)
+		return result, err
+	}
+
+	users, err := s.userRepo.GetActiveUserList(ctx)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun get active users", err)
+		return result, err
+	}
+
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun get payroll components", err)
+		return result, err
+	}
+	byCode := componentsByCode(components)
+
+	// Compute every draft before writing so a failing calculation only
+	// skips the user instead of aborting the run
+	drafts := make([]payrollRunDraft, 0, len(users))
+	for _, user := range users {
+		existing, err := s.payrollRecordRepo.GetPayrollRecordByPeriod(ctx, user.User_id, r.Payment_period, model.PayrollRunRegular)
+		if err == nil {
+			adopted, reason, err := s.adoptPayrollRecord(ctx, existing, byCode, paymentDate, draft.Status_id)
+			if err != nil {
+				return result, err
+			}
+			if reason != "" {
+				result.Skipped = append(result.Skipped, model.PayrollRunSkippedUser{User_id: user.User_id, Name: user.Name, Reason: reason})
+				continue
+			}
+			drafts = append(drafts, adopted)
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			utils.LogError("Services", "CreatePayrollRun get record of the period", err)
+			return result, err
+		}
+
+		items, taxMethod, err := s.carryForward(ctx, user.User_id, byCode)
+		if err != nil {
+			return result, err
+		}
+		if r.Tax_method != "" {
+			taxMethod = r.Tax_method
+		}
+
+		record, calculation, err := computePayrollRecord(ctx, s.payrollCalculation, model.PayrollCalculationInput{
+			User_id:        user.User_id,
+			Payment_period: r.Payment_period,
+			Items:          items,
+			Tax_method:     taxMethod,
+		}, paymentDate.Format("2006-01-02"), draft.Status_id)
+		if err != nil {
+			result.Skipped = append(result.Skipped, model.PayrollRunSkippedUser{User_id: user.User_id, Name: user.Name, Reason: err.Error()})
+			continue
+		}
+		drafts = append(drafts, payrollRunDraft{record: record, result: calculation})
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun open tx", err)
+		return result, err
+	}
+
+	result.Run_id, err = s.payrollRunRepo.CreatePayrollRun(ctx, tx, model.PayrollRun{
+		Payment_period: r.Payment_period,
+		Payment_date:   paymentDate,
+		Run_type:       model.PayrollRunRegular,
+		Status:         model.PayrollRunDraft,
+	})
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun", err)
+		utils.CommitOrRollback(tx, "Services CreatePayrollRun", err)
+		return result, err
+	}
+
+	// Corrections booked in the period before it had a run are paid with it
+	err = s.payrollRecordRepo.AttachPayrollCorrectionsToRun(ctx, tx, result.Run_id, r.Payment_period, draft.Status_id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun attach corrections", err)
+		utils.CommitOrRollback(tx, "Services CreatePayrollRun", err)
+		return result, err
+	}
+
+	for _, d := range drafts {
+		d.record.Run_id = uuid.NullUUID{UUID: result.Run_id, Valid: true}
+		id := d.payroll_id
+		if id == uuid.Nil {
+			id, err = s.payrollRecordRepo.CreatePayrollRecord(ctx, tx, d.record)
+		} else {
+			_, err = s.payrollRecordRepo.UpdatePayrollRecord(ctx, tx, id, d.record)
+		}
+		if err == nil {
+			err = saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, id, d.result)
+		}
+		if err != nil {
+			utils.LogError("Services", "CreatePayrollRun save record", err)
+			utils.CommitOrRollback(tx, "Services CreatePayrollRun", err)
+			return result, err
+		}
+		if d.payroll_id == uuid.Nil {
+			result.Created = append(result.Created, id)
+		} else {
+			result.Adopted = append(result.Adopted, id)
+		}
+	}
+
+	utils.CommitOrRollback(tx, "Services CreatePayrollRun", err)
+	return result, err
+}
+
+// Recurring inputs and tax method of the user's latest record a new draft
+// starts from, nothing for a user paid for the first time
+func (s *payrollRunService) carryForward(ctx context.Context, userId uuid.UUID, byCode map[string]model.PayrollComponent) ([]model.PayrollItemInput, string, error) {
+	latest, err := s.payrollRecordRepo.GetLatestPayrollRecord(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", nil
+	}
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun get latest record", err)
+		return nil, "", err
+	}
+
+	previousItems, err := s.payrollItemRepo.GetPayrollItemList(ctx, latest.Payroll_id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun get latest items", err)
+		return nil, "", err
+	}
+	// The compensation the latest record was paid with tells its fixed
+	// allowances apart from the items entered for it
+	period, _ := time.Parse(PaymentPeriodLayout, latest.Payment_period)
+	paidWith, err := s.compensationRepo.GetCompensationInForce(ctx, userId, period.AddDate(0, 1, -1))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.LogError("Services", "CreatePayrollRun get compensation of the latest record", err)
+		return nil, "", err
+	}
+	return carriedForwardInputs(previousItems, latest.Proration, byCode, paidWith.Allowances), latest.Tax_method, nil
+}
+
+// Items of a previous record that recur in the next period. The salary is
+// left out, the basic salary and the fixed allowances are paid from the
+// compensation in force, so a raise applies and a dropped allowance stops.
+func carriedForwardInputs(items []model.PayrollItem, proration model.Proration, byCode map[string]model.PayrollComponent, allowances []model.PayrollItemInput) []model.PayrollItemInput {
+	fixed := make(map[string]bool, len(allowances))
+	for _, allowance := range allowances {
+		fixed[allowance.Component_code] = true
+	}
+	inputs := make([]model.PayrollItemInput, 0, len(items))
+	for _, item := range items {
+		if item.Is_one_off || byCode[item.Component_code].Is_system || fixed[item.Component_code] || item.Amount <= 0 {
+			continue
+		}
+		amount := item.Amount
+		// A partial month of a joiner carries the full monthly amount forward
+		if item.Component_type == model.ComponentEarning {
+			amount = unprorateAmount(item.Amount, proration)
+		}
+		inputs = append(inputs, model.PayrollItemInput{Component_code: item.Component_code, Amount: amount, Note: item.Note})
+	}
+	return inputs
+}
+
+// Recomputes a record created for the period before its run existed so it
+// can join the run as a draft. The record keeps its own inputs and tax
+// method. A record that was already settled is left alone and the reason
+// is returned instead.
+func (s *payrollRunService) adoptPayrollRecord(ctx context.Context, existing model.PayrollRecord, byCode map[string]model.PayrollComponent, paymentDate time.Time, draftId uuid.UUID) (payrollRunDraft, string, error) {
+	adopted := payrollRunDraft{payroll_id: existing.Payroll_id}
+
+	status, err := s.statusRepo.GetStatusDetail(ctx, existing.Status_id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun get status of the existing record", err)
+		return adopted, "", err
+	}
+	if IsPayrollRunLocked(status.Name) {
+		return adopted, "already has a " + status.Name + " record for " + existing.Payment_period + " outside of a run", nil
+	}
+
+	savedItems, err := s.payrollItemRepo.GetPayrollItemList(ctx, existing.Payroll_id)
+	if err != nil {
+		utils.LogError("Services", "CreatePayrollRun get existing items", err)
+		return adopted, "", err
+	}
+	items := make([]model.PayrollItemInput, 0, len(savedItems))
+	for _, item := range savedItems {
+		if byCode[item.Component_code].Is_system {
+			continue
+		}
+		amount := item.Amount
+		// Recurring earnings were prorated, the calculation prorates them again
+		if item.Component_type == model.ComponentEarning && !item.Is_one_off {
+			amount = unprorateAmount(item.Amount, existing.Proration)
+		}
+		items = append(items, model.PayrollItemInput{Component_code: item.Component_code, Amount: amount, Note: item.Note})
+	}
+
+	adopted.record, adopted.result, err = computePayrollRecord(ctx, s.payrollCalculation, model.PayrollCalculationInput{
+		User_id:        existing.User_id,
+		Payment_period: existing.Payment_period,
+		Items:          items,
+		Tax_method:     existing.Tax_method,
+	}, paymentDate.Format("2006-01-02"), draftId)
+	if err != nil {
+		return adopted, err.Error(), nil
+	}
+	return adopted, "", nil
+}
+
+// Moves a run along the state machine, its records follow the run status
+func (s *payrollRunService) UpdatePayrollRunStatus(ctx context.Context, id uuid.UUID, r model.UpdatePayrollRunStatusModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		idResult uuid.UUID
+		err      error
+	)
+
+	run, err := s.payrollRunRepo.GetPayrollRunDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRunStatus get run", err)
+		return idResult, err
+	}
+
+	allowed := false
+	for _, next := range payrollRunTransitions[run.Status] {
+		if next == r.Status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		err = errors.New("payroll run cannot move from " + run.Status + " to " + r.Status)
+		return idResult, err
+	}
+	// Closing a paid run changes none of its figures, it is still allowed
+	// once the period itself is closed
+	if r.Status != model.PayrollRunClosed {
+		err = checkPayrollPeriodOpen(ctx, s.payrollPeriodRepo, run.Payment_period)
+		if err != nil {
+			return idResult, err
+		}
+	}
+
+	status, err := s.statusRepo.GetStatusByName(ctx, r.Status)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRunStatus get status", err)
+		return idResult, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRunStatus open tx", err)
+		return idResult, err
+	}
+
+	idResult, err = s.payrollRunRepo.UpdatePayrollRunStatus(ctx, tx, id, r.Status)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRunStatus", err)
+		utils.CommitOrRollback(tx, "Services UpdatePayrollRunStatus", err)
+		return idResult, err
+	}
+
+	err = s.payrollRecordRepo.UpdatePayrollRecordStatusByRun(ctx, tx, id, status.Status_id)
+	if err != nil {
+		utils.LogError("Services", "UpdatePayrollRunStatus update records", err)
+		utils.CommitOrRollback(tx, "Services UpdatePayrollRunStatus", err)
+		return idResult, err
+	}
+
+	utils.CommitOrRollback(tx, "Services UpdatePayrollRunStatus", err)
+	return idResult, err
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestCarriedForwardInputs(t *testing.T) {
+	byCode := componentsByCode([]model.PayrollComponent{
+		{Component_code: model.ComponentBasicSalary, Component_type: model.ComponentEarning, Is_system: true},
+		{Component_code: model.ComponentOvertime, Component_type: model.ComponentEarning, Is_system: true},
+		{Component_code: model.ComponentLoanInstallment, Component_type: model.ComponentDeduction, Is_system: true},
+		{Component_code: "POSITION", Component_type: model.ComponentEarning, Is_bpjs_base: true},
+		{Component_code: "TRANSPORT", Component_type: model.ComponentEarning},
+		{Component_code: "BONUS", Component_type: model.ComponentEarning, Is_one_off: true},
+		{Component_code: "COOPERATIVE", Component_type: model.ComponentDeduction},
+	})
+	item := func(code string, amount int) model.PayrollItem {
+		component := byCode[code]
+		return model.PayrollItem{Component_code: code, Component_type: component.Component_type, Amount: amount, Is_one_off: component.Is_one_off}
+	}
+	previous := []model.PayrollItem{
+		item(model.ComponentBasicSalary, 5000000),
+		item("POSITION", 1000000),
+		item("TRANSPORT", 500000),
+		item("BONUS", 2000000),
+		item("COOPERATIVE", 100000),
+		item(model.ComponentOvertime, 150000),
+		item(model.ComponentLoanInstallment, 300000),
+	}
+
+	tests := []struct {
+		name       string
+		items      []model.PayrollItem
+		proration  model.Proration
+		allowances []model.PayrollItemInput
+		want       []model.PayrollItemInput
+	}{
+		{
+			name:       "only the recurring items besides the salary",
+			items:      previous,
+			allowances: []model.PayrollItemInput{{Component_code: "POSITION", Amount: 1000000}},
+			want: []model.PayrollItemInput{
+				{Component_code: "TRANSPORT", Amount: 500000},
+				{Component_code: "COOPERATIVE", Amount: 100000},
+			},
+		},
+		{
+			name:  "an item that is no fixed allowance is carried",
+			items: previous,
+			want: []model.PayrollItemInput{
+				{Component_code: "POSITION", Amount: 1000000},
+				{Component_code: "TRANSPORT", Amount: 500000},
+				{Component_code: "COOPERATIVE", Amount: 100000},
+			},
+		},
+		{
+			name:       "a joiner's partial month carries the monthly earnings",
+			items:      []model.PayrollItem{item("TRANSPORT", 250000), item("COOPERATIVE", 100000)},
+			proration:  model.Proration{Period_days: 30, Employed_days: 15},
+			allowances: nil,
+			want: []model.PayrollItemInput{
+				{Component_code: "TRANSPORT", Amount: 500000},
+				{Component_code: "COOPERATIVE", Amount: 100000},
+			},
+		},
+		{
+			name:  "no previous record",
+			items: nil,
+			want:  []model.PayrollItemInput{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := carriedForwardInputs(tt.items, tt.proration, byCode, tt.allowances)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("carriedForwardInputs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
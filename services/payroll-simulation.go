@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+)
+
+// Highest basic salary tried when looking for a net target
+const simulationMaxSalary = 1_000_000_000_000
+
+// Runs the regular payroll pipeline on a hypothetical employee, e.g. to
+// price an offer or a raise. A gross target fixes the basic salary straight
+// away, a net target is reached by searching the lowest basic salary whose
+// take home pay is at least the target. Nothing is written.
+func (s *payrollCalculationService) Simulate(ctx context.Context, in model.PayrollSimulationModel) (model.PayrollSimulationResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.PayrollSimulationResult
+		err    error
+	)
+	result.Target_type = in.Target_type
+	result.Target_amount = in.Target_amount
+
+	if in.Target_amount <= 0 {
+		return result, errors.New("target_amount must be greater than zero")
+	}
+	if in.Payment_period == "" {
+		in.Payment_period = time.Now().Format(PaymentPeriodLayout)
+	}
+	if _, err = time.Parse(PaymentPeriodLayout, in.Payment_period); err != nil {
+		return result, errors.New("payment_period must be formatted as YYYY-MM")
+	}
+	if in.Jkk_risk_class == 0 {
+		in.Jkk_risk_class = 1
+	}
+	if in.Jkk_risk_class < 1 || in.Jkk_risk_class > 5 {
+		return result, errors.New("jkk_risk_class must be between 1 and 5")
+	}
+	for _, allowance := range in.Allowances {
+		if allowance.Amount <= 0 {
+			return result, errors.New("amount of " + allowance.Component_code + " must be greater than zero")
+		}
+	}
+
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "Simulate get payroll components", err)
+		return result, err
+	}
+	byCode := componentsByCode(components)
+	allowances := 0
+	for _, allowance := range in.Allowances {
+		if byCode[allowance.Component_code].Component_type == model.ComponentEarning {
+			allowances += allowance.Amount
+		}
+	}
+
+	var month model.PayrollCalculationResult
+	switch in.Target_type {
+	case model.SimulationTargetGross:
+		if in.Target_amount <= allowances {
+			return result, errors.New("allowances already reach the gross target, nothing is left for the basic salary")
+		}
+		month, err = s.simulateMonth(ctx, in, in.Target_amount-allowances, components)
+	case model.SimulationTargetNet:
+		month, err = s.simulateNet(ctx, in, components)
+	default:
+		return result, errors.New("target_type must be gross or net")
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.PayrollCalculationResult = month
+	result.Employer_cost = month.Gross_salary + month.Bpjs_employer + month.Tax_detail.Employer_tax
+	return result, err
+}
+
+// Take home pay grows with the basic salary apart from small dips where the
+// TER rate steps up, the search settles on the lowest salary of the first
+// range reaching the target
+func (s *payrollCalculationService) simulateNet(ctx context.Context, in model.PayrollSimulationModel, components []model.PayrollComponent) (model.PayrollCalculationResult, error) {
+	takeHome := func(basic int) (int, error) {
+		month, err := s.simulateMonth(ctx, in, basic, components)
+		return month.Total_salary, err
+	}
+
+	net, err := takeHome(1)
+	if err != nil {
+		return model.PayrollCalculationResult{}, err
+	}
+	if net >= in.Target_amount {
+		return model.PayrollCalculationResult{}, errors.New("allowances already reach the net target, nothing is left for the basic salary")
+	}
+
+	lo, hi := 1, in.Target_amount
+	for {
+		if net, err = takeHome(hi); err != nil {
+			return model.PayrollCalculationResult{}, err
+		}
+		if net >= in.Target_amount {
+			break
+		}
+		if hi > simulationMaxSalary {
+			return model.PayrollCalculationResult{}, errors.New("net target cannot be reached, the deductions exceed what any salary pays")
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if net, err = takeHome(mid); err != nil {
+			return model.PayrollCalculationResult{}, err
+		}
+		if net >= in.Target_amount {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return s.simulateMonth(ctx, in, hi, components)
+}
+
+// One month of the simulated employee through the same steps Calculate
+// takes for a full month without leave, overtime, loans or claims
+func (s *payrollCalculationService) simulateMonth(ctx context.Context, in model.PayrollSimulationModel, basic int, components []model.PayrollComponent) (model.PayrollCalculationResult, error) {
+	var (
+		result model.PayrollCalculationResult
+		err    error
+	)
+	period, _ := time.Parse(PaymentPeriodLayout, in.Payment_period)
+
+	result.Payment_period = in.Payment_period
+	result.Basic_salary = basic
+	result.Proration, _, _, err = prorate(s.prorationMethod, period, nil, nil)
+	if err != nil {
+		return result, err
+	}
+	result.Items, err = buildPayrollItems(model.Compensation{Basic_salary: basic, Allowances: in.Allowances}, nil, components)
+	if err != nil {
+		return result, err
+	}
+
+	var bpjsBase, taxableIncome int
+	result.Gross_salary, bpjsBase, taxableIncome = payrollBases(result.Items)
+
+	result.Bpjs_detail, err = s.bpjs.Calculate(ctx, max(bpjsBase, 0), in.Jkk_risk_class)
+	if err != nil {
+		utils.LogError("Services", "Simulate bpjs", err)
+		return result, err
+	}
+	if result.Bpjs_detail, err = enrolledBpjs(result.Bpjs_detail, in.Bpjs_programs); err != nil {
+		return result, err
+	}
+	result.Bpjs = result.Bpjs_detail.Employee_total
+	result.Bpjs_employer = result.Bpjs_detail.Employer_total
+
+	taxInput := model.Pph21Input{
+		Ptkp_status:       in.Ptkp_status,
+		Method:            in.Tax_method,
+		Period:            period,
+		Gross_income:      max(taxableIncome, 0),
+		Employer_premiums: result.Bpjs_detail.Employer_taxable,
+		Pension_deduction: result.Bpjs_detail.Employee_pension,
+	}
+	// December settles a year in which January to November paid the same
+	if period.Month() == time.December {
+		november := in
+		november.Payment_period = period.AddDate(0, -1, 0).Format(PaymentPeriodLayout)
+		before, err := s.simulateMonth(ctx, november, basic, components)
+		if err != nil {
+			return result, err
+		}
+		months := int(time.November)
+		taxInput.Ytd_gross_income = before.Tax_detail.Taxable_income * months
+		taxInput.Ytd_pension_deduction = before.Tax_detail.Pension_deduction * months
+		taxInput.Ytd_tax = before.Tax * months
+		taxInput.Ytd_months = months
+	}
+
+	result.Tax_detail, err = s.pph21.CalculateMonthly(taxInput)
+	if err != nil {
+		return result, err
+	}
+	result.Tax_method = result.Tax_detail.Method
+	result.Tax_allowance = result.Tax_detail.Tax_allowance
+	result.Tax = result.Tax_detail.Tax
+
+	settlePayrollItems(&result, componentsByCode(components))
+	return result, err
+}
+
+// Keeps the programs the employee takes part in, nil keeps all of them
+func enrolledBpjs(breakdown model.BpjsBreakdown, programs []string) (model.BpjsBreakdown, error) {
+	if programs == nil {
+		return breakdown, nil
+	}
+	enrolled := make(map[string]bool, len(programs))
+	for _, code := range programs {
+		enrolled[strings.ToUpper(strings.TrimSpace(code))] = true
+	}
+
+	var kept model.BpjsBreakdown
+	kept.Items = make([]model.BpjsItem, 0, len(breakdown.Items))
+	for _, item := range breakdown.Items {
+		if !enrolled[item.Program_code] {
+			continue
+		}
+		delete(enrolled, item.Program_code)
+		kept.Employer_total += item.Employer_amount
+		kept.Employee_total += item.Employee_amount
+		switch item.Program_code {
+		case model.BpjsJkk, model.BpjsJkm, model.BpjsKesehatan:
+			kept.Employer_taxable += item.Employer_amount
+		case model.BpjsJht, model.BpjsJp:
+			kept.Employee_pension += item.Employee_amount
+		}
+		kept.Items = append(kept.Items, item)
+	}
+	for code := range enrolled {
+		return kept, errors.New("unknown bpjs program " + code)
+	}
+	return kept, nil
+}
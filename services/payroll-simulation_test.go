@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+)
+
+// Components seeded by the payroll items migration
+type fakePayrollComponentRepo struct {
+	repository.PayrollComponentRepo
+}
+
+func (fakePayrollComponentRepo) GetPayrollComponentList(ctx context.Context) ([]model.PayrollComponent, error) {
+	return []model.PayrollComponent{
+		{Component_code: model.ComponentBasicSalary, Component_type: model.ComponentEarning, Is_taxable: true, Is_bpjs_base: true, Is_system: true},
+		{Component_code: "FIXED_ALLOWANCE", Component_type: model.ComponentEarning, Is_taxable: true, Is_bpjs_base: true},
+		{Component_code: "MEAL", Component_type: model.ComponentEarning, Is_taxable: true},
+		{Component_code: "OTHER_DEDUCTION", Component_type: model.ComponentDeduction, Is_one_off: true},
+		{Component_code: model.ComponentTaxAllowance, Component_type: model.ComponentEarning, Is_taxable: true, Is_system: true},
+		{Component_code: model.ComponentBpjsEmployee, Component_type: model.ComponentDeduction, Is_system: true},
+		{Component_code: model.ComponentPph21, Component_type: model.ComponentDeduction, Is_system: true},
+	}, nil
+}
+
+func TestEnrolledBpjs(t *testing.T) {
+	breakdown, err := NewBpjsService(fakeBpjsRepo{}, 0, nil).Calculate(context.Background(), 10000000, 1)
+	if err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		programs        []string
+		items           int
+		employerTotal   int
+		employeeTotal   int
+		employerTaxable int
+		employeePension int
+		wantErr         bool
+	}{
+		{name: "every program", programs: nil, items: 5, employerTotal: 1024000, employeeTotal: 400000, employerTaxable: 454000, employeePension: 300000},
+		{name: "ketenagakerjaan only", programs: []string{"jht", " JP ", "JKK", "JKM"}, items: 4, employerTotal: 624000, employeeTotal: 300000, employerTaxable: 54000, employeePension: 300000},
+		{name: "none", programs: []string{}, items: 0},
+		{name: "unknown program", programs: []string{"JHT", "JKP"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := enrolledBpjs(breakdown, tt.programs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("enrolledBpjs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Items) != tt.items || got.Employer_total != tt.employerTotal || got.Employee_total != tt.employeeTotal ||
+				got.Employer_taxable != tt.employerTaxable || got.Employee_pension != tt.employeePension {
+				t.Errorf("enrolledBpjs() = %d items, %+v, want %d items, employer %d, employee %d, taxable %d, pension %d",
+					len(got.Items), got, tt.items, tt.employerTotal, tt.employeeTotal, tt.employerTaxable, tt.employeePension)
+			}
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	s := NewPayrollCalculationService(nil, nil, fakePayrollComponentRepo{}, nil, nil, nil, nil, nil, nil, NewPph21Service(), NewBpjsService(fakeBpjsRepo{}, 0, nil), "", 5, time.Second, nil)
+	meal := []model.PayrollItemInput{{Component_code: "MEAL", Amount: 500000}}
+
+	tests := []struct {
+		name    string
+		in      model.PayrollSimulationModel
+		basic   int
+		bpjs    int
+		wantErr string
+	}{
+		{
+			name:  "gross target",
+			in:    model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 10000000, Ptkp_status: "TK/0", Payment_period: "2024-03", Allowances: meal},
+			basic: 9500000, bpjs: 380000,
+		},
+		{
+			name:  "gross target without kesehatan",
+			in:    model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 10000000, Ptkp_status: "TK/0", Payment_period: "2024-03", Allowances: meal, Bpjs_programs: []string{"JHT", "JP", "JKK", "JKM"}},
+			basic: 9500000, bpjs: 285000,
+		},
+		{
+			// December settles the tax of a year paid the same every month
+			name:  "gross target in december",
+			in:    model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 10000000, Ptkp_status: "K/1", Payment_period: "2024-12", Allowances: meal},
+			basic: 9500000, bpjs: 380000,
+		},
+		{name: "net target", in: model.PayrollSimulationModel{Target_type: model.SimulationTargetNet, Target_amount: 8000000, Ptkp_status: "TK/0", Payment_period: "2024-03", Allowances: meal}},
+		{name: "net target paid gross up", in: model.PayrollSimulationModel{Target_type: model.SimulationTargetNet, Target_amount: 25000000, Ptkp_status: "K/2", Tax_method: model.TaxMethodGrossUp, Payment_period: "2024-03", Jkk_risk_class: 3}},
+		{name: "no target", in: model.PayrollSimulationModel{Target_type: model.SimulationTargetGross}, wantErr: "target_amount must be greater than zero"},
+		{name: "bad period", in: model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 1, Payment_period: "03-2024"}, wantErr: "payment_period must be formatted as YYYY-MM"},
+		{name: "bad risk class", in: model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 1, Jkk_risk_class: 6}, wantErr: "jkk_risk_class must be between 1 and 5"},
+		{
+			name:    "no allowance amount",
+			in:      model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 1, Allowances: []model.PayrollItemInput{{Component_code: "MEAL"}}},
+			wantErr: "amount of MEAL must be greater than zero",
+		},
+		{name: "unknown target", in: model.PayrollSimulationModel{Target_type: "cost", Target_amount: 1}, wantErr: "target_type must be gross or net"},
+		{
+			name:    "allowances above the gross target",
+			in:      model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 500000, Ptkp_status: "TK/0", Allowances: meal},
+			wantErr: "allowances already reach the gross target, nothing is left for the basic salary",
+		},
+		{
+			name:    "allowances above the net target",
+			in:      model.PayrollSimulationModel{Target_type: model.SimulationTargetNet, Target_amount: 400000, Ptkp_status: "TK/0", Allowances: meal},
+			wantErr: "allowances already reach the net target, nothing is left for the basic salary",
+		},
+		{
+			name:    "unknown bpjs program",
+			in:      model.PayrollSimulationModel{Target_type: model.SimulationTargetGross, Target_amount: 10000000, Ptkp_status: "TK/0", Bpjs_programs: []string{"JKP"}},
+			wantErr: "unknown bpjs program JKP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Simulate(context.Background(), tt.in)
+			if (tt.wantErr == "") != (err == nil) || err != nil && err.Error() != tt.wantErr {
+				t.Fatalf("Simulate() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+
+			if got.Employer_cost != got.Gross_salary+got.Bpjs_employer+got.Tax_detail.Employer_tax {
+				t.Errorf("Employer_cost = %d, want gross %d + bpjs %d + employer tax %d", got.Employer_cost, got.Gross_salary, got.Bpjs_employer, got.Tax_detail.Employer_tax)
+			}
+			net := got.Gross_salary
+			for _, item := range got.Items {
+				if item.Component_type == model.ComponentDeduction {
+					net -= item.Amount
+				}
+			}
+			if got.Total_salary != net {
+				t.Errorf("Total_salary = %d, want the items to net to %d", got.Total_salary, net)
+			}
+
+			if tt.in.Target_type == model.SimulationTargetGross {
+				if got.Basic_salary != tt.basic || got.Gross_salary-got.Tax_allowance != tt.in.Target_amount || got.Bpjs != tt.bpjs {
+					t.Errorf("Simulate() = basic %d, gross %d, bpjs %d, want %d, %d, %d", got.Basic_salary, got.Gross_salary-got.Tax_allowance, got.Bpjs, tt.basic, tt.in.Target_amount, tt.bpjs)
+				}
+				return
+			}
+
+			// The lowest basic salary reaching the net target
+			if got.Total_salary < tt.in.Target_amount {
+				t.Fatalf("Total_salary = %d, want at least %d", got.Total_salary, tt.in.Target_amount)
+			}
+			lower := tt.in
+			lower.Target_type = model.SimulationTargetGross
+			lower.Target_amount = got.Basic_salary - 1
+			for _, allowance := range lower.Allowances {
+				lower.Target_amount += allowance.Amount
+			}
+			below, err := s.Simulate(context.Background(), lower)
+			if err != nil {
+				t.Fatalf("Simulate() one rupiah lower error = %v", err)
+			}
+			if below.Total_salary >= tt.in.Target_amount {
+				t.Errorf("basic salary %d already nets %d, want the search to stop there", below.Basic_salary, below.Total_salary)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+type PayslipDistributionService interface {
+	DistributePayslips(ctx context.Context, r model.DistributePayslipModel) ([]model.PayslipDelivery, error)
+	GetPayslipDeliveryList(ctx context.Context, period string) ([]model.PayslipDelivery, error)
+}
+
+type payslipDistributionService struct {
+	payslipDeliveryRepo repository.PayslipDeliveryRepo
+	payrollRecordRepo   repository.PayrollRecordRepo
+	userRepo            repository.UserRepo
+	payslipService      PayslipService
+	mailer              utils.Mailer
+	companyName         string
+	maxAttempts         int
+	retryDelay          time.Duration
+	timeoutContext      time.Duration
+	db                  *sqlx.DB
+	// Periods with a distribution job in progress
+	running sync.Map
+}
+
+func NewPayslipDistributionService(payslipDeliveryRepo repository.PayslipDeliveryRepo, payrollRecordRepo repository.PayrollRecordRepo, userRepo repository.UserRepo, payslipService PayslipService, mailer utils.Mailer, companyName string, maxAttempts int, retryDelay time.Duration, timeoutContext time.Duration, db *sqlx.DB) PayslipDistributionService {
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	return &payslipDistributionService{
+		payslipDeliveryRepo: payslipDeliveryRepo,
+		payrollRecordRepo:   payrollRecordRepo,
+		userRepo:            userRepo,
+		payslipService:      payslipService,
+		mailer:              mailer,
+		companyName:         companyName,
+		maxAttempts:         maxAttempts,
+		retryDelay:          retryDelay,
+		timeoutContext:      timeoutContext,
+		db:                  db,
+	}
+}
+
+func (s *payslipDistributionService) GetPayslipDeliveryList(ctx context.Context, period string) ([]model.PayslipDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	list, err := s.payslipDeliveryRepo.GetPayslipDeliveryList(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "GetPayslipDeliveryList", err)
+		return list, err
+	}
+	return list, err
+}
+
+// Queues a delivery for every approved regular record of the period and
+// sends the ones not delivered yet in the background. Corrections booked in
+// the period go out on the regular payslip they are paid with. Calling it again for the same
+// period retries failed deliveries that still have attempts left.
+func (s *payslipDistributionService) DistributePayslips(ctx context.Context, r model.DistributePayslipModel) ([]model.PayslipDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		list []model.PayslipDelivery
+		err  error
+	)
+
+	if _, err = time.Parse(PaymentPeriodLayout, r.Payment_period); err != nil {
+		err = errors.New("payment_period must be formatted as YYYY-MM")
+		return list, err
+	}
+	if _, busy := s.running.LoadOrStore(r.Payment_period, true); busy {
+		err = errors.New("payslips of " + r.Payment_period + " are already being distributed")
+		return list, err
+	}
+	started := false
+	defer func() {
+		if !started {
+			s.running.Delete(r.Payment_period)
+		}
+	}()
+
+	records, err := s.payrollRecordRepo.GetPayslipRecordList(ctx, r.Payment_period)
+	if err != nil {
+		utils.LogError("Services", "DistributePayslips get records", err)
+		return list, err
+	}
+	if len(records) == 0 {
+		err = errors.New("no approved payroll records found for " + r.Payment_period)
+		return list, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "DistributePayslips open tx", err)
+		return list, err
+	}
+	for _, record := range records {
+		detail, err := s.payrollRecordRepo.GetPayrollRecordDetail(ctx, record.Payroll_id)
+		if err != nil {
+			utils.LogError("Services", "DistributePayslips get record", err)
+			utils.CommitOrRollback(tx, "Services DistributePayslips", err)
+			return list, err
+		}
+		user, err := s.userRepo.GetUserDetail(ctx, detail.User_id)
+		if err != nil {
+			utils.LogError("Services", "DistributePayslips get user", err)
+			utils.CommitOrRollback(tx, "Services DistributePayslips", err)
+			return list, err
+		}
+		_, err = s.payslipDeliveryRepo.CreatePayslipDelivery(ctx, tx, model.PayslipDelivery{
+			Payroll_id:     record.Payroll_id,
+			User_id:        user.User_id,
+			Payment_period: r.Payment_period,
+			Email:          user.Email,
+		})
+		if err != nil {
+			utils.LogError("Services", "DistributePayslips create delivery", err)
+			utils.CommitOrRollback(tx, "Services DistributePayslips", err)
+			return list, err
+		}
+	}
+	utils.CommitOrRollback(tx, "Services DistributePayslips", err)
+
+	list, err = s.payslipDeliveryRepo.GetPayslipDeliveryList(ctx, r.Payment_period)
+	if err != nil {
+		utils.LogError("Services", "DistributePayslips get deliveries", err)
+		return list, err
+	}
+
+	queue := make([]model.PayslipDelivery, 0, len(list))
+	for _, delivery := range list {
+		if delivery.Status != model.PayslipDeliverySent && delivery.Attempts < s.maxAttempts {
+			queue = append(queue, delivery)
+		}
+	}
+
+	// The job outlives the request, it runs on its own context
+	started = true
+	go func() {
+		defer s.running.Delete(r.Payment_period)
+		for _, delivery := range queue {
+			s.deliver(context.Background(), delivery)
+		}
+	}()
+
+	return list, err
+}
+
+// Sends one payslip, retrying until it is delivered or out of attempts.
+// Every attempt is recorded so HR can follow the job.
+func (s *payslipDistributionService) deliver(ctx context.Context, delivery model.PayslipDelivery) {
+	for delivery.Attempts < s.maxAttempts {
+		if delivery.Attempts > 0 {
+			time.Sleep(s.retryDelay * time.Duration(delivery.Attempts))
+		}
+		delivery.Attempts++
+
+		err := s.sendPayslip(ctx, delivery)
+		if err == nil {
+			now := time.Now()
+			delivery.Status = model.PayslipDeliverySent
+			delivery.Last_error = ""
+			delivery.Sent_at = &now
+		} else {
+			utils.LogError("Services", "deliver payslip to "+delivery.Email, err)
+			delivery.Status = model.PayslipDeliveryFailed
+			delivery.Last_error = err.Error()
+		}
+
+		if err := s.saveDelivery(ctx, delivery); err != nil {
+			return
+		}
+		if delivery.Status == model.PayslipDeliverySent {
+			return
+		}
+	}
+}
+
+func (s *payslipDistributionService) sendPayslip(ctx context.Context, delivery model.PayslipDelivery) error {
+	if delivery.Email == "" {
+		return errors.New("user has no email address")
+	}
+
+	user, err := s.userRepo.GetUserDetail(ctx, delivery.User_id)
+	if err != nil {
+		return err
+	}
+	password, hint := payslipPassword(user)
+
+	payslip, err := s.payslipService.GetProtectedPayslip(ctx, delivery.Payroll_id, password)
+	if err != nil {
+		return err
+	}
+
+	period := delivery.Payment_period
+	if t, err := time.Parse(PaymentPeriodLayout, period); err == nil {
+		period = t.Format("January 2006")
+	}
+	return s.mailer.Send(utils.MailMessage{
+		To:      delivery.Email,
+		Subject: fmt.Sprintf("Slip gaji %s - %s", period, s.companyName),
+		Body: fmt.Sprintf("Dear %s,\n\nAttached is your payslip for %s.\nThe file is protected, open it with %s.\n\nRegards,\n%s\n",
+			user.Name, period, hint, s.companyName),
+		Attachments: []utils.MailAttachment{{
+			Filename:    payslip.Filename,
+			ContentType: "application/pdf",
+			Content:     payslip.Content,
+		}},
+	})
+}
+
+func (s *payslipDistributionService) saveDelivery(ctx context.Context, delivery model.PayslipDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "saveDelivery open tx", err)
+		return err
+	}
+	_, err = s.payslipDeliveryRepo.UpdatePayslipDelivery(ctx, tx, delivery)
+	utils.CommitOrRollback(tx, "Services saveDelivery", err)
+	return err
+}
+
+// Birth date as DDMMYYYY, or the NIK for users without a birth date
+func payslipPassword(user model.UserDetailModel) (string, string) {
+	if user.Birth_date != nil {
+		return user.Birth_date.Format("02012006"), "your date of birth (DDMMYYYY)"
+	}
+	return user.Nik, "your NIK"
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+)
+
+// A rendered payslip
+type Payslip struct {
+	Filename string
+	Content  []byte
+}
+
+type PayslipService interface {
+	GetPayslip(ctx context.Context, id uuid.UUID) (Payslip, error)
+	GetPayslipArchive(ctx context.Context, period string) (Payslip, error)
+	GetProtectedPayslip(ctx context.Context, id uuid.UUID, password string) (Payslip, error)
+}
+
+type payslipService struct {
+	payrollRecordService PayrollRecordService
+	payrollRecordRepo    repository.PayrollRecordRepo
+	userRepo             repository.UserRepo
+	companyName          string
+	timeoutContext       time.Duration
+}
+
+func NewPayslipService(payrollRecordService PayrollRecordService, payrollRecordRepo repository.PayrollRecordRepo, userRepo repository.UserRepo, companyName string, timeoutContext time.Duration) PayslipService {
+	return &payslipService{
+		payrollRecordService: payrollRecordService,
+		payrollRecordRepo:    payrollRecordRepo,
+		userRepo:             userRepo,
+		companyName:          companyName,
+		timeoutContext:       timeoutContext,
+	}
+}
+
+func (s *payslipService) GetPayslip(ctx context.Context, id uuid.UUID) (Payslip, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	return s.renderPayslip(ctx, id, "")
+}
+
+// Payslip that needs password to be opened, used for emailed payslips
+func (s *payslipService) GetProtectedPayslip(ctx context.Context, id uuid.UUID, password string) (Payslip, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	if password == "" {
+		return Payslip{}, errors.New("payslip password is required")
+	}
+	return s.renderPayslip(ctx, id, password)
+}
+
+// Zips the payslips of every approved regular record in a period
+func (s *payslipService) GetPayslipArchive(ctx context.Context, period string) (Payslip, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		archive Payslip
+		buf     bytes.Buffer
+	)
+
+	if _, err := time.Parse(PaymentPeriodLayout, period); err != nil {
+		return archive, errors.New("period must be formatted as YYYY-MM")
+	}
+
+	records, err := s.payrollRecordRepo.GetPayslipRecordList(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "GetPayslipArchive get records", err)
+		return archive, err
+	}
+	if len(records) == 0 {
+		return archive, errors.New("no approved payroll records found for " + period)
+	}
+
+	w := zip.NewWriter(&buf)
+	for _, record := range records {
+		payslip, err := s.renderPayslip(ctx, record.Payroll_id, "")
+		if err != nil {
+			utils.LogError("Services", "GetPayslipArchive render", err)
+			return archive, err
+		}
+		f, err := w.Create(payslip.Filename)
+		if err != nil {
+			return archive, err
+		}
+		if _, err = f.Write(payslip.Content); err != nil {
+			return archive, err
+		}
+	}
+	if err = w.Close(); err != nil {
+		return archive, err
+	}
+
+	archive.Filename = "payslips-" + period + ".zip"
+	archive.Content = buf.Bytes()
+	return archive, err
+}
+
+func (s *payslipService) renderPayslip(ctx context.Context, id uuid.UUID, password string) (Payslip, error) {
+	var payslip Payslip
+
+	detail, err := s.payrollRecordService.GetPayrollRecordDetail(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "renderPayslip get payroll detail", err)
+		return payslip, err
+	}
+	user, err := s.userRepo.GetUserDetail(ctx, detail.User_id)
+	if err != nil {
+		utils.LogError("Services", "renderPayslip get user", err)
+		return payslip, err
+	}
+	corrections, err := s.payrollRecordRepo.GetPayslipCorrectionList(ctx, id)
+	if err != nil {
+		utils.LogError("Services", "renderPayslip get corrections", err)
+		return payslip, err
+	}
+
+	payslip.Filename = fmt.Sprintf("payslip-%s-%s.pdf", detail.Payment_period, user.Nik)
+	doc := payslipDocument(s.companyName, detail, user, corrections)
+	if password != "" {
+		doc.SetPassword(password, "")
+	}
+	payslip.Content = doc.Bytes()
+	return payslip, nil
+}
+
+// Lays out a single A4 payslip. Corrections paid with it are added to the
+// take home pay.
+func payslipDocument(companyName string, detail model.PayrollRecordDetailModel, user model.UserDetailModel, corrections []model.PayslipCorrection) *utils.PDFDocument {
+	const (
+		left   = 40.0
+		right  = utils.PDFPageWidth - 40
+		middle = utils.PDFPageWidth / 2
+	)
+	doc := utils.NewPDFDocument()
+	doc.AddPage()
+
+	// Header band
+	doc.FillRect(0, 0, utils.PDFPageWidth, 80, 0.92)
+	doc.FillRect(0, 80, utils.PDFPageWidth, 4, 0.15)
+	period := detail.Payment_period
+	if t, err := time.Parse(PaymentPeriodLayout, detail.Payment_period); err == nil {
+		period = t.Format("January 2006")
+	}
+	doc.Text(left, 38, 18, true, companyName)
+	doc.Text(left, 60, 10, false, "SLIP GAJI / PAYSLIP")
+	doc.TextRight(right, 38, 12, true, period)
+	doc.TextRight(right, 60, 9, false, "Payment date "+detail.Payment_date.Format("02 Jan 2006"))
+
+	// Employee profile
+	y := 115.0
+	profile := [][2]string{
+		{"Name", detail.Name},
+		{"NIK", user.Nik},
+		{"Position", user.Position_name},
+		{"PTKP status", user.Ptkp_status},
+		{"Tax method", detail.Tax_method},
+		{"Status", detail.Status_name},
+	}
+	if detail.Proration.Period_days > 0 {
+		profile = append(profile,
+			[2]string{"Paid days", fmt.Sprintf("%d of %d (%s days)", detail.Proration.Paid_days, detail.Proration.Period_days, detail.Proration.Method)},
+			[2]string{"Unpaid days", fmt.Sprintf("%d", detail.Proration.Unpaid_days)},
+		)
+	}
+	for i, row := range profile {
+		x := left
+		if i%2 == 1 {
+			x = middle + 10
+		}
+		doc.Text(x, y, 8, false, row[0])
+		doc.Text(x+70, y, 9, true, row[1])
+		if i%2 == 1 {
+			y += 16
+		}
+	}
+
+	// Earnings on the left, deductions on the right
+	y += 14
+	doc.FillRect(left, y, right-left, 18, 0.9)
+	doc.Text(left+6, y+12, 9, true, "EARNINGS")
+	doc.Text(middle+10, y+12, 9, true, "DEDUCTIONS")
+	y += 32
+
+	var (
+		earnings, deductions           []model.PayrollItem
+		totalEarnings, totalDeductions int
+	)
+	for _, item := range detail.Items {
+		if item.Component_type == model.ComponentEarning {
+			earnings = append(earnings, item)
+			totalEarnings += item.Amount
+		} else {
+			deductions = append(deductions, item)
+			totalDeductions += item.Amount
+		}
+	}
+	rows := max(len(earnings), len(deductions))
+	for i := 0; i < rows; i++ {
+		if i < len(earnings) {
+			doc.Text(left+6, y, 9, false, earnings[i].Name)
+			doc.TextRight(middle-10, y, 9, false, utils.FormatRupiah(earnings[i].Amount))
+		}
+		if i < len(deductions) {
+			doc.Text(middle+10, y, 9, false, deductions[i].Name)
+			doc.TextRight(right-6, y, 9, false, utils.FormatRupiah(deductions[i].Amount))
+		}
+		y += 15
+	}
+
+	doc.Line(left, y-6, right, y-6, 0.5)
+	y += 8
+	doc.Text(left+6, y, 9, true, "Total earnings")
+	doc.TextRight(middle-10, y, 9, true, utils.FormatRupiah(totalEarnings))
+	doc.Text(middle+10, y, 9, true, "Total deductions")
+	doc.TextRight(right-6, y, 9, true, utils.FormatRupiah(totalDeductions))
+
+	// Reversals and adjustments of earlier periods paid with this salary
+	takeHomePay := detail.Total_salary
+	if len(corrections) > 0 {
+		y += 18
+		doc.FillRect(left, y, right-left, 18, 0.9)
+		doc.Text(left+6, y+12, 9, true, "CORRECTIONS OF EARLIER PERIODS")
+		y += 32
+		doc.Text(left+6, y, 9, false, "Net salary "+detail.Payment_period)
+		doc.TextRight(right-6, y, 9, false, utils.FormatRupiah(detail.Total_salary))
+		y += 15
+		for _, correction := range corrections {
+			label := fmt.Sprintf("%s of %s", correctionLabel(correction.Record_type), correction.Corrected_period)
+			if correction.Correction_reason != "" {
+				label += ": " + correction.Correction_reason
+			}
+			doc.Text(left+6, y, 9, false, label)
+			doc.TextRight(right-6, y, 9, false, utils.FormatRupiah(correction.Total_salary))
+			takeHomePay += correction.Total_salary
+			y += 15
+		}
+		doc.Line(left, y-6, right, y-6, 0.5)
+	}
+
+	// Take home pay
+	y += 18
+	doc.FillRect(left, y, right-left, 26, 0.15)
+	doc.FillRect(left+1, y+1, right-left-2, 24, 0.93)
+	doc.Text(left+6, y+17, 11, true, "TAKE HOME PAY")
+	doc.TextRight(right-6, y+17, 11, true, utils.FormatRupiah(takeHomePay))
+	y += 50
+
+	// Why a deduction was made, e.g. the leave request behind it
+	noted := false
+	for _, item := range deductions {
+		if item.Note == "" {
+			continue
+		}
+		if !noted {
+			doc.Text(left, y, 9, true, "Deduction notes")
+			y += 16
+			noted = true
+		}
+		doc.Text(left+6, y, 8, false, item.Name+": "+item.Note)
+		y += 13
+	}
+	if noted {
+		y += 12
+	}
+
+	// Contributions paid by the employer on top of the salary
+	doc.Text(left, y, 9, true, "Employer contributions (not deducted from salary)")
+	y += 16
+	for _, item := range detail.Bpjs_items {
+		if item.Employer_amount == 0 {
+			continue
+		}
+		doc.Text(left+6, y, 8, false, fmt.Sprintf("BPJS %s %.2f%% of %s", item.Program_code, item.Employer_rate*100, utils.FormatRupiah(item.Base_wage)))
+		doc.TextRight(middle-10, y, 8, false, utils.FormatRupiah(item.Employer_amount))
+		y += 13
+	}
+	if detail.Tax_detail.Employer_tax != 0 {
+		doc.Text(left+6, y, 8, false, "PPh 21 borne by employer")
+		doc.TextRight(middle-10, y, 8, false, utils.FormatRupiah(detail.Tax_detail.Employer_tax))
+	}
+
+	doc.Line(left, utils.PDFPageHeight-50, right, utils.PDFPageHeight-50, 0.5)
+	doc.Text(left, utils.PDFPageHeight-36, 7, false, "This payslip is generated electronically and does not require a signature.")
+	doc.TextRight(right, utils.PDFPageHeight-36, 7, false, detail.Payroll_id.String())
+	return doc
+}
+
+func correctionLabel(recordType string) string {
+	if recordType == model.PayrollRecordReversal {
+		return "Reversal"
+	}
+	return "Adjustment"
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/dafiqarba/be-payroll/model"
@@ -82,6 +83,15 @@ func (service *positionService) CreatePosition(ctx context.Context, u model.Posi
 		err error
 	)
 
+	if u.Jkk_risk_class == 0 {
+		u.Jkk_risk_class = 1
+	}
+	if u.Jkk_risk_class < 1 || u.Jkk_risk_class > 5 {
+		err = errors.New("jkk_risk_class must be between 1 and 5")
+		utils.LogError("Services", "CreatePosition", err)
+		return id, err
+	}
+
 	tx, err := service.db.Beginx()
 	if err != nil {
 		utils.LogError("Services", "CreatePosition open tx", err)
@@ -108,6 +118,15 @@ func (service *positionService) UpdatePosition(ctx context.Context, u model.Posi
 		err error
 	)
 
+	if u.Jkk_risk_class == 0 {
+		u.Jkk_risk_class = 1
+	}
+	if u.Jkk_risk_class < 1 || u.Jkk_risk_class > 5 {
+		err = errors.New("jkk_risk_class must be between 1 and 5")
+		utils.LogError("Services", "UpdatePosition", err)
+		return id, err
+	}
+
 	tx, err := service.db.Beginx()
 	if err != nil {
 		utils.LogError("Services", "UpdatePosition open tx", err)
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+const (
+	// Biaya jabatan is 5% of gross, capped at 500.000 a month
+	biayaJabatanRate       = 0.05
+	biayaJabatanMonthlyCap = 500000
+	// PTKP for the taxpayer, the married addition and each dependent
+	ptkpBase      = 54000000
+	ptkpMarried   = 4500000
+	ptkpDependent = 4500000
+	// Number of iterations used to converge the gross-up tax allowance
+	grossUpIterations = 50
+)
+
+// Progressive Pasal 17 brackets (UU HPP)
+var pasal17Brackets = []taxBracket{
+	{60000000, 0.05},
+	{250000000, 0.15},
+	{500000000, 0.25},
+	{5000000000, 0.30},
+	{math.MaxInt64, 0.35},
+}
+
+// PTKP status per TER category (PP 58/2023)
+var terCategories = map[string]string{
+	model.PtkpTK0: "A",
+	model.PtkpTK1: "A",
+	model.PtkpK0:  "A",
+	model.PtkpTK2: "B",
+	model.PtkpTK3: "B",
+	model.PtkpK1:  "B",
+	model.PtkpK2:  "B",
+	model.PtkpK3:  "C",
+}
+
+// Monthly TER tables, upper bound of the monthly gross and its effective rate
+var terTables = map[string][]taxBracket{
+	"A": {
+		{5400000, 0}, {5650000, 0.0025}, {5950000, 0.005}, {6300000, 0.0075},
+		{6750000, 0.01}, {7500000, 0.0125}, {8550000, 0.015}, {9650000, 0.0175},
+		{10050000, 0.02}, {10350000, 0.0225}, {10700000, 0.025}, {11050000, 0.03},
+		{11600000, 0.035}, {12500000, 0.04}, {13750000, 0.05}, {15100000, 0.06},
+		{16950000, 0.07}, {19750000, 0.08}, {24150000, 0.09}, {26450000, 0.10},
+		{28000000, 0.11}, {30050000, 0.12}, {32400000, 0.13}, {35400000, 0.14},
+		{39100000, 0.15}, {43850000, 0.16}, {47800000, 0.17}, {51400000, 0.18},
+		{56300000, 0.19}, {62200000, 0.20}, {68600000, 0.21}, {77500000, 0.22},
+		{89000000, 0.23}, {103000000, 0.24}, {125000000, 0.25}, {157000000, 0.26},
+		{206000000, 0.27}, {337000000, 0.28}, {454000000, 0.29}, {550000000, 0.30},
+		{695000000, 0.31}, {910000000, 0.32}, {1400000000, 0.33}, {math.MaxInt64, 0.34},
+	},
+	"B": {
+		{6200000, 0}, {6500000, 0.0025}, {6850000, 0.005}, {7300000, 0.0075},
+		{9200000, 0.01}, {10750000, 0.015}, {11250000, 0.02}, {11600000, 0.025},
+		{12600000, 0.03}, {13600000, 0.04}, {14950000, 0.05}, {16400000, 0.06},
+		{18450000, 0.07}, {21850000, 0.08}, {26000000, 0.09}, {27700000, 0.10},
+		{29350000, 0.11}, {31450000, 0.12}, {33950000, 0.13}, {37100000, 0.14},
+		{41100000, 0.15}, {45800000, 0.16}, {49500000, 0.17}, {53800000, 0.18},
+		{58500000, 0.19}, {64000000, 0.20}, {71000000, 0.21}, {80000000, 0.22},
+		{93000000, 0.23}, {109000000, 0.24}, {129000000, 0.25}, {163000000, 0.26},
+		{211000000, 0.27}, {374000000, 0.28}, {459000000, 0.29}, {555000000, 0.30},
+		{704000000, 0.31}, {957000000, 0.32}, {1405000000, 0.33}, {math.MaxInt64, 0.34},
+	},
+	"C": {
+		{6600000, 0}, {6950000, 0.0025}, {7350000, 0.005}, {7800000, 0.0075},
+		{8850000, 0.01}, {9800000, 0.0125}, {10950000, 0.015}, {11200000, 0.0175},
+		{12050000, 0.02}, {12950000, 0.03}, {14150000, 0.04}, {15550000, 0.05},
+		{17050000, 0.06}, {19500000, 0.07}, {22700000, 0.08}, {26600000, 0.09},
+		{28100000, 0.10}, {30100000, 0.11}, {32600000, 0.12}, {35400000, 0.13},
+		{38900000, 0.14}, {43000000, 0.15}, {47400000, 0.16}, {51200000, 0.17},
+		{55800000, 0.18}, {60400000, 0.19}, {66700000, 0.20}, {74500000, 0.21},
+		{83200000, 0.22}, {95600000, 0.23}, {110000000, 0.24}, {134000000, 0.25},
+		{169000000, 0.26}, {221000000, 0.27}, {390000000, 0.28}, {463000000, 0.29},
+		{561000000, 0.30}, {709000000, 0.31}, {965000000, 0.32}, {1419000000, 0.33},
+		{math.MaxInt64, 0.34},
+	},
+}
+
+type taxBracket struct {
+	upper int
+	rate  float64
+}
+
+// Pph21Service computes PPh 21 withholding, it does not touch the database
+type Pph21Service interface {
+	CalculateMonthly(in model.Pph21Input) (model.Pph21Breakdown, error)
+	CalculateIrregular(in model.Pph21Input) (model.Pph21Breakdown, error)
+	Ptkp(status string) (int, error)
+	AnnualTax(pkp int) int
+}
+
+type pph21Service struct{}
+
+func NewPph21Service() Pph21Service {
+	return &pph21Service{}
+}
+
+func (s *pph21Service) CalculateMonthly(in model.Pph21Input) (model.Pph21Breakdown, error) {
+	var (
+		breakdown model.Pph21Breakdown
+		err       error
+	)
+
+	if err = normalizePph21Input(&in); err != nil {
+		return breakdown, err
+	}
+
+	breakdown.Ptkp_status = in.Ptkp_status
+	breakdown.Method = in.Method
+	breakdown.Gross_income = in.Gross_income
+	breakdown.Employer_premiums = in.Employer_premiums
+	breakdown.Pension_deduction = in.Pension_deduction
+
+	// December settles the whole year with the Pasal 17 brackets
+	if in.Period.Month() == time.December {
+		breakdown.Scheme = model.TaxSchemeAnnual
+		taxFor := func(allowance int) int {
+			s.fillAnnual(&breakdown, in, allowance)
+			return breakdown.Tax
+		}
+		s.applyMethod(&breakdown, in.Method, taxFor)
+		return breakdown, err
+	}
+
+	breakdown.Scheme = model.TaxSchemeTer
+	breakdown.Ter_category = terCategories[in.Ptkp_status]
+	taxFor := func(allowance int) int {
+		breakdown.Taxable_income = in.Gross_income + in.Employer_premiums + allowance
+		breakdown.Ter_rate = bracketRate(terTables[breakdown.Ter_category], breakdown.Taxable_income)
+		breakdown.Tax = roundRupiah(float64(breakdown.Taxable_income) * breakdown.Ter_rate)
+		return breakdown.Tax
+	}
+	s.applyMethod(&breakdown, in.Method, taxFor)
+	return breakdown, err
+}
+
+// Tax on irregular income such as THR or bonus, paid apart from the regular
+// payroll. The tax is what the irregular income adds to the tax of the
+// month's regular income: on the TER rate of the combined income during the
+// year, on the annualized Pasal 17 brackets in December.
+func (s *pph21Service) CalculateIrregular(in model.Pph21Input) (model.Pph21Breakdown, error) {
+	var (
+		breakdown model.Pph21Breakdown
+		err       error
+	)
+
+	if err = normalizePph21Input(&in); err != nil {
+		return breakdown, err
+	}
+
+	breakdown.Ptkp_status = in.Ptkp_status
+	breakdown.Method = in.Method
+	breakdown.Scheme = model.TaxSchemeIrregular
+	breakdown.Gross_income = in.Gross_income
+	breakdown.Regular_income = in.Regular_income
+
+	if in.Period.Month() == time.December {
+		ptkp, _ := s.Ptkp(in.Ptkp_status)
+		months := in.Ytd_months + 1
+		annualTax := func(gross int) (int, int) {
+			biayaJabatan := min(roundRupiah(float64(gross)*biayaJabatanRate), biayaJabatanMonthlyCap*months)
+			net := gross - biayaJabatan - in.Ytd_pension_deduction - in.Pension_deduction
+			pkp := max((net-ptkp)/1000*1000, 0)
+			return s.AnnualTax(pkp), pkp
+		}
+		regularGross := in.Ytd_gross_income + in.Regular_income
+		regularTax, _ := annualTax(regularGross)
+		taxFor := func(allowance int) int {
+			breakdown.Taxable_income = in.Gross_income + allowance
+			breakdown.Annual_gross_income = regularGross + breakdown.Taxable_income
+			breakdown.Annual_tax, breakdown.Pkp = annualTax(breakdown.Annual_gross_income)
+			breakdown.Ptkp = ptkp
+			breakdown.Tax = breakdown.Annual_tax - regularTax
+			return breakdown.Tax
+		}
+		s.applyMethod(&breakdown, in.Method, taxFor)
+		return breakdown, err
+	}
+
+	breakdown.Ter_category = terCategories[in.Ptkp_status]
+	table := terTables[breakdown.Ter_category]
+	regularTax := roundRupiah(float64(in.Regular_income) * bracketRate(table, in.Regular_income))
+	taxFor := func(allowance int) int {
+		breakdown.Taxable_income = in.Gross_income + allowance
+		combined := in.Regular_income + breakdown.Taxable_income
+		breakdown.Ter_rate = bracketRate(table, combined)
+		breakdown.Tax = roundRupiah(float64(combined)*breakdown.Ter_rate) - regularTax
+		return breakdown.Tax
+	}
+	s.applyMethod(&breakdown, in.Method, taxFor)
+	return breakdown, err
+}
+
+func normalizePph21Input(in *model.Pph21Input) error {
+	if in.Ptkp_status == "" {
+		in.Ptkp_status = model.PtkpTK0
+	}
+	if in.Method == "" {
+		in.Method = model.TaxMethodGross
+	}
+	if err := ValidatePtkpStatus(in.Ptkp_status); err != nil {
+		return err
+	}
+	if in.Method != model.TaxMethodGross && in.Method != model.TaxMethodGrossUp && in.Method != model.TaxMethodNet {
+		return errors.New("unknown tax method " + in.Method)
+	}
+	return nil
+}
+
+// Splits the tax between employee and employer according to the method.
+// For gross-up the allowance is iterated until it equals the tax it produces.
+func (s *pph21Service) applyMethod(breakdown *model.Pph21Breakdown, method string, taxFor func(allowance int) int) {
+	switch method {
+	case model.TaxMethodGrossUp:
+		allowance := 0
+		for i := 0; i < grossUpIterations; i++ {
+			tax := max(taxFor(allowance), 0)
+			if tax == allowance {
+				break
+			}
+			allowance = tax
+		}
+		taxFor(allowance)
+		breakdown.Tax_allowance = allowance
+		breakdown.Employee_tax = breakdown.Tax
+	case model.TaxMethodNet:
+		taxFor(0)
+		breakdown.Employer_tax = breakdown.Tax
+	default:
+		taxFor(0)
+		breakdown.Employee_tax = breakdown.Tax
+	}
+}
+
+func (s *pph21Service) fillAnnual(breakdown *model.Pph21Breakdown, in model.Pph21Input, allowance int) {
+	months := in.Ytd_months + 1
+	breakdown.Taxable_income = in.Gross_income + in.Employer_premiums + allowance
+	breakdown.Annual_gross_income = in.Ytd_gross_income + breakdown.Taxable_income
+	breakdown.Annual_biaya_jabatan = min(
+		roundRupiah(float64(breakdown.Annual_gross_income)*biayaJabatanRate),
+		biayaJabatanMonthlyCap*months,
+	)
+	breakdown.Annual_pension_deduction = in.Ytd_pension_deduction + in.Pension_deduction
+	breakdown.Annual_net_income = breakdown.Annual_gross_income - breakdown.Annual_biaya_jabatan - breakdown.Annual_pension_deduction
+	breakdown.Ptkp, _ = s.Ptkp(in.Ptkp_status)
+	breakdown.Pkp = max((breakdown.Annual_net_income-breakdown.Ptkp)/1000*1000, 0)
+	breakdown.Annual_tax = s.AnnualTax(breakdown.Pkp)
+	breakdown.Withheld_before = in.Ytd_tax
+	breakdown.Tax = breakdown.Annual_tax - in.Ytd_tax
+}
+
+// Ptkp returns the yearly PTKP amount of a status such as K/2
+func (s *pph21Service) Ptkp(status string) (int, error) {
+	if err := ValidatePtkpStatus(status); err != nil {
+		return 0, err
+	}
+	ptkp := ptkpBase
+	if status[0] == 'K' {
+		ptkp += ptkpMarried
+	}
+	dependents := int(status[len(status)-1] - '0')
+	return ptkp + dependents*ptkpDependent, nil
+}
+
+// AnnualTax applies the progressive Pasal 17 brackets to a yearly PKP
+func (s *pph21Service) AnnualTax(pkp int) int {
+	var (
+		tax   float64
+		lower int
+	)
+	for _, b := range pasal17Brackets {
+		if pkp <= lower {
+			break
+		}
+		tax += float64(min(pkp, b.upper)-lower) * b.rate
+		lower = b.upper
+	}
+	return roundRupiah(tax)
+}
+
+// ValidatePtkpStatus rejects statuses outside TK/0 through K/3
+func ValidatePtkpStatus(status string) error {
+	if _, ok := terCategories[status]; !ok {
+		return errors.New("unknown ptkp status " + status)
+	}
+	return nil
+}
+
+func bracketRate(table []taxBracket, amount int) float64 {
+	for _, b := range table {
+		if amount <= b.upper {
+			return b.rate
+		}
+	}
+	return table[len(table)-1].rate
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestPph21MonthlyTer(t *testing.T) {
+	march := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		ptkp     string
+		gross    int
+		premiums int
+		category string
+		rate     float64
+		tax      int
+	}{
+		{"A below the first bracket", model.PtkpTK0, 5400000, 0, "A", 0, 0},
+		{"A just over the first bracket", model.PtkpTK0, 5400001, 0, "A", 0.0025, 13500},
+		{"A on the 2% bracket", model.PtkpTK1, 10000000, 0, "A", 0.02, 200000},
+		{"A counts the employer premiums", model.PtkpK0, 9500000, 200000, "A", 0.02, 194000},
+		{"B below the first bracket", model.PtkpTK2, 6200000, 0, "B", 0, 0},
+		{"B on the 1.5% bracket", model.PtkpK1, 10000000, 0, "B", 0.015, 150000},
+		{"B on the 8% bracket", model.PtkpK2, 20000000, 0, "B", 0.08, 1600000},
+		{"C below the first bracket", model.PtkpK3, 6600000, 0, "C", 0, 0},
+		{"C on the 1.5% bracket", model.PtkpK3, 10000000, 0, "C", 0.015, 150000},
+		{"C on the 8% bracket", model.PtkpK3, 20000000, 0, "C", 0.08, 1600000},
+		{"C on the last bracket", model.PtkpK3, 2000000000, 0, "C", 0.34, 680000000},
+	}
+
+	s := NewPph21Service()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.CalculateMonthly(model.Pph21Input{
+				Ptkp_status:       tt.ptkp,
+				Method:            model.TaxMethodGross,
+				Period:            march,
+				Gross_income:      tt.gross,
+				Employer_premiums: tt.premiums,
+			})
+			if err != nil {
+				t.Fatalf("CalculateMonthly() error = %v", err)
+			}
+			if got.Scheme != model.TaxSchemeTer {
+				t.Errorf("Scheme = %q, want %q", got.Scheme, model.TaxSchemeTer)
+			}
+			if got.Ter_category != tt.category {
+				t.Errorf("Ter_category = %q, want %q", got.Ter_category, tt.category)
+			}
+			if got.Ter_rate != tt.rate {
+				t.Errorf("Ter_rate = %v, want %v", got.Ter_rate, tt.rate)
+			}
+			if got.Taxable_income != tt.gross+tt.premiums {
+				t.Errorf("Taxable_income = %d, want %d", got.Taxable_income, tt.gross+tt.premiums)
+			}
+			if got.Tax != tt.tax || got.Employee_tax != tt.tax {
+				t.Errorf("Tax = %d, Employee_tax = %d, want %d", got.Tax, got.Employee_tax, tt.tax)
+			}
+		})
+	}
+}
+
+func TestPph21DecemberTrueUp(t *testing.T) {
+	december := time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		in        model.Pph21Input
+		pkp       int
+		annualTax int
+		tax       int
+	}{
+		{
+			// 120.000.000 - 6.000.000 biaya jabatan - 54.000.000 PTKP
+			name: "settles the first bracket",
+			in: model.Pph21Input{
+				Ptkp_status: model.PtkpTK0, Gross_income: 10000000,
+				Ytd_gross_income: 110000000, Ytd_tax: 2200000, Ytd_months: 11,
+			},
+			pkp:       60000000,
+			annualTax: 3000000,
+			tax:       800000,
+		},
+		{
+			// 240.000.000 - 6.000.000 capped biaya jabatan - 2.400.000
+			// pension - 63.000.000 PTKP, spread over the 5% and 15% brackets
+			name: "caps biaya jabatan and deducts the pension",
+			in: model.Pph21Input{
+				Ptkp_status: model.PtkpK1, Gross_income: 20000000, Pension_deduction: 200000,
+				Ytd_gross_income: 220000000, Ytd_pension_deduction: 2200000, Ytd_tax: 17600000, Ytd_months: 11,
+			},
+			pkp:       168600000,
+			annualTax: 19290000,
+			tax:       1690000,
+		},
+		{
+			name: "refunds an overpayment",
+			in: model.Pph21Input{
+				Ptkp_status: model.PtkpTK0, Gross_income: 10000000,
+				Ytd_gross_income: 110000000, Ytd_tax: 3300000, Ytd_months: 11,
+			},
+			pkp:       60000000,
+			annualTax: 3000000,
+			tax:       -300000,
+		},
+		{
+			// Joined in October, biaya jabatan is capped for 3 months
+			name: "caps biaya jabatan by the months worked",
+			in: model.Pph21Input{
+				Ptkp_status: model.PtkpTK0, Gross_income: 30000000,
+				Ytd_gross_income: 60000000, Ytd_tax: 0, Ytd_months: 2,
+			},
+			pkp:       34500000,
+			annualTax: 1725000,
+			tax:       1725000,
+		},
+	}
+
+	s := NewPph21Service()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.Method = model.TaxMethodGross
+			tt.in.Period = december
+			got, err := s.CalculateMonthly(tt.in)
+			if err != nil {
+				t.Fatalf("CalculateMonthly() error = %v", err)
+			}
+			if got.Scheme != model.TaxSchemeAnnual {
+				t.Errorf("Scheme = %q, want %q", got.Scheme, model.TaxSchemeAnnual)
+			}
+			if got.Pkp != tt.pkp {
+				t.Errorf("Pkp = %d, want %d", got.Pkp, tt.pkp)
+			}
+			if got.Annual_tax != tt.annualTax {
+				t.Errorf("Annual_tax = %d, want %d", got.Annual_tax, tt.annualTax)
+			}
+			if got.Withheld_before != tt.in.Ytd_tax {
+				t.Errorf("Withheld_before = %d, want %d", got.Withheld_before, tt.in.Ytd_tax)
+			}
+			if got.Tax != tt.tax {
+				t.Errorf("Tax = %d, want %d", got.Tax, tt.tax)
+			}
+		})
+	}
+}
+
+func TestPph21GrossUpConverges(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        model.Pph21Input
+		allowance int
+	}{
+		{
+			name: "ter",
+			in: model.Pph21Input{
+				Ptkp_status: model.PtkpTK0, Gross_income: 10000000,
+				Period: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			},
+			allowance: 230179,
+		},
+		{
+			name: "ter crossing into the next bracket",
+			in: model.Pph21Input{
+				Ptkp_status: model.PtkpK3, Gross_income: 22500000,
+				Period: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "december true-up",
+			in: model.Pph21Input{
+				Ptkp_status: model.PtkpK1, Gross_income: 20000000, Pension_deduction: 200000,
+				Ytd_gross_income: 220000000, Ytd_pension_deduction: 2200000, Ytd_tax: 17600000, Ytd_months: 11,
+				Period: time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	s := NewPph21Service()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.Method = model.TaxMethodGrossUp
+			got, err := s.CalculateMonthly(tt.in)
+			if err != nil {
+				t.Fatalf("CalculateMonthly() error = %v", err)
+			}
+			// The allowance pays exactly the tax it gives rise to
+			if got.Tax_allowance != got.Tax || got.Tax <= 0 {
+				t.Errorf("Tax_allowance = %d, Tax = %d, want them equal and positive", got.Tax_allowance, got.Tax)
+			}
+			if got.Taxable_income != tt.in.Gross_income+got.Tax_allowance {
+				t.Errorf("Taxable_income = %d, want gross plus allowance %d", got.Taxable_income, tt.in.Gross_income+got.Tax_allowance)
+			}
+			if got.Employee_tax != got.Tax || got.Employer_tax != 0 {
+				t.Errorf("Employee_tax = %d, Employer_tax = %d, want %d and 0", got.Employee_tax, got.Employer_tax, got.Tax)
+			}
+			if tt.allowance != 0 && got.Tax_allowance != tt.allowance {
+				t.Errorf("Tax_allowance = %d, want %d", got.Tax_allowance, tt.allowance)
+			}
+		})
+	}
+}
+
+func TestPph21NetMethod(t *testing.T) {
+	s := NewPph21Service()
+	got, err := s.CalculateMonthly(model.Pph21Input{
+		Ptkp_status:  model.PtkpTK0,
+		Method:       model.TaxMethodNet,
+		Period:       time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Gross_income: 10000000,
+	})
+	if err != nil {
+		t.Fatalf("CalculateMonthly() error = %v", err)
+	}
+	if got.Tax != 200000 || got.Employer_tax != 200000 || got.Employee_tax != 0 || got.Tax_allowance != 0 {
+		t.Errorf("Tax = %d, Employer_tax = %d, Employee_tax = %d, Tax_allowance = %d, want the employer to bear 200000",
+			got.Tax, got.Employer_tax, got.Employee_tax, got.Tax_allowance)
+	}
+}
+
+func TestPph21Ptkp(t *testing.T) {
+	tests := []struct {
+		status  string
+		ptkp    int
+		wantErr bool
+	}{
+		{model.PtkpTK0, 54000000, false},
+		{model.PtkpTK3, 67500000, false},
+		{model.PtkpK0, 58500000, false},
+		{model.PtkpK3, 72000000, false},
+		{"K/4", 0, true},
+		{"", 0, true},
+	}
+
+	s := NewPph21Service()
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			got, err := s.Ptkp(tt.status)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Ptkp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.ptkp {
+				t.Errorf("Ptkp() = %d, want %d", got, tt.ptkp)
+			}
+		})
+	}
+}
+
+func TestPph21AnnualTax(t *testing.T) {
+	tests := []struct {
+		pkp int
+		tax int
+	}{
+		{0, 0},
+		{60000000, 3000000},
+		{250000000, 31500000},
+		{500000000, 94000000},
+		{5000000000, 1444000000},
+		{6000000000, 1794000000},
+	}
+
+	s := NewPph21Service()
+	for _, tt := range tests {
+		if got := s.AnnualTax(tt.pkp); got != tt.tax {
+			t.Errorf("AnnualTax(%d) = %d, want %d", tt.pkp, got, tt.tax)
+		}
+	}
+}
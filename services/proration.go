@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/google/uuid"
+)
+
+// Works out which share of a monthly salary is earned in period, only the
+// days between join and termination count
+func prorate(method string, period time.Time, join *time.Time, termination *time.Time) (model.Proration, time.Time, time.Time, error) {
+	proration := model.Proration{Method: method}
+	if method != model.ProrationCalendarDay && method != model.ProrationWorkingDay {
+		return proration, time.Time{}, time.Time{}, errors.New("proration method must be calendar or working")
+	}
+
+	start := time.Date(period.Year(), period.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+	from, to := start, end
+	if join != nil && truncateDay(*join).After(from) {
+		from = truncateDay(*join)
+	}
+	if termination != nil && truncateDay(*termination).Before(to) {
+		to = truncateDay(*termination)
+	}
+	if from.After(to) {
+		return proration, from, to, errors.New("user is not employed in " + period.Format(PaymentPeriodLayout))
+	}
+
+	proration.Period_days = countDays(method, start, end)
+	proration.Employed_days = countDays(method, from, to)
+	proration.Paid_days = proration.Employed_days
+	proration.Factor = math.Round(float64(proration.Employed_days)/float64(proration.Period_days)*10000) / 10000
+	return proration, from, to, nil
+}
+
+// Amount earned for the employed days of a proration
+func prorateAmount(amount int, proration model.Proration) int {
+	if proration.Period_days == 0 || proration.Employed_days == proration.Period_days {
+		return amount
+	}
+	return roundRupiah(float64(amount) * float64(proration.Employed_days) / float64(proration.Period_days))
+}
+
+// Monthly amount behind an item prorated with proration, the inverse of
+// prorateAmount
+func unprorateAmount(amount int, proration model.Proration) int {
+	if proration.Employed_days == 0 || proration.Employed_days == proration.Period_days {
+		return amount
+	}
+	return roundRupiah(float64(amount) * float64(proration.Period_days) / float64(proration.Employed_days))
+}
+
+// A deduction caused by a single leave request
+type leaveDeduction struct {
+	request_id uuid.UUID
+	days       int
+	note       string
+}
+
+// Days of leave to deduct in the employment window from..to. Unpaid leave
+// is deducted in full, quota limited leave only for the days taken after the
+// yearly quota ran out. leaves must cover the year up to the end of the window.
+func leaveDeductions(method string, from time.Time, to time.Time, leaves []model.UnpaidLeave) []leaveDeduction {
+	sort.SliceStable(leaves, func(i, j int) bool { return leaves[i].From_date.Before(leaves[j].From_date) })
+
+	var (
+		deductions = make([]leaveDeduction, 0)
+		used       = make(map[int]int)
+		deducted   = make(map[time.Time]bool)
+		yearStart  = time.Date(from.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	)
+	for _, leave := range leaves {
+		days := 0
+		start := maxTime(truncateDay(leave.From_date), yearStart)
+		for day := start; !day.After(truncateDay(leave.To_date)) && !day.After(to); day = day.AddDate(0, 0, 1) {
+			if !countsAsDay(method, day) {
+				continue
+			}
+			deduct := !leave.Is_paid
+			if leave.Is_paid && leave.Quota != nil {
+				used[leave.Leave_id]++
+				deduct = used[leave.Leave_id] > *leave.Quota
+			}
+			if deduct && !day.Before(from) && !deducted[day] {
+				deducted[day] = true
+				days++
+			}
+		}
+		if days == 0 {
+			continue
+		}
+
+		note := fmt.Sprintf("%s %s - %s, %d days", leave.Leave_name, leave.From_date.Format("02 Jan"), leave.To_date.Format("02 Jan"), days)
+		if leave.Is_paid {
+			note = fmt.Sprintf("%s over quota of %d days, %s - %s, %d days", leave.Leave_name, *leave.Quota, leave.From_date.Format("02 Jan"), leave.To_date.Format("02 Jan"), days)
+		}
+		deductions = append(deductions, leaveDeduction{request_id: leave.Request_id, days: days, note: note})
+	}
+	return deductions
+}
+
+func countDays(method string, from time.Time, to time.Time) int {
+	days := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if countsAsDay(method, day) {
+			days++
+		}
+	}
+	return days
+}
+
+func countsAsDay(method string, day time.Time) bool {
+	if method == model.ProrationWorkingDay {
+		return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+	}
+	return true
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
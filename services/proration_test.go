@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestProrate(t *testing.T) {
+	// February 2024 has 29 days, 21 of them Monday to Friday
+	february := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	date := func(day int) *time.Time {
+		d := time.Date(2024, time.February, day, 9, 30, 0, 0, time.UTC)
+		return &d
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		join        *time.Time
+		termination *time.Time
+		periodDays  int
+		days        int
+		factor      float64
+		wantErr     bool
+	}{
+		{"calendar full month", model.ProrationCalendarDay, nil, nil, 29, 29, 1, false},
+		{"calendar joined mid month", model.ProrationCalendarDay, date(15), nil, 29, 15, 0.5172, false},
+		{"calendar terminated mid month", model.ProrationCalendarDay, nil, date(10), 29, 10, 0.3448, false},
+		{"calendar joined before the month", model.ProrationCalendarDay, &time.Time{}, nil, 29, 29, 1, false},
+		{"working full month", model.ProrationWorkingDay, nil, nil, 21, 21, 1, false},
+		{"working joined mid month", model.ProrationWorkingDay, date(15), nil, 21, 11, 0.5238, false},
+		{"working joined on a weekend", model.ProrationWorkingDay, date(17), nil, 21, 9, 0.4286, false},
+		{"working one week", model.ProrationWorkingDay, date(5), date(11), 21, 5, 0.2381, false},
+		{"not employed yet", model.ProrationCalendarDay, &time.Time{}, &time.Time{}, 0, 0, 0, true},
+		{"unknown method", "hourly", nil, nil, 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, _, err := prorate(tt.method, february, tt.join, tt.termination)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prorate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Method != tt.method || got.Period_days != tt.periodDays || got.Employed_days != tt.days || got.Paid_days != tt.days {
+				t.Errorf("prorate() = %+v, want %d of %d %s days", got, tt.days, tt.periodDays, tt.method)
+			}
+			if got.Factor != tt.factor {
+				t.Errorf("Factor = %v, want %v", got.Factor, tt.factor)
+			}
+		})
+	}
+}
+
+func TestProrateAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int
+		proration model.Proration
+		prorated  int
+	}{
+		{"full month", 5800000, model.Proration{Period_days: 29, Employed_days: 29}, 5800000},
+		{"half a calendar month", 5800000, model.Proration{Period_days: 29, Employed_days: 15}, 3000000},
+		{"part of the working days", 6300000, model.Proration{Period_days: 21, Employed_days: 11}, 3300000},
+		{"rounded to the rupiah", 1000000, model.Proration{Period_days: 30, Employed_days: 7}, 233333},
+		{"no proration", 1000000, model.Proration{}, 1000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prorateAmount(tt.amount, tt.proration)
+			if got != tt.prorated {
+				t.Fatalf("prorateAmount() = %d, want %d", got, tt.prorated)
+			}
+			// Unprorating gives the monthly amount back, up to the rounding
+			monthly := unprorateAmount(got, tt.proration)
+			if diff := monthly - tt.amount; diff < -5 || diff > 5 {
+				t.Errorf("unprorateAmount() = %d, want about %d", monthly, tt.amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+)
+
+// An exported report
+type ReportFile struct {
+	Filename string
+	Content  []byte
+}
+
+type ReportService interface {
+	GetPayrollCostSummary(ctx context.Context, periodFrom string, periodTo string, groupBy string) (model.PayrollCostSummary, error)
+	ExportPayrollCostSummary(ctx context.Context, periodFrom string, periodTo string, groupBy string) (ReportFile, error)
+	GetPayrollVariance(ctx context.Context, period string, compareTo string, threshold string) (model.PayrollVarianceReport, error)
+}
+
+type reportService struct {
+	reportRepo        repository.ReportRepo
+	varianceThreshold float64
+	timeoutContext    time.Duration
+}
+
+func NewReportService(reportRepo repository.ReportRepo, varianceThreshold float64, timeoutContext time.Duration) ReportService {
+	return &reportService{
+		reportRepo:        reportRepo,
+		varianceThreshold: varianceThreshold,
+		timeoutContext:    timeoutContext,
+	}
+}
+
+// Payroll cost of the approved, paid and closed records of a period or a
+// range of periods. groupBy is a comma separated list of position and role,
+// position,role when empty and none for the period totals only. periodTo
+// defaults to periodFrom.
+func (s *reportService) GetPayrollCostSummary(ctx context.Context, periodFrom string, periodTo string, groupBy string) (model.PayrollCostSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		summary model.PayrollCostSummary
+		err     error
+	)
+	summary.Rows = make([]model.PayrollCostRow, 0)
+
+	if periodTo == "" {
+		periodTo = periodFrom
+	}
+	if err = validatePeriodRange(periodFrom, periodTo); err != nil {
+		return summary, err
+	}
+	summary.Period_from = periodFrom
+	summary.Period_to = periodTo
+
+	summary.Group_by, err = payrollCostGroupBy(groupBy)
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Rows, err = s.reportRepo.GetPayrollCostList(ctx, periodFrom, periodTo, summary.Group_by)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollCostSummary", err)
+		return summary, err
+	}
+	summary.Total.Headcount, err = s.reportRepo.GetPayrollHeadcount(ctx, periodFrom, periodTo)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollCostSummary get headcount", err)
+		return summary, err
+	}
+
+	for _, row := range summary.Rows {
+		summary.Total.Record_count += row.Record_count
+		summary.Total.Gross_pay += row.Gross_pay
+		summary.Total.Employee_bpjs += row.Employee_bpjs
+		summary.Total.Employer_bpjs += row.Employer_bpjs
+		summary.Total.Tax_withheld += row.Tax_withheld
+		summary.Total.Employer_tax += row.Employer_tax
+		summary.Total.Net_pay += row.Net_pay
+		summary.Total.Total_cost += row.Total_cost
+	}
+	return summary, err
+}
+
+// The cost summary as CSV, one line per row and a closing total line
+func (s *reportService) ExportPayrollCostSummary(ctx context.Context, periodFrom string, periodTo string, groupBy string) (ReportFile, error) {
+	var file ReportFile
+
+	summary, err := s.GetPayrollCostSummary(ctx, periodFrom, periodTo, groupBy)
+	if err != nil {
+		return file, err
+	}
+
+	records := [][]string{{
+		"payment_period", "position", "role", "headcount", "record_count", "gross_pay", "employee_bpjs",
+		"employer_bpjs", "tax_withheld", "employer_tax", "net_pay", "total_cost",
+	}}
+	for _, row := range append(summary.Rows, summary.Total) {
+		records = append(records, []string{
+			row.Payment_period, row.Position, row.Role,
+			strconv.Itoa(row.Headcount), strconv.Itoa(row.Record_count), strconv.Itoa(row.Gross_pay), strconv.Itoa(row.Employee_bpjs),
+			strconv.Itoa(row.Employer_bpjs), strconv.Itoa(row.Tax_withheld), strconv.Itoa(row.Employer_tax), strconv.Itoa(row.Net_pay), strconv.Itoa(row.Total_cost),
+		})
+	}
+	records[len(records)-1][0] = "TOTAL"
+
+	file.Content, err = csvBytes(records, ',')
+	if err != nil {
+		utils.LogError("Services", "ExportPayrollCostSummary write csv", err)
+		return file, err
+	}
+	file.Filename = "payroll-cost-" + summary.Period_from
+	if summary.Period_to != summary.Period_from {
+		file.Filename += "-to-" + summary.Period_to
+	}
+	file.Filename += ".csv"
+	return file, err
+}
+
+// Compares the regular payroll of a period with an earlier one, the previous
+// month unless compareTo is set. Net pay moving by more than threshold
+// percent, the configured threshold when empty, is flagged.
+func (s *reportService) GetPayrollVariance(ctx context.Context, period string, compareTo string, threshold string) (model.PayrollVarianceReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		report model.PayrollVarianceReport
+		err    error
+	)
+	report.Employees = make([]model.PayrollVarianceEmployee, 0)
+
+	month, err := time.Parse(PaymentPeriodLayout, period)
+	if err != nil {
+		return report, errors.New("period must be formatted as YYYY-MM")
+	}
+	if compareTo == "" {
+		compareTo = month.AddDate(0, -1, 0).Format(PaymentPeriodLayout)
+	}
+	if err = validatePeriodRange(compareTo, period); err != nil {
+		return report, errors.New("compare_to must be a period before " + period + " formatted as YYYY-MM")
+	}
+	report.Period = period
+	report.Compare_to = compareTo
+
+	report.Threshold_percent = s.varianceThreshold
+	if threshold != "" {
+		report.Threshold_percent, err = strconv.ParseFloat(threshold, 64)
+		if err != nil || report.Threshold_percent < 0 {
+			return report, errors.New("threshold must be a percentage of zero or more")
+		}
+	}
+
+	current, err := s.reportRepo.GetPayrollPeriodUserList(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollVariance get users", err)
+		return report, err
+	}
+	previous, err := s.reportRepo.GetPayrollPeriodUserList(ctx, compareTo)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollVariance get previous users", err)
+		return report, err
+	}
+	currentItems, err := s.reportRepo.GetPayrollPeriodItemList(ctx, period)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollVariance get items", err)
+		return report, err
+	}
+	previousItems, err := s.reportRepo.GetPayrollPeriodItemList(ctx, compareTo)
+	if err != nil {
+		utils.LogError("Services", "GetPayrollVariance get previous items", err)
+		return report, err
+	}
+
+	report.Summary.Headcount = len(current)
+	report.Summary.Previous_headcount = len(previous)
+	report.Employees, report.Summary = payrollVariance(period, current, previous, currentItems, previousItems, report.Threshold_percent, report.Summary)
+	return report, err
+}
+
+func validatePeriodRange(periodFrom string, periodTo string) error {
+	if _, err := time.Parse(PaymentPeriodLayout, periodFrom); err != nil {
+		return errors.New("period_from must be formatted as YYYY-MM")
+	}
+	if _, err := time.Parse(PaymentPeriodLayout, periodTo); err != nil {
+		return errors.New("period_to must be formatted as YYYY-MM")
+	}
+	if periodTo < periodFrom {
+		return errors.New("period_to must not be before period_from")
+	}
+	return nil
+}
+
+func payrollCostGroupBy(groupBy string) ([]string, error) {
+	switch groupBy {
+	case "":
+		return []string{"position", "role"}, nil
+	case "none":
+		return make([]string, 0), nil
+	}
+	groups := make([]string, 0, 2)
+	for _, group := range strings.Split(groupBy, ",") {
+		group = strings.TrimSpace(group)
+		if group != "position" && group != "role" {
+			return groups, errors.New("group_by must be position, role, position,role or none")
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// Matches the users and components of both periods. An employee leaving
+// within the period is flagged terminated along with those no longer paid.
+// Employees without any difference are only counted.
+func payrollVariance(period string, current, previous []model.PayrollPeriodUserTotal, currentItems, previousItems []model.PayrollPeriodItemTotal, threshold float64, summary model.PayrollVarianceSummary) ([]model.PayrollVarianceEmployee, model.PayrollVarianceSummary) {
+	employees := make([]model.PayrollVarianceEmployee, 0)
+
+	type pair struct {
+		current, previous *model.PayrollPeriodUserTotal
+	}
+	users := make(map[uuid.UUID]*pair, len(current))
+	order := make([]uuid.UUID, 0, len(current)+len(previous))
+	get := func(id uuid.UUID) *pair {
+		if _, ok := users[id]; !ok {
+			users[id] = &pair{}
+			order = append(order, id)
+		}
+		return users[id]
+	}
+	for i := range current {
+		get(current[i].User_id).current = &current[i]
+	}
+	for i := range previous {
+		get(previous[i].User_id).previous = &previous[i]
+	}
+
+	components := make(map[uuid.UUID][]model.PayrollVarianceComponent)
+	index := make(map[uuid.UUID]map[string]int)
+	addItem := func(item model.PayrollPeriodItemTotal, current bool) {
+		if index[item.User_id] == nil {
+			index[item.User_id] = make(map[string]int)
+		}
+		i, ok := index[item.User_id][item.Component_code]
+		if !ok {
+			i = len(components[item.User_id])
+			index[item.User_id][item.Component_code] = i
+			components[item.User_id] = append(components[item.User_id], model.PayrollVarianceComponent{
+				Component_code: item.Component_code,
+				Name:           item.Name,
+				Component_type: item.Component_type,
+			})
+		}
+		if current {
+			components[item.User_id][i].Current += item.Amount
+		} else {
+			components[item.User_id][i].Previous += item.Amount
+		}
+	}
+	for _, item := range currentItems {
+		addItem(item, true)
+	}
+	for _, item := range previousItems {
+		addItem(item, false)
+	}
+
+	for _, id := range order {
+		p := users[id]
+		employee := model.PayrollVarianceEmployee{User_id: id, Flags: make([]string, 0), Components: make([]model.PayrollVarianceComponent, 0)}
+		switch {
+		case p.current == nil:
+			employee.Name, employee.Nik = p.previous.Name, p.previous.Nik
+			employee.Flags = append(employee.Flags, model.VarianceTerminated)
+			summary.Terminated++
+		case p.previous == nil:
+			employee.Name, employee.Nik = p.current.Name, p.current.Nik
+			employee.Flags = append(employee.Flags, model.VarianceNewEmployee)
+			summary.New_employees++
+		default:
+			employee.Name, employee.Nik = p.current.Name, p.current.Nik
+			if t := p.current.Termination_date; t != nil && t.Format(PaymentPeriodLayout) == period {
+				employee.Flags = append(employee.Flags, model.VarianceTerminated)
+				summary.Terminated++
+			}
+		}
+		if p.current != nil {
+			employee.Basic_salary = p.current.Basic_salary
+			employee.Net_pay = p.current.Net_pay
+			summary.Net_pay += p.current.Net_pay
+		}
+		if p.previous != nil {
+			employee.Previous_basic_salary = p.previous.Basic_salary
+			employee.Previous_net_pay = p.previous.Net_pay
+			summary.Previous_net_pay += p.previous.Net_pay
+		}
+		employee.Net_pay_difference = employee.Net_pay - employee.Previous_net_pay
+
+		if p.current != nil && p.previous != nil {
+			if employee.Basic_salary != employee.Previous_basic_salary {
+				employee.Flags = append(employee.Flags, model.VarianceSalary)
+				summary.Salary_changes++
+			}
+			if employee.Previous_net_pay != 0 {
+				change := math.Round(float64(employee.Net_pay_difference)/math.Abs(float64(employee.Previous_net_pay))*10000) / 100
+				employee.Net_pay_change = &change
+			}
+			if employee.Net_pay_difference != 0 && (employee.Net_pay_change == nil || math.Abs(*employee.Net_pay_change) > threshold) {
+				employee.Flags = append(employee.Flags, model.VarianceNetPay)
+				summary.Net_pay_anomalies++
+			}
+		}
+
+		for _, component := range components[id] {
+			component.Difference = component.Current - component.Previous
+			if component.Difference != 0 {
+				employee.Components = append(employee.Components, component)
+			}
+		}
+
+		if len(employee.Flags) == 0 && len(employee.Components) == 0 {
+			summary.Unchanged++
+			continue
+		}
+		employees = append(employees, employee)
+	}
+
+	// Anomalies first, by name within each group
+	sort.SliceStable(employees, func(i, j int) bool {
+		if (len(employees[i].Flags) > 0) != (len(employees[j].Flags) > 0) {
+			return len(employees[i].Flags) > 0
+		}
+		return employees[i].Name < employees[j].Name
+	})
+	return employees, summary
+}
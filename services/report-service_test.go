@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/google/uuid"
+)
+
+type fakeReportRepo struct {
+	repository.ReportRepo
+	rows      []model.PayrollCostRow
+	headcount int
+}
+
+func (f fakeReportRepo) GetPayrollCostList(ctx context.Context, periodFrom string, periodTo string, groupBy []string) ([]model.PayrollCostRow, error) {
+	return f.rows, nil
+}
+
+func (f fakeReportRepo) GetPayrollHeadcount(ctx context.Context, periodFrom string, periodTo string) (int, error) {
+	return f.headcount, nil
+}
+
+func TestPayrollCostGroupBy(t *testing.T) {
+	tests := []struct {
+		groupBy string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{"position", "role"}, false},
+		{"none", []string{}, false},
+		{"role", []string{"role"}, false},
+		{"role, position", []string{"role", "position"}, false},
+		{"department", nil, true},
+		{"position,", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			got, err := payrollCostGroupBy(tt.groupBy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("payrollCostGroupBy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("payrollCostGroupBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePeriodRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantErr  string
+	}{
+		{"one period", "2024-03", "2024-03", ""},
+		{"across a year", "2023-11", "2024-02", ""},
+		{"reversed", "2024-03", "2024-02", "period_to must not be before period_from"},
+		{"bad from", "2024-3", "2024-03", "period_from must be formatted as YYYY-MM"},
+		{"bad to", "2024-03", "2024-13", "period_to must be formatted as YYYY-MM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePeriodRange(tt.from, tt.to)
+			if (tt.wantErr == "") != (err == nil) || err != nil && err.Error() != tt.wantErr {
+				t.Errorf("validatePeriodRange() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportPayrollCostSummary(t *testing.T) {
+	repo := fakeReportRepo{
+		rows: []model.PayrollCostRow{
+			{Payment_period: "2024-02", Position: "Engineer", Role: "staff", Headcount: 2, Record_count: 2, Gross_pay: 30000000, Employee_bpjs: 1000000, Employer_bpjs: 2500000, Tax_withheld: 1500000, Net_pay: 27500000, Total_cost: 32500000},
+			// A reversal of a January record booked in March nets out
+			{Payment_period: "2024-03", Position: "Engineer", Role: "staff", Headcount: 2, Record_count: 3, Gross_pay: 25000000, Employee_bpjs: 800000, Employer_bpjs: 2000000, Tax_withheld: 1000000, Employer_tax: 200000, Net_pay: 23200000, Total_cost: 27200000},
+		},
+		headcount: 3,
+	}
+	s := NewReportService(repo, 10, time.Second)
+
+	tests := []struct {
+		name     string
+		from, to string
+		filename string
+	}{
+		{"one period", "2024-02", "", "payroll-cost-2024-02.csv"},
+		{"range", "2024-02", "2024-03", "payroll-cost-2024-02-to-2024-03.csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := s.ExportPayrollCostSummary(context.Background(), tt.from, tt.to, "")
+			if err != nil {
+				t.Fatalf("ExportPayrollCostSummary() error = %v", err)
+			}
+			if file.Filename != tt.filename {
+				t.Errorf("Filename = %q, want %q", file.Filename, tt.filename)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(file.Content), "\r\n"), "\r\n")
+			want := []string{
+				"payment_period,position,role,headcount,record_count,gross_pay,employee_bpjs,employer_bpjs,tax_withheld,employer_tax,net_pay,total_cost",
+				"2024-02,Engineer,staff,2,2,30000000,1000000,2500000,1500000,0,27500000,32500000",
+				"2024-03,Engineer,staff,2,3,25000000,800000,2000000,1000000,200000,23200000,27200000",
+				// Employees paid in both periods are counted once
+				"TOTAL,,,3,5,55000000,1800000,4500000,2500000,200000,50700000,59700000",
+			}
+			if !reflect.DeepEqual(lines, want) {
+				t.Errorf("ExportPayrollCostSummary() =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPayrollVariance(t *testing.T) {
+	type user struct {
+		name            string
+		previous, basic int
+		prevNet, net    int
+		terminated      bool
+		items, newItems map[string]int
+	}
+	users := []user{
+		{name: "Andi", previous: 10000000, basic: 10000000, prevNet: 9000000, net: 9000000, items: map[string]int{model.ComponentBasicSalary: 10000000}, newItems: map[string]int{model.ComponentBasicSalary: 10000000}},
+		{name: "Budi", previous: 10000000, basic: 12000000, prevNet: 9000000, net: 10800000, items: map[string]int{model.ComponentBasicSalary: 10000000}, newItems: map[string]int{model.ComponentBasicSalary: 12000000}},
+		{name: "Citra", basic: 5000000, net: 5000000, newItems: map[string]int{model.ComponentBasicSalary: 5000000}},
+		{name: "Dewi", previous: 6000000, prevNet: 6000000, items: map[string]int{model.ComponentBasicSalary: 6000000}},
+		// 5% more net pay stays under the threshold
+		{name: "Eko", previous: 8000000, basic: 8000000, prevNet: 7000000, net: 7350000, items: map[string]int{model.ComponentBasicSalary: 8000000}, newItems: map[string]int{model.ComponentBasicSalary: 8000000, model.ComponentOvertime: 350000}},
+		{name: "Fajar", previous: 8000000, basic: 8000000, prevNet: 8000000, net: 4000000, terminated: true, items: map[string]int{model.ComponentBasicSalary: 8000000}, newItems: map[string]int{model.ComponentBasicSalary: 4000000}},
+	}
+
+	var (
+		current, previous           []model.PayrollPeriodUserTotal
+		currentItems, previousItems []model.PayrollPeriodItemTotal
+	)
+	ids := make(map[string]uuid.UUID)
+	leaving := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+	for _, u := range users {
+		id := uuid.New()
+		ids[u.name] = id
+		if u.previous > 0 {
+			previous = append(previous, model.PayrollPeriodUserTotal{User_id: id, Name: u.name, Basic_salary: u.previous, Net_pay: u.prevNet})
+		}
+		if u.basic > 0 {
+			total := model.PayrollPeriodUserTotal{User_id: id, Name: u.name, Basic_salary: u.basic, Net_pay: u.net}
+			if u.terminated {
+				total.Termination_date = &leaving
+			}
+			current = append(current, total)
+		}
+		for code, amount := range u.items {
+			previousItems = append(previousItems, model.PayrollPeriodItemTotal{User_id: id, Component_code: code, Component_type: model.ComponentEarning, Amount: amount})
+		}
+		for code, amount := range u.newItems {
+			currentItems = append(currentItems, model.PayrollPeriodItemTotal{User_id: id, Component_code: code, Component_type: model.ComponentEarning, Amount: amount})
+		}
+	}
+
+	employees, summary := payrollVariance("2024-03", current, previous, currentItems, previousItems, 10, model.PayrollVarianceSummary{})
+
+	wantSummary := model.PayrollVarianceSummary{
+		New_employees: 1, Terminated: 2, Salary_changes: 1, Net_pay_anomalies: 2, Unchanged: 1,
+		Net_pay: 36150000, Previous_net_pay: 39000000,
+	}
+	if summary != wantSummary {
+		t.Errorf("summary = %+v, want %+v", summary, wantSummary)
+	}
+
+	// Flagged employees first, by name within each group
+	tests := []struct {
+		name       string
+		flags      []string
+		difference int
+		change     float64
+		components map[string]int
+	}{
+		{"Budi", []string{model.VarianceSalary, model.VarianceNetPay}, 1800000, 20, map[string]int{model.ComponentBasicSalary: 2000000}},
+		{"Citra", []string{model.VarianceNewEmployee}, 5000000, 0, map[string]int{model.ComponentBasicSalary: 5000000}},
+		{"Dewi", []string{model.VarianceTerminated}, -6000000, 0, map[string]int{model.ComponentBasicSalary: -6000000}},
+		{"Fajar", []string{model.VarianceTerminated, model.VarianceNetPay}, -4000000, -50, map[string]int{model.ComponentBasicSalary: -4000000}},
+		{"Eko", []string{}, 350000, 5, map[string]int{model.ComponentOvertime: 350000}},
+	}
+	if len(employees) != len(tests) {
+		t.Fatalf("payrollVariance() = %d employees, want %d", len(employees), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := employees[i]
+			if got.User_id != ids[tt.name] || got.Name != tt.name {
+				t.Fatalf("employee %d = %s, want %s", i, got.Name, tt.name)
+			}
+			if !reflect.DeepEqual(got.Flags, tt.flags) {
+				t.Errorf("Flags = %v, want %v", got.Flags, tt.flags)
+			}
+			if got.Net_pay_difference != tt.difference {
+				t.Errorf("Net_pay_difference = %d, want %d", got.Net_pay_difference, tt.difference)
+			}
+			// Only employees paid in both periods have a percentage
+			if (got.Net_pay_change != nil) != (tt.change != 0) || got.Net_pay_change != nil && *got.Net_pay_change != tt.change {
+				t.Errorf("Net_pay_change = %v, want %v", got.Net_pay_change, tt.change)
+			}
+			components := make(map[string]int)
+			for _, c := range got.Components {
+				components[c.Component_code] = c.Difference
+			}
+			if !reflect.DeepEqual(components, tt.components) {
+				t.Errorf("component differences = %v, want %v", components, tt.components)
+			}
+		})
+	}
+}
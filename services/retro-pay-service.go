@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type RetroPayService interface {
+	//Create
+	CreateRetroPay(ctx context.Context, r model.CreateRetroPayModel) (model.RetroPayResult, error)
+	//Read
+	GetRetroPayList(ctx context.Context, userId uuid.NullUUID, period string) ([]model.RetroPay, error)
+}
+
+type retroPayService struct {
+	retroPayRepo         repository.RetroPayRepo
+	payrollRecordRepo    repository.PayrollRecordRepo
+	payrollItemRepo      repository.PayrollItemRepo
+	payrollRunRepo       repository.PayrollRunRepo
+	payrollPeriodRepo    repository.PayrollPeriodRepo
+	payrollComponentRepo repository.PayrollComponentRepo
+	bpjsRepo             repository.BpjsRepo
+	payrollCalculation   PayrollCalculationService
+	timeoutContext       time.Duration
+	db                   *sqlx.DB
+}
+
+func NewRetroPayService(retroPayRepo repository.RetroPayRepo, payrollRecordRepo repository.PayrollRecordRepo, payrollItemRepo repository.PayrollItemRepo, payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, payrollComponentRepo repository.PayrollComponentRepo, bpjsRepo repository.BpjsRepo, calc PayrollCalculationService, timeoutContext time.Duration, db *sqlx.DB) RetroPayService {
+	return &retroPayService{
+		retroPayRepo:         retroPayRepo,
+		payrollRecordRepo:    payrollRecordRepo,
+		payrollItemRepo:      payrollItemRepo,
+		payrollRunRepo:       payrollRunRepo,
+		payrollPeriodRepo:    payrollPeriodRepo,
+		payrollComponentRepo: payrollComponentRepo,
+		bpjsRepo:             bpjsRepo,
+		payrollCalculation:   calc,
+		timeoutContext:       timeoutContext,
+		db:                   db,
+	}
+}
+
+// Rapel booked in a payment period, of a single user when userId is set
+func (s *retroPayService) GetRetroPayList(ctx context.Context, userId uuid.NullUUID, period string) ([]model.RetroPay, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	if _, err := time.Parse(PaymentPeriodLayout, period); err != nil {
+		return make([]model.RetroPay, 0), errors.New("period must be formatted as YYYY-MM")
+	}
+
+	list, err := s.retroPayRepo.GetRetroPayList(ctx, userId, period)
+	if err != nil {
+		utils.LogError("Services", "GetRetroPayList", err)
+		return list, err
+	}
+	return list, err
+}
+
+// Applies a back-dated compensation change. Every period since the
+// effective period that was already paid is recomputed with the
+// compensation now in force in it and the differences in salary, BPJS and
+// PPh 21 are booked as rapel in the current payroll run, whose record of the
+// user is recalculated as well.
+func (s *retroPayService) CreateRetroPay(ctx context.Context, r model.CreateRetroPayModel) (model.RetroPayResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.RetroPayResult
+		err    error
+	)
+	result.User_id = r.User_id
+	result.Retro_pays = make([]model.RetroPay, 0)
+
+	if r.User_id == uuid.Nil {
+		return result, errors.New("user_id is required")
+	}
+	effective, err := time.Parse(PaymentPeriodLayout, r.Effective_period)
+	if err != nil {
+		return result, errors.New("effective_period must be formatted as YYYY-MM")
+	}
+
+	run, err := s.currentRun(ctx, r.Effective_period)
+	if err != nil {
+		return result, err
+	}
+	result.Payment_period = run.Payment_period
+
+	current, err := s.payrollRecordRepo.GetPayrollRecordByPeriod(ctx, r.User_id, run.Payment_period, model.PayrollRunRegular)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, errors.New("user has no payroll record in the " + run.Payment_period + " run to book the rapel in")
+	}
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get current record", err)
+		return result, err
+	}
+
+	components, err := s.payrollComponentRepo.GetPayrollComponentList(ctx)
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get payroll components", err)
+		return result, err
+	}
+	byCode := componentsByCode(components)
+
+	for month := effective; month.Format(PaymentPeriodLayout) < run.Payment_period; month = month.AddDate(0, 1, 0) {
+		retro, ok, err := s.recompute(ctx, r, month.Format(PaymentPeriodLayout), byCode)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			retro.Payment_period = run.Payment_period
+			retro.Reason = r.Reason
+			result.Retro_pays = append(result.Retro_pays, retro)
+		}
+	}
+
+	items, err := s.payrollItemRepo.GetPayrollItemList(ctx, current.Payroll_id)
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get current items", err)
+		return result, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay open tx", err)
+		return result, err
+	}
+
+	for i := range result.Retro_pays {
+		result.Retro_pays[i].Retro_id, err = s.retroPayRepo.CreateRetroPay(ctx, tx, result.Retro_pays[i])
+		if err != nil {
+			utils.LogError("Services", "CreateRetroPay", err)
+			utils.CommitOrRollback(tx, "Services CreateRetroPay", err)
+			return result, err
+		}
+		result.Net_difference += result.Retro_pays[i].Net_difference
+	}
+
+	// The current month is paid at the new salary along with the rapel
+	calculation, err := s.payrollCalculation.Calculate(ctx, model.PayrollCalculationInput{
+		User_id:        r.User_id,
+		Payment_period: run.Payment_period,
+		Items:          retroInputs(items, current.Proration, byCode),
+		Tax_method:     current.Tax_method,
+		Retro_pays:     result.Retro_pays,
+	})
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay calculate current record", err)
+		utils.CommitOrRollback(tx, "Services CreateRetroPay", err)
+		return result, err
+	}
+	record := payrollRecordFromResult(calculation, current.Payment_date, current.Status_id)
+	result.Payroll_id, err = s.payrollRecordRepo.UpdatePayrollRecord(ctx, tx, current.Payroll_id, record)
+	if err == nil {
+		err = saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, current.Payroll_id, calculation)
+	}
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay update current record", err)
+		utils.CommitOrRollback(tx, "Services CreateRetroPay", err)
+		return result, err
+	}
+
+	utils.CommitOrRollback(tx, "Services CreateRetroPay", err)
+	return result, err
+}
+
+// Earliest open regular run from the effective period on in a period that
+// is not closed, the run the rapel is paid with
+func (s *retroPayService) currentRun(ctx context.Context, effectivePeriod string) (model.PayrollRun, error) {
+	runs, err := s.payrollRunRepo.GetPayrollRunList(ctx)
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get runs", err)
+		return model.PayrollRun{}, err
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Payment_period < runs[j].Payment_period })
+	for _, run := range runs {
+		if run.Run_type != model.PayrollRunRegular || IsPayrollRunLocked(run.Status) || run.Payment_period < effectivePeriod {
+			continue
+		}
+		closed, err := payrollPeriodClosed(ctx, s.payrollPeriodRepo, run.Payment_period)
+		if err != nil {
+			return model.PayrollRun{}, err
+		}
+		if !closed {
+			return run, nil
+		}
+	}
+	return model.PayrollRun{}, errors.New("no open payroll run to pay the rapel with, create the run of the current period first")
+}
+
+// Recomputes a period at the new salary. Periods the user was not paid in
+// are skipped, periods that are approved but not paid yet cannot be
+// recomputed until they are.
+func (s *retroPayService) recompute(ctx context.Context, r model.CreateRetroPayModel, period string, byCode map[string]model.PayrollComponent) (model.RetroPay, bool, error) {
+	var retro model.RetroPay
+
+	record, err := s.payrollRecordRepo.GetPayrollRecordByPeriod(ctx, r.User_id, period, model.PayrollRunRegular)
+	if errors.Is(err, sql.ErrNoRows) {
+		return retro, false, nil
+	}
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get record", err)
+		return retro, false, err
+	}
+
+	detail, err := s.payrollRecordRepo.GetPayrollRecordDetail(ctx, record.Payroll_id)
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get record status", err)
+		return retro, false, err
+	}
+	switch detail.Status_name {
+	case model.PayrollRunPaid, model.PayrollRunClosed:
+	case model.PayrollRunApproved:
+		return retro, false, errors.New("payroll of " + period + " is approved but not paid yet, pay it before computing the rapel")
+	default:
+		return retro, false, errors.New("payroll of " + period + " is still " + detail.Status_name + ", update it with the new salary instead")
+	}
+
+	// A corrected period paid what its latest adjustment says
+	record, err = s.latestAdjustment(ctx, record)
+	if err != nil {
+		return retro, false, err
+	}
+
+	items, err := s.payrollItemRepo.GetPayrollItemList(ctx, record.Payroll_id)
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get items", err)
+		return retro, false, err
+	}
+	bpjsItems, err := s.bpjsRepo.GetBpjsItemList(ctx, record.Payroll_id)
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get bpjs items", err)
+		return retro, false, err
+	}
+	previous, err := s.retroPayRepo.GetRetroPayListByEarnedPeriod(ctx, r.User_id, period)
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay get previous rapel", err)
+		return retro, false, err
+	}
+
+	calculation, err := s.payrollCalculation.Calculate(ctx, model.PayrollCalculationInput{
+		User_id:        r.User_id,
+		Payment_period: period,
+		Items:          retroInputs(items, record.Proration, byCode),
+		Tax_method:     record.Tax_method,
+	})
+	if err != nil {
+		utils.LogError("Services", "CreateRetroPay recalculate "+period, err)
+		return retro, false, err
+	}
+
+	record.Payment_period = period
+	retro = retroDifference(record, items, bpjsItems, calculation, previous)
+	changed := retro.Earning_difference != 0 || retro.Bpjs_employee_difference != 0 || retro.Bpjs_employer_difference != 0 || retro.Tax_difference != 0
+	return retro, changed, nil
+}
+
+func (s *retroPayService) latestAdjustment(ctx context.Context, record model.PayrollRecord) (model.PayrollRecord, error) {
+	for {
+		corrections, err := s.payrollRecordRepo.GetPayrollCorrectionList(ctx, record.Payroll_id)
+		if err != nil {
+			utils.LogError("Services", "CreateRetroPay get corrections", err)
+			return record, err
+		}
+		next := uuid.Nil
+		for _, c := range corrections {
+			if c.Record_type == model.PayrollRecordAdjustment {
+				next = c.Payroll_id
+			}
+		}
+		if next == uuid.Nil {
+			return record, nil
+		}
+		record, err = s.payrollRecordRepo.GetPayrollRecord(ctx, next)
+		if err != nil {
+			utils.LogError("Services", "CreateRetroPay get adjustment", err)
+			return record, err
+		}
+	}
+}
+
+// Client items of a stored record. Recurring earnings go back to their
+// monthly amount, the calculation prorates them again.
+func retroInputs(items []model.PayrollItem, proration model.Proration, byCode map[string]model.PayrollComponent) []model.PayrollItemInput {
+	inputs := make([]model.PayrollItemInput, 0, len(items))
+	for _, item := range items {
+		if byCode[item.Component_code].Is_system {
+			continue
+		}
+		amount := item.Amount
+		if item.Component_type == model.ComponentEarning && !item.Is_one_off {
+			amount = unprorateAmount(item.Amount, proration)
+		}
+		inputs = append(inputs, model.PayrollItemInput{Component_code: item.Component_code, Amount: amount, Note: item.Note})
+	}
+	return inputs
+}
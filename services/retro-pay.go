@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/utils"
+)
+
+// Differences between what an earned period should have paid and what it
+// paid, net of rapel already computed for it
+func retroDifference(before model.PayrollRecord, beforeItems []model.PayrollItem, beforeBpjs []model.BpjsItem, after model.PayrollCalculationResult, previous []model.RetroPay) model.RetroPay {
+	retro := model.RetroPay{
+		User_id:             before.User_id,
+		Earned_period:       before.Payment_period,
+		Basic_salary_before: before.Basic_salary,
+		Basic_salary_after:  after.Basic_salary,
+	}
+
+	retro.Earning_difference = payEarnings(after.Items) - payEarnings(beforeItems)
+	retro.Employer_premiums_difference = after.Tax_detail.Employer_premiums - before.Tax_detail.Employer_premiums
+	retro.Pension_difference = after.Tax_detail.Pension_deduction - before.Tax_detail.Pension_deduction
+	retro.Taxable_difference = after.Tax_detail.Taxable_income - before.Tax_detail.Taxable_income
+	retro.Tax_difference = after.Tax_detail.Tax - before.Tax_detail.Tax
+	retro.Employee_tax_difference = after.Tax_detail.Employee_tax - before.Tax_detail.Employee_tax
+
+	byProgram := make(map[string]int)
+	items := make(model.RetroBpjsItems, 0, len(after.Bpjs_detail.Items))
+	for _, item := range after.Bpjs_detail.Items {
+		byProgram[item.Program_code] = len(items)
+		items = append(items, model.BpjsItem{Program_code: item.Program_code, Employer_amount: item.Employer_amount, Employee_amount: item.Employee_amount})
+	}
+	for _, item := range beforeBpjs {
+		i, ok := byProgram[item.Program_code]
+		if !ok {
+			byProgram[item.Program_code] = len(items)
+			items = append(items, model.BpjsItem{Program_code: item.Program_code})
+			i = len(items) - 1
+		}
+		items[i].Employer_amount -= item.Employer_amount
+		items[i].Employee_amount -= item.Employee_amount
+	}
+
+	for _, p := range previous {
+		retro.Basic_salary_before = p.Basic_salary_after
+		retro.Earning_difference -= p.Earning_difference
+		retro.Employer_premiums_difference -= p.Employer_premiums_difference
+		retro.Pension_difference -= p.Pension_difference
+		retro.Taxable_difference -= p.Taxable_difference
+		retro.Tax_difference -= p.Tax_difference
+		retro.Employee_tax_difference -= p.Employee_tax_difference
+		for _, item := range p.Bpjs_items {
+			i, ok := byProgram[item.Program_code]
+			if !ok {
+				byProgram[item.Program_code] = len(items)
+				items = append(items, model.BpjsItem{Program_code: item.Program_code})
+				i = len(items) - 1
+			}
+			items[i].Employer_amount -= item.Employer_amount
+			items[i].Employee_amount -= item.Employee_amount
+		}
+	}
+
+	retro.Bpjs_items = make(model.RetroBpjsItems, 0, len(items))
+	for _, item := range items {
+		if item.Employer_amount == 0 && item.Employee_amount == 0 {
+			continue
+		}
+		retro.Bpjs_employee_difference += item.Employee_amount
+		retro.Bpjs_employer_difference += item.Employer_amount
+		retro.Bpjs_items = append(retro.Bpjs_items, item)
+	}
+	retro.Net_difference = retro.Earning_difference - retro.Bpjs_employee_difference - retro.Employee_tax_difference
+	return retro
+}
+
+// Earnings less the deductions other than BPJS and PPh 21
+func payEarnings(items []model.PayrollItem) int {
+	total := 0
+	for _, item := range items {
+		switch {
+		case item.Component_code == model.ComponentBpjsEmployee, item.Component_code == model.ComponentPph21:
+		case item.Component_type == model.ComponentEarning:
+			total += item.Amount
+		default:
+			total -= item.Amount
+		}
+	}
+	return total
+}
+
+// The December true-up recomputes the tax of the whole year, rapel earned
+// in the same year is part of its income instead of carrying its own tax
+func retroInTrueUp(retro model.RetroPay, period time.Time) bool {
+	return period.Month() == time.December && retro.Earned_period[:4] == period.Format("2006")
+}
+
+func mergeRetroBpjs(detail *model.BpjsBreakdown, retro model.RetroPay) {
+	for _, diff := range retro.Bpjs_items {
+		merged := false
+		for i := range detail.Items {
+			if detail.Items[i].Program_code == diff.Program_code {
+				detail.Items[i].Employer_amount += diff.Employer_amount
+				detail.Items[i].Employee_amount += diff.Employee_amount
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			detail.Items = append(detail.Items, diff)
+		}
+	}
+	detail.Employee_total += retro.Bpjs_employee_difference
+	detail.Employer_total += retro.Bpjs_employer_difference
+	detail.Employer_taxable += retro.Employer_premiums_difference
+	detail.Employee_pension += retro.Pension_difference
+}
+
+func addRetroTax(detail *model.Pph21Breakdown, retro model.RetroPay) {
+	detail.Gross_income += retro.Taxable_difference - retro.Employer_premiums_difference
+	detail.Employer_premiums += retro.Employer_premiums_difference
+	detail.Taxable_income += retro.Taxable_difference
+	detail.Pension_deduction += retro.Pension_difference
+	detail.Tax += retro.Tax_difference
+	detail.Employee_tax += retro.Employee_tax_difference
+	detail.Employer_tax += retro.Tax_difference - retro.Employee_tax_difference
+}
+
+func retroPayNote(retro model.RetroPay) string {
+	if retro.Basic_salary_before == retro.Basic_salary_after {
+		return "Rapel " + retro.Earned_period
+	}
+	return fmt.Sprintf("Rapel %s, gaji pokok %s to %s", retro.Earned_period, utils.FormatRupiah(retro.Basic_salary_before), utils.FormatRupiah(retro.Basic_salary_after))
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestRetroDifference(t *testing.T) {
+	payslip := func(basic, bpjs, tax int) []model.PayrollItem {
+		return []model.PayrollItem{
+			{Component_code: model.ComponentBasicSalary, Component_type: model.ComponentEarning, Amount: basic},
+			{Component_code: "MEAL", Component_type: model.ComponentEarning, Amount: 500000},
+			{Component_code: "UNPAID_LEAVE", Component_type: model.ComponentDeduction, Amount: 100000},
+			{Component_code: model.ComponentBpjsEmployee, Component_type: model.ComponentDeduction, Amount: bpjs},
+			{Component_code: model.ComponentPph21, Component_type: model.ComponentDeduction, Amount: tax},
+		}
+	}
+	before := model.PayrollRecord{
+		Payment_period: "2024-01",
+		Basic_salary:   10000000,
+		Tax_detail:     model.Pph21Breakdown{Employer_premiums: 454000, Pension_deduction: 300000, Taxable_income: 10854000, Tax: 300000, Employee_tax: 300000},
+	}
+	beforeItems := payslip(10000000, 400000, 300000)
+	beforeBpjs := []model.BpjsItem{
+		{Program_code: model.BpjsJht, Employer_amount: 370000, Employee_amount: 200000},
+		{Program_code: model.BpjsJp, Employer_amount: 200000, Employee_amount: 100000},
+		{Program_code: model.BpjsKesehatan, Employer_amount: 400000, Employee_amount: 100000},
+	}
+	// The same month paid on a basic salary of 12.000.000, JP is capped
+	raised := model.PayrollCalculationResult{
+		Basic_salary: 12000000,
+		Items:        payslip(12000000, 460423, 420000),
+		Bpjs_detail: model.BpjsBreakdown{Items: []model.BpjsItem{
+			{Program_code: model.BpjsJht, Employer_amount: 444000, Employee_amount: 240000},
+			{Program_code: model.BpjsJp, Employer_amount: 200846, Employee_amount: 100423},
+			{Program_code: model.BpjsKesehatan, Employer_amount: 480000, Employee_amount: 120000},
+		}},
+		Tax_detail: model.Pph21Breakdown{Employer_premiums: 544800, Pension_deduction: 340423, Taxable_income: 13044800, Tax: 420000, Employee_tax: 420000},
+	}
+	unchanged := model.PayrollCalculationResult{
+		Basic_salary: 10000000,
+		Items:        beforeItems,
+		Bpjs_detail:  model.BpjsBreakdown{Items: beforeBpjs},
+		Tax_detail:   before.Tax_detail,
+	}
+
+	tests := []struct {
+		name     string
+		after    model.PayrollCalculationResult
+		previous []model.RetroPay
+		want     model.RetroPay
+	}{
+		{
+			name: "raise", after: raised,
+			want: model.RetroPay{
+				Earned_period: "2024-01", Basic_salary_before: 10000000, Basic_salary_after: 12000000,
+				Earning_difference: 2000000, Bpjs_employee_difference: 60423, Bpjs_employer_difference: 154846,
+				Bpjs_items: model.RetroBpjsItems{
+					{Program_code: model.BpjsJht, Employer_amount: 74000, Employee_amount: 40000},
+					{Program_code: model.BpjsJp, Employer_amount: 846, Employee_amount: 423},
+					{Program_code: model.BpjsKesehatan, Employer_amount: 80000, Employee_amount: 20000},
+				},
+				Employer_premiums_difference: 90800, Pension_difference: 40423, Taxable_difference: 2190800,
+				Tax_difference: 120000, Employee_tax_difference: 120000, Net_difference: 1819577,
+			},
+		},
+		{
+			// A first raise to 11.000.000 was already paid as rapel
+			name: "rapel already paid", after: raised,
+			previous: []model.RetroPay{{
+				Basic_salary_before: 10000000, Basic_salary_after: 11000000, Earning_difference: 1000000,
+				Bpjs_items:                   model.RetroBpjsItems{{Program_code: model.BpjsJht, Employer_amount: 37000, Employee_amount: 20000}},
+				Employer_premiums_difference: 45400, Pension_difference: 20000, Taxable_difference: 1095400,
+				Tax_difference: 60000, Employee_tax_difference: 60000,
+			}},
+			want: model.RetroPay{
+				Earned_period: "2024-01", Basic_salary_before: 11000000, Basic_salary_after: 12000000,
+				Earning_difference: 1000000, Bpjs_employee_difference: 40423, Bpjs_employer_difference: 117846,
+				Bpjs_items: model.RetroBpjsItems{
+					{Program_code: model.BpjsJht, Employer_amount: 37000, Employee_amount: 20000},
+					{Program_code: model.BpjsJp, Employer_amount: 846, Employee_amount: 423},
+					{Program_code: model.BpjsKesehatan, Employer_amount: 80000, Employee_amount: 20000},
+				},
+				Employer_premiums_difference: 45400, Pension_difference: 20423, Taxable_difference: 1095400,
+				Tax_difference: 60000, Employee_tax_difference: 60000, Net_difference: 899577,
+			},
+		},
+		{
+			// The user left Kesehatan after the period was paid
+			name: "program dropped", after: model.PayrollCalculationResult{
+				Basic_salary: 10000000,
+				Items:        payslip(10000000, 300000, 300000),
+				Bpjs_detail:  model.BpjsBreakdown{Items: beforeBpjs[:2]},
+				Tax_detail:   model.Pph21Breakdown{Employer_premiums: 54000, Pension_deduction: 300000, Taxable_income: 10454000, Tax: 300000, Employee_tax: 300000},
+			},
+			want: model.RetroPay{
+				Earned_period: "2024-01", Basic_salary_before: 10000000, Basic_salary_after: 10000000,
+				Bpjs_employee_difference: -100000, Bpjs_employer_difference: -400000,
+				Bpjs_items:                   model.RetroBpjsItems{{Program_code: model.BpjsKesehatan, Employer_amount: -400000, Employee_amount: -100000}},
+				Employer_premiums_difference: -400000, Taxable_difference: -400000, Net_difference: 100000,
+			},
+		},
+		{
+			name: "nothing changed", after: unchanged,
+			want: model.RetroPay{
+				Earned_period: "2024-01", Basic_salary_before: 10000000, Basic_salary_after: 10000000,
+				Bpjs_items: model.RetroBpjsItems{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retroDifference(before, beforeItems, beforeBpjs, tt.after, tt.previous)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("retroDifference() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetroInTrueUp(t *testing.T) {
+	tests := []struct {
+		earned string
+		period time.Time
+		want   bool
+	}{
+		{"2024-03", time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC), true},
+		{"2024-03", time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2023-11", time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		if got := retroInTrueUp(model.RetroPay{Earned_period: tt.earned}, tt.period); got != tt.want {
+			t.Errorf("retroInTrueUp(%s, %s) = %v, want %v", tt.earned, tt.period.Format(PaymentPeriodLayout), got, tt.want)
+		}
+	}
+}
+
+func TestRetroPayNote(t *testing.T) {
+	tests := []struct {
+		before, after int
+		want          string
+	}{
+		{10000000, 12000000, "Rapel 2024-01, gaji pokok Rp 10.000.000 to Rp 12.000.000"},
+		{10000000, 10000000, "Rapel 2024-01"},
+	}
+
+	for _, tt := range tests {
+		retro := model.RetroPay{Earned_period: "2024-01", Basic_salary_before: tt.before, Basic_salary_after: tt.after}
+		if got := retroPayNote(retro); got != tt.want {
+			t.Errorf("retroPayNote() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+)
+
+// A rendered 1721-A1 certificate or an archive of them
+type TaxCertificateFile struct {
+	Filename string
+	Content  []byte
+}
+
+type TaxCertificateService interface {
+	GetTaxCertificate(ctx context.Context, userId uuid.UUID, year int) (model.TaxCertificate, error)
+	GetTaxCertificateList(ctx context.Context, year int) ([]model.TaxCertificate, error)
+	GetTaxCertificatePDF(ctx context.Context, userId uuid.UUID, year int) (TaxCertificateFile, error)
+	GetTaxCertificateArchive(ctx context.Context, year int) (TaxCertificateFile, error)
+}
+
+type taxCertificateService struct {
+	payrollRecordRepo repository.PayrollRecordRepo
+	payrollItemRepo   repository.PayrollItemRepo
+	userRepo          repository.UserRepo
+	pph21             Pph21Service
+	companyName       string
+	companyTin        string
+	timeoutContext    time.Duration
+}
+
+func NewTaxCertificateService(payrollRecordRepo repository.PayrollRecordRepo, payrollItemRepo repository.PayrollItemRepo, userRepo repository.UserRepo, pph21 Pph21Service, companyName string, companyTin string, timeoutContext time.Duration) TaxCertificateService {
+	return &taxCertificateService{
+		payrollRecordRepo: payrollRecordRepo,
+		payrollItemRepo:   payrollItemRepo,
+		userRepo:          userRepo,
+		pph21:             pph21,
+		companyName:       companyName,
+		companyTin:        companyTin,
+		timeoutContext:    timeoutContext,
+	}
+}
+
+func (s *taxCertificateService) GetTaxCertificate(ctx context.Context, userId uuid.UUID, year int) (model.TaxCertificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	if err := validateTaxYear(year); err != nil {
+		return model.TaxCertificate{}, err
+	}
+	seq, err := s.sequence(ctx, userId, year)
+	if err != nil {
+		return model.TaxCertificate{}, err
+	}
+	return s.certificate(ctx, userId, year, seq)
+}
+
+// Certificates of every user paid in the tax year
+func (s *taxCertificateService) GetTaxCertificateList(ctx context.Context, year int) ([]model.TaxCertificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	list := make([]model.TaxCertificate, 0)
+	if err := validateTaxYear(year); err != nil {
+		return list, err
+	}
+
+	users, err := s.payrollRecordRepo.GetTaxYearUserList(ctx, year)
+	if err != nil {
+		utils.LogError("Services", "GetTaxCertificateList get users", err)
+		return list, err
+	}
+	for i, userId := range users {
+		certificate, err := s.certificate(ctx, userId, year, i+1)
+		if err != nil {
+			return list, err
+		}
+		list = append(list, certificate)
+	}
+	return list, err
+}
+
+func (s *taxCertificateService) GetTaxCertificatePDF(ctx context.Context, userId uuid.UUID, year int) (TaxCertificateFile, error) {
+	var file TaxCertificateFile
+
+	certificate, err := s.GetTaxCertificate(ctx, userId, year)
+	if err != nil {
+		return file, err
+	}
+	file.Filename = taxCertificateFilename(certificate)
+	file.Content = taxCertificateDocument(certificate).Bytes()
+	return file, err
+}
+
+// Zips the certificate PDFs of every user paid in the tax year
+func (s *taxCertificateService) GetTaxCertificateArchive(ctx context.Context, year int) (TaxCertificateFile, error) {
+	var (
+		archive TaxCertificateFile
+		buf     bytes.Buffer
+	)
+
+	list, err := s.GetTaxCertificateList(ctx, year)
+	if err != nil {
+		return archive, err
+	}
+	if len(list) == 0 {
+		return archive, errors.New("no payroll records found for " + strconv.Itoa(year))
+	}
+
+	w := zip.NewWriter(&buf)
+	for _, certificate := range list {
+		f, err := w.Create(taxCertificateFilename(certificate))
+		if err != nil {
+			return archive, err
+		}
+		if _, err = f.Write(taxCertificateDocument(certificate).Bytes()); err != nil {
+			return archive, err
+		}
+	}
+	if err = w.Close(); err != nil {
+		return archive, err
+	}
+
+	archive.Filename = fmt.Sprintf("1721-a1-%d.zip", year)
+	archive.Content = buf.Bytes()
+	return archive, err
+}
+
+// Position of the user in the year's certificate numbering
+func (s *taxCertificateService) sequence(ctx context.Context, userId uuid.UUID, year int) (int, error) {
+	users, err := s.payrollRecordRepo.GetTaxYearUserList(ctx, year)
+	if err != nil {
+		utils.LogError("Services", "1721-A1 sequence get users", err)
+		return 0, err
+	}
+	for i, id := range users {
+		if id == userId {
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("no payroll records of the user in %d", year)
+}
+
+// Aggregates the year's payroll records into the form lines and reconciles
+// the annual tax with what was withheld month by month
+func (s *taxCertificateService) certificate(ctx context.Context, userId uuid.UUID, year int, seq int) (model.TaxCertificate, error) {
+	certificate := model.TaxCertificate{
+		Tax_year:     year,
+		User_id:      userId,
+		Employer:     s.companyName,
+		Employer_tin: s.companyTin,
+	}
+
+	user, err := s.userRepo.GetUserDetail(ctx, userId)
+	if err != nil {
+		utils.LogError("Services", "1721-A1 get user", err)
+		return certificate, err
+	}
+	certificate.Nik = user.Nik
+	certificate.Name = user.Name
+	certificate.Position = user.Position_name
+	certificate.Ptkp_status = user.Ptkp_status
+
+	records, err := s.payrollRecordRepo.GetPayrollRecordListByYear(ctx, userId, year)
+	if err != nil {
+		utils.LogError("Services", "1721-A1 get records", err)
+		return certificate, err
+	}
+	if len(records) == 0 {
+		return certificate, fmt.Errorf("no payroll records of the user in %d", year)
+	}
+
+	for _, record := range records {
+		items, err := s.payrollItemRepo.GetPayrollItemList(ctx, record.Payroll_id)
+		if err != nil {
+			utils.LogError("Services", "1721-A1 get items", err)
+			return certificate, err
+		}
+		for _, item := range items {
+			if !item.Is_taxable {
+				continue
+			}
+			switch {
+			case item.Component_type == model.ComponentDeduction:
+				certificate.Salary -= item.Amount
+			case item.Component_code == model.ComponentBasicSalary, item.Component_code == model.ComponentRapel:
+				certificate.Salary += item.Amount
+			case item.Component_code == model.ComponentTaxAllowance:
+				certificate.Tax_allowance += item.Amount
+			case item.Component_code == model.ComponentOvertime || !item.Is_one_off:
+				certificate.Other_allowances += item.Amount
+			default:
+				certificate.Bonus_and_thr += item.Amount
+			}
+		}
+		certificate.Insurance_premiums += record.Tax_detail.Employer_premiums
+		certificate.Pension_contribution += record.Tax_detail.Pension_deduction
+		certificate.Tax_withheld += record.Tax
+
+		month, _ := time.Parse(PaymentPeriodLayout, record.Earned_period)
+		if certificate.Period_start == 0 {
+			certificate.Period_start = int(month.Month())
+		}
+		certificate.Period_end = int(month.Month())
+	}
+	certificate.Payroll_record_count = len(records)
+	certificate.Number = fmt.Sprintf("1.1-%02d.%02d-%07d", certificate.Period_end, year%100, seq)
+
+	months := certificate.Period_end - certificate.Period_start + 1
+	certificate.Gross_income = certificate.Salary + certificate.Tax_allowance + certificate.Other_allowances +
+		certificate.Honorarium + certificate.Insurance_premiums + certificate.Benefits_in_kind + certificate.Bonus_and_thr
+	certificate.Biaya_jabatan = min(roundRupiah(float64(certificate.Gross_income)*biayaJabatanRate), biayaJabatanMonthlyCap*months)
+	certificate.Total_deductions = certificate.Biaya_jabatan + certificate.Pension_contribution
+	certificate.Net_income = certificate.Gross_income - certificate.Total_deductions
+	certificate.Annual_net_income = certificate.Net_income + certificate.Previous_net_income
+	certificate.Ptkp, err = s.pph21.Ptkp(certificate.Ptkp_status)
+	if err != nil {
+		return certificate, err
+	}
+	certificate.Pkp = max((certificate.Annual_net_income-certificate.Ptkp)/1000*1000, 0)
+	certificate.Annual_tax = s.pph21.AnnualTax(certificate.Pkp)
+	certificate.Tax_due = certificate.Annual_tax - certificate.Previous_tax
+	certificate.Underpaid_tax = certificate.Tax_due - certificate.Tax_withheld
+	return certificate, err
+}
+
+func validateTaxYear(year int) error {
+	if year < 2000 || year > 9999 {
+		return errors.New("year must be a four digit tax year")
+	}
+	return nil
+}
+
+func taxCertificateFilename(certificate model.TaxCertificate) string {
+	return fmt.Sprintf("1721-a1-%d-%s.pdf", certificate.Tax_year, certificate.Nik)
+}
+
+// Lays out the 1721-A1 form on a single A4 page
+func taxCertificateDocument(c model.TaxCertificate) *utils.PDFDocument {
+	const (
+		left   = 40.0
+		right  = utils.PDFPageWidth - 40
+		amount = right - 6
+	)
+	doc := utils.NewPDFDocument()
+	doc.AddPage()
+
+	doc.FillRect(0, 0, utils.PDFPageWidth, 80, 0.92)
+	doc.FillRect(0, 80, utils.PDFPageWidth, 4, 0.15)
+	doc.Text(left, 34, 12, true, "BUKTI PEMOTONGAN PAJAK PENGHASILAN PASAL 21")
+	doc.Text(left, 50, 9, false, "bagi pegawai tetap atau penerima pensiun atau tunjangan hari tua/jaminan hari tua berkala")
+	doc.Text(left, 66, 9, false, "Nomor "+c.Number)
+	doc.TextRight(right, 34, 14, true, "1721 - A1")
+	doc.TextRight(right, 66, 9, false, fmt.Sprintf("Masa perolehan %02d - %02d / %d", c.Period_start, c.Period_end, c.Tax_year))
+
+	y := 110.0
+	section := func(title string) {
+		doc.FillRect(left, y, right-left, 18, 0.9)
+		doc.Text(left+6, y+12, 9, true, title)
+		y += 32
+	}
+	field := func(label, value string) {
+		doc.Text(left+6, y, 8, false, label)
+		doc.Text(left+150, y, 9, true, value)
+		y += 15
+	}
+	line := func(no, label string, value int, bold bool) {
+		doc.Text(left+6, y, 8, bold, no)
+		doc.Text(left+30, y, 8, bold, label)
+		doc.TextRight(amount, y, 9, bold, utils.FormatRupiah(value))
+		y += 15
+	}
+
+	section("A. IDENTITAS PENERIMA PENGHASILAN YANG DIPOTONG")
+	field("NIK / NPWP", c.Nik)
+	field("Nama", c.Name)
+	field("Jabatan", c.Position)
+	field("Status PTKP", c.Ptkp_status)
+	y += 6
+
+	section("B. RINCIAN PENGHASILAN DAN PENGHITUNGAN PPh PASAL 21")
+	doc.Text(left+6, y, 8, true, "PENGHASILAN BRUTO")
+	y += 15
+	line("1", "Gaji/pensiun atau THT/JHT", c.Salary, false)
+	line("2", "Tunjangan PPh", c.Tax_allowance, false)
+	line("3", "Tunjangan lainnya, uang lembur dan sebagainya", c.Other_allowances, false)
+	line("4", "Honorarium dan imbalan lain sejenisnya", c.Honorarium, false)
+	line("5", "Premi asuransi yang dibayar pemberi kerja", c.Insurance_premiums, false)
+	line("6", "Penerimaan dalam bentuk natura dan kenikmatan lainnya", c.Benefits_in_kind, false)
+	line("7", "Tantiem, bonus, gratifikasi, jasa produksi dan THR", c.Bonus_and_thr, false)
+	line("8", "Jumlah penghasilan bruto (1 s.d. 7)", c.Gross_income, true)
+	y += 4
+	doc.Text(left+6, y, 8, true, "PENGURANGAN")
+	y += 15
+	line("9", "Biaya jabatan", c.Biaya_jabatan, false)
+	line("10", "Iuran terkait program pensiun atau hari tua", c.Pension_contribution, false)
+	line("11", "Jumlah pengurangan (9 + 10)", c.Total_deductions, true)
+	y += 4
+	doc.Text(left+6, y, 8, true, "PENGHITUNGAN PPh PASAL 21")
+	y += 15
+	line("12", "Jumlah penghasilan neto (8 - 11)", c.Net_income, false)
+	line("13", "Penghasilan neto masa pajak sebelumnya", c.Previous_net_income, false)
+	line("14", "Jumlah penghasilan neto setahun / disetahunkan", c.Annual_net_income, false)
+	line("15", "Penghasilan tidak kena pajak (PTKP)", c.Ptkp, false)
+	line("16", "Penghasilan kena pajak setahun / disetahunkan", c.Pkp, false)
+	line("17", "PPh Pasal 21 atas penghasilan kena pajak setahun", c.Annual_tax, false)
+	line("18", "PPh Pasal 21 yang telah dipotong masa pajak sebelumnya", c.Previous_tax, false)
+	line("19", "PPh Pasal 21 terutang", c.Tax_due, true)
+	line("20", "PPh Pasal 21 yang telah dipotong dan dilunasi", c.Tax_withheld, false)
+	line("21", "PPh Pasal 21 kurang (lebih) bayar (19 - 20)", c.Underpaid_tax, true)
+	y += 6
+
+	section("C. IDENTITAS PEMOTONG")
+	field("NPWP pemotong", c.Employer_tin)
+	field("Nama pemotong", c.Employer)
+	field("Tanggal", time.Date(c.Tax_year, time.Month(c.Period_end)+1, 0, 0, 0, 0, 0, time.UTC).Format("02-01-2006"))
+
+	doc.Line(left, utils.PDFPageHeight-50, right, utils.PDFPageHeight-50, 0.5)
+	doc.Text(left, utils.PDFPageHeight-36, 7, false, "This certificate is generated electronically from the payroll records of the tax year.")
+	return doc
+}
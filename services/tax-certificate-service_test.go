@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/google/uuid"
+)
+
+// Payroll records of one user's tax year and their items
+type fakeTaxYearRepo struct {
+	repository.PayrollRecordRepo
+	repository.PayrollItemRepo
+	repository.UserRepo
+	ptkpStatus string
+	records    []model.PayrollRecord
+	items      map[uuid.UUID][]model.PayrollItem
+}
+
+func (f *fakeTaxYearRepo) GetUserDetail(ctx context.Context, id uuid.UUID) (model.UserDetailModel, error) {
+	return model.UserDetailModel{User_id: id, Nik: "3201010101010001", Name: "Budi", Ptkp_status: f.ptkpStatus}, nil
+}
+
+func (f *fakeTaxYearRepo) GetPayrollRecordListByYear(ctx context.Context, userId uuid.UUID, year int) ([]model.PayrollRecord, error) {
+	return f.records, nil
+}
+
+func (f *fakeTaxYearRepo) GetPayrollItemList(ctx context.Context, payrollId uuid.UUID) ([]model.PayrollItem, error) {
+	return f.items[payrollId], nil
+}
+
+func TestTaxCertificate(t *testing.T) {
+	type month struct {
+		period   string
+		items    []model.PayrollItem
+		premiums int
+		pension  int
+		tax      int
+	}
+	earning := func(code string, amount int, oneOff bool) model.PayrollItem {
+		return model.PayrollItem{Component_code: code, Component_type: model.ComponentEarning, Amount: amount, Is_taxable: true, Is_one_off: oneOff}
+	}
+	salary := func(amount int) model.PayrollItem { return earning(model.ComponentBasicSalary, amount, false) }
+
+	fullYear := make([]month, 12)
+	for i := range fullYear {
+		fullYear[i] = month{
+			period: fmt.Sprintf("2024-%02d", i+1),
+			items:  []model.PayrollItem{salary(8000000), earning(model.ComponentTaxAllowance, 50000, false)},
+			tax:    50000,
+		}
+	}
+	fullYear[5].items = append(fullYear[5].items, earning(model.ComponentRapel, 1000000, true))
+
+	tests := []struct {
+		name       string
+		ptkpStatus string
+		months     []month
+		want       model.TaxCertificate
+		wantErr    bool
+	}{
+		{
+			// Biaya jabatan stays under the 6.000.000 cap of a full year
+			name: "full year", ptkpStatus: "K/1", months: fullYear,
+			want: model.TaxCertificate{
+				Number: "1.1-12.24-0000001", Period_start: 1, Period_end: 12,
+				Salary: 97000000, Tax_allowance: 600000, Gross_income: 97600000,
+				Biaya_jabatan: 4880000, Total_deductions: 4880000, Net_income: 92720000, Annual_net_income: 92720000,
+				Ptkp: 63000000, Pkp: 29720000, Annual_tax: 1486000, Tax_due: 1486000,
+				Tax_withheld: 600000, Underpaid_tax: 886000, Payroll_record_count: 12,
+			},
+		},
+		{
+			// Three months cap biaya jabatan at 1.500.000, a deduction lowers
+			// the salary and the non taxable reimbursement is left out
+			name: "joined in october", ptkpStatus: "TK/0",
+			months: []month{
+				{period: "2024-10", items: []model.PayrollItem{salary(20000000), earning("MEAL", 1000000, false)}, premiums: 54000, pension: 500423, tax: 400000},
+				{period: "2024-11", items: []model.PayrollItem{
+					salary(20000000), earning("MEAL", 1000000, false), earning(model.ComponentOvertime, 500000, true),
+					{Component_code: "UNPAID_LEAVE", Component_type: model.ComponentDeduction, Amount: 1000000, Is_taxable: true},
+					{Component_code: "REIMBURSEMENT", Component_type: model.ComponentEarning, Amount: 750000, Is_one_off: true},
+				}, premiums: 54000, pension: 500423, tax: 400000},
+				{period: "2024-12", items: []model.PayrollItem{salary(20000000), earning("MEAL", 1000000, false), earning("THR", 10000000, true)}, premiums: 54000, pension: 500423, tax: 400000},
+			},
+			want: model.TaxCertificate{
+				Number: "1.1-12.24-0000001", Period_start: 10, Period_end: 12,
+				Salary: 59000000, Other_allowances: 3500000, Insurance_premiums: 162000, Bonus_and_thr: 10000000, Gross_income: 72662000,
+				Biaya_jabatan: 1500000, Pension_contribution: 1501269, Total_deductions: 3001269, Net_income: 69660731, Annual_net_income: 69660731,
+				Ptkp: 54000000, Pkp: 15660000, Annual_tax: 783000, Tax_due: 783000,
+				Tax_withheld: 1200000, Underpaid_tax: -417000, Payroll_record_count: 3,
+			},
+		},
+		{name: "no records", ptkpStatus: "TK/0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTaxYearRepo{ptkpStatus: tt.ptkpStatus, items: map[uuid.UUID][]model.PayrollItem{}}
+			for _, m := range tt.months {
+				record := model.PayrollRecord{
+					Payroll_id: uuid.New(), Payment_period: m.period, Earned_period: m.period, Tax: m.tax,
+					Tax_detail: model.Pph21Breakdown{Employer_premiums: m.premiums, Pension_deduction: m.pension},
+				}
+				repo.records = append(repo.records, record)
+				repo.items[record.Payroll_id] = m.items
+			}
+			s := &taxCertificateService{payrollRecordRepo: repo, payrollItemRepo: repo, userRepo: repo, pph21: NewPph21Service()}
+
+			userId := uuid.New()
+			got, err := s.certificate(context.Background(), userId, 2024, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("certificate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			tt.want.Tax_year, tt.want.User_id, tt.want.Nik, tt.want.Name, tt.want.Ptkp_status = 2024, userId, "3201010101010001", "Budi", tt.ptkpStatus
+			if got != tt.want {
+				t.Errorf("certificate() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTaxYear(t *testing.T) {
+	tests := []struct {
+		year    int
+		wantErr bool
+	}{
+		{2024, false},
+		{1999, true},
+		{24, true},
+		{10000, true},
+	}
+
+	for _, tt := range tests {
+		if err := validateTaxYear(tt.year); (err != nil) != tt.wantErr {
+			t.Errorf("validateTaxYear(%d) error = %v, wantErr %v", tt.year, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+	"github.com/dafiqarba/be-payroll/repository"
+	"github.com/dafiqarba/be-payroll/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Permenaker 6/2016: THR is due at least 7 days before the holiday
+const thrPaymentDeadlineDays = 7
+
+type ThrService interface {
+	//Create
+	CreateThrRun(ctx context.Context, r model.CreateThrRunModel) (model.PayrollRunGenerateResult, error)
+	//Read
+	GetThrEntitlementList(ctx context.Context, holidayDate string) ([]model.ThrEntitlement, error)
+}
+
+type thrService struct {
+	payrollRunRepo     repository.PayrollRunRepo
+	payrollPeriodRepo  repository.PayrollPeriodRepo
+	payrollRecordRepo  repository.PayrollRecordRepo
+	payrollItemRepo    repository.PayrollItemRepo
+	bpjsRepo           repository.BpjsRepo
+	userRepo           repository.UserRepo
+	statusRepo         repository.StatusRepo
+	compensationRepo   repository.CompensationRepo
+	payrollCalculation PayrollCalculationService
+	timeoutContext     time.Duration
+	db                 *sqlx.DB
+}
+
+func NewThrService(payrollRunRepo repository.PayrollRunRepo, payrollPeriodRepo repository.PayrollPeriodRepo, payrollRecordRepo repository.PayrollRecordRepo, payrollItemRepo repository.PayrollItemRepo, bpjsRepo repository.BpjsRepo, userRepo repository.UserRepo, statusRepo repository.StatusRepo, compensationRepo repository.CompensationRepo, calc PayrollCalculationService, timeoutContext time.Duration, db *sqlx.DB) ThrService {
+	return &thrService{
+		payrollRunRepo:     payrollRunRepo,
+		payrollPeriodRepo:  payrollPeriodRepo,
+		payrollRecordRepo:  payrollRecordRepo,
+		payrollItemRepo:    payrollItemRepo,
+		bpjsRepo:           bpjsRepo,
+		userRepo:           userRepo,
+		statusRepo:         statusRepo,
+		compensationRepo:   compensationRepo,
+		payrollCalculation: calc,
+		timeoutContext:     timeoutContext,
+		db:                 db,
+	}
+}
+
+// THR entitlement of every active user as of holidayDate (YYYY-MM-DD)
+func (s *thrService) GetThrEntitlementList(ctx context.Context, holidayDate string) ([]model.ThrEntitlement, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	holiday, err := time.Parse("2006-01-02", holidayDate)
+	if err != nil {
+		return make([]model.ThrEntitlement, 0), errors.New("holiday_date must be formatted as YYYY-MM-DD")
+	}
+	return s.entitlements(ctx, holiday)
+}
+
+// Opens a THR run paid on the payment date and generates a draft record for
+// every eligible user. The run is a payroll run of its own so it goes
+// through the same review and approval as the regular payroll.
+func (s *thrService) CreateThrRun(ctx context.Context, r model.CreateThrRunModel) (model.PayrollRunGenerateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutContext)
+	defer cancel()
+
+	var (
+		result model.PayrollRunGenerateResult
+		err    error
+	)
+	result.Created = make([]uuid.UUID, 0)
+	result.Adopted = make([]uuid.UUID, 0)
+	result.Skipped = make([]model.PayrollRunSkippedUser, 0)
+
+	if r.Holiday_name == "" {
+		return result, errors.New("holiday_name is required")
+	}
+	holiday, err := time.Parse("2006-01-02", r.Holiday_date)
+	if err != nil {
+		return result, errors.New("holiday_date must be formatted as YYYY-MM-DD")
+	}
+	paymentDate, err := time.Parse("2006-01-02", r.Payment_date)
+	if err != nil {
+		return result, errors.New("payment_date must be formatted as YYYY-MM-DD")
+	}
+	if paymentDate.After(holiday.AddDate(0, 0, -thrPaymentDeadlineDays)) {
+		return result, fmt.Errorf("THR must be paid at least %d days before the holiday", thrPaymentDeadlineDays)
+	}
+	period := paymentDate.Format(PaymentPeriodLayout)
+	err = checkPayrollPeriodOpen(ctx, s.payrollPeriodRepo, period)
+	if err != nil {
+		return result, err
+	}
+
+	_, err = s.payrollRunRepo.GetPayrollRunByPeriod(ctx, period, model.PayrollRunThr)
+	if err == nil {
+		return result, errors.New("THR run of " + period + " already exists")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		utils.LogError("Services", "CreateThrRun get run by period", err)
+		return result, err
+	}
+
+	draft, err := s.statusRepo.GetStatusByName(ctx, model.PayrollRunDraft)
+	if err != nil {
+		utils.LogError("Services", "CreateThrRun get draft status", err)
+		return result, err
+	}
+
+	entitlements, err := s.entitlements(ctx, holiday)
+	if err != nil {
+		return result, err
+	}
+
+	type draftRecord struct {
+		record model.PayrollRecord
+		result model.PayrollCalculationResult
+	}
+	drafts := make([]draftRecord, 0, len(entitlements))
+	for _, entitlement := range entitlements {
+		if !entitlement.Eligible {
+			result.Skipped = append(result.Skipped, model.PayrollRunSkippedUser{User_id: entitlement.User_id, Name: entitlement.Name, Reason: entitlement.Reason})
+			continue
+		}
+
+		taxMethod := r.Tax_method
+		if taxMethod == "" {
+			taxMethod = entitlement.Tax_method
+		}
+		calculation, err := s.payrollCalculation.CalculateIrregular(ctx, model.PayrollIrregularInput{
+			User_id:        entitlement.User_id,
+			Payment_period: period,
+			Component_code: model.ComponentThr,
+			Amount:         entitlement.Amount,
+			Note:           thrNote(r.Holiday_name, entitlement),
+			Tax_method:     taxMethod,
+			Regular_income: entitlement.Regular_income,
+		})
+		if err != nil {
+			result.Skipped = append(result.Skipped, model.PayrollRunSkippedUser{User_id: entitlement.User_id, Name: entitlement.Name, Reason: err.Error()})
+			continue
+		}
+		drafts = append(drafts, draftRecord{record: payrollRecordFromResult(calculation, paymentDate, draft.Status_id), result: calculation})
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "CreateThrRun open tx", err)
+		return result, err
+	}
+
+	result.Run_id, err = s.payrollRunRepo.CreatePayrollRun(ctx, tx, model.PayrollRun{
+		Payment_period: period,
+		Payment_date:   paymentDate,
+		Run_type:       model.PayrollRunThr,
+		Status:         model.PayrollRunDraft,
+		Holiday_name:   r.Holiday_name,
+		Holiday_date:   &holiday,
+	})
+	if err != nil {
+		utils.LogError("Services", "CreateThrRun", err)
+		utils.CommitOrRollback(tx, "Services CreateThrRun", err)
+		return result, err
+	}
+
+	for _, d := range drafts {
+		d.record.Run_id = uuid.NullUUID{UUID: result.Run_id, Valid: true}
+		id, err := s.payrollRecordRepo.CreatePayrollRecord(ctx, tx, d.record)
+		if err == nil {
+			err = saveLineItems(ctx, tx, s.payrollItemRepo, s.bpjsRepo, id, d.result)
+		}
+		if err != nil {
+			utils.LogError("Services", "CreateThrRun create record", err)
+			utils.CommitOrRollback(tx, "Services CreateThrRun", err)
+			return result, err
+		}
+		result.Created = append(result.Created, id)
+	}
+
+	utils.CommitOrRollback(tx, "Services CreateThrRun", err)
+	return result, err
+}
+
+// Entitlement of every active user. The monthly wage is the basic salary
+// plus fixed allowances of the compensation in force on the holiday, the
+// latest regular payroll record only gives the regular income and the tax
+// method the THR is taxed with.
+func (s *thrService) entitlements(ctx context.Context, holiday time.Time) ([]model.ThrEntitlement, error) {
+	list := make([]model.ThrEntitlement, 0)
+
+	users, err := s.userRepo.GetActiveUserList(ctx)
+	if err != nil {
+		utils.LogError("Services", "THR entitlements get active users", err)
+		return list, err
+	}
+
+	for _, user := range users {
+		entitlement := model.ThrEntitlement{
+			User_id:   user.User_id,
+			Name:      user.Name,
+			Nik:       user.Nik,
+			Join_date: user.Join_date,
+		}
+
+		compensation, err := s.compensationRepo.GetCompensationInForce(ctx, user.User_id, holiday)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			entitlement.Reason = "no compensation in force on the holiday"
+		case err != nil:
+			utils.LogError("Services", "THR entitlements get compensation", err)
+			return list, err
+		default:
+			entitlement.Monthly_wage = thrWage(compensation)
+		}
+
+		// A new hire has no record yet and is taxed on the THR alone
+		latest, err := s.payrollRecordRepo.GetLatestPayrollRecord(ctx, user.User_id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			utils.LogError("Services", "THR entitlements get latest record", err)
+			return list, err
+		default:
+			entitlement.Tax_method = latest.Tax_method
+			entitlement.Regular_income = latest.Tax_detail.Taxable_income
+		}
+
+		if entitlement.Reason == "" {
+			entitlement.Service_months, entitlement.Amount, entitlement.Reason = thrEntitlement(user.Join_date, user.Termination_date, holiday, entitlement.Monthly_wage)
+			entitlement.Eligible = entitlement.Reason == ""
+		}
+		list = append(list, entitlement)
+	}
+	return list, err
+}
+
+// Permenaker 6/2016 article 3, the wage of THR is the basic salary plus the
+// fixed allowances
+func thrWage(compensation model.Compensation) int {
+	wage := compensation.Basic_salary
+	for _, allowance := range compensation.Allowances {
+		wage += allowance.Amount
+	}
+	return wage
+}
+
+// Permenaker 6/2016: a full month of wage from 12 months of service, months
+// of service / 12 of it from one month of service
+func thrEntitlement(join *time.Time, termination *time.Time, holiday time.Time, wage int) (int, int, string) {
+	if join == nil {
+		return 0, 0, "join date is not set"
+	}
+	if termination != nil && truncateDay(*termination).Before(holiday) {
+		return 0, 0, "employment ends before the holiday"
+	}
+
+	start := truncateDay(*join)
+	months := (holiday.Year()-start.Year())*12 + int(holiday.Month()-start.Month())
+	if holiday.Day() < start.Day() {
+		months--
+	}
+	switch {
+	case months < 1:
+		return max(months, 0), 0, "less than one month of service"
+	case wage <= 0:
+		return months, 0, "no fixed wage"
+	case months >= 12:
+		return months, wage, ""
+	default:
+		return months, roundRupiah(float64(wage) * float64(months) / 12), ""
+	}
+}
+
+func thrNote(holidayName string, entitlement model.ThrEntitlement) string {
+	if entitlement.Service_months >= 12 {
+		return fmt.Sprintf("THR %s, one month of %s", holidayName, utils.FormatRupiah(entitlement.Monthly_wage))
+	}
+	return fmt.Sprintf("THR %s, %d/12 of %s", holidayName, entitlement.Service_months, utils.FormatRupiah(entitlement.Monthly_wage))
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dafiqarba/be-payroll/model"
+)
+
+func TestThrEntitlement(t *testing.T) {
+	holiday := time.Date(2024, time.April, 10, 0, 0, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	const wage = 5000000
+
+	tests := []struct {
+		name        string
+		join        *time.Time
+		termination *time.Time
+		wage        int
+		months      int
+		amount      int
+		reason      string
+	}{
+		{"twelve months exactly", date(2023, time.April, 10), nil, wage, 12, wage, ""},
+		{"several years", date(2019, time.January, 2), nil, wage, 63, wage, ""},
+		{"eleven months", date(2023, time.May, 10), nil, wage, 11, 4583333, ""},
+		{"six months", date(2023, time.October, 10), nil, wage, 6, 2500000, ""},
+		{"a day short of six months", date(2023, time.October, 11), nil, wage, 5, 2083333, ""},
+		{"one month", date(2024, time.March, 10), nil, wage, 1, 416667, ""},
+		{"less than one month", date(2024, time.March, 11), nil, wage, 0, 0, "less than one month of service"},
+		{"joins after the holiday", date(2024, time.May, 1), nil, wage, 0, 0, "less than one month of service"},
+		{"terminated on the holiday", date(2023, time.October, 10), date(2024, time.April, 10), wage, 6, 2500000, ""},
+		{"terminated before the holiday", date(2023, time.October, 10), date(2024, time.April, 9), wage, 0, 0, "employment ends before the holiday"},
+		{"no fixed wage", date(2023, time.October, 10), nil, 0, 6, 0, "no fixed wage"},
+		{"no join date", nil, nil, wage, 0, 0, "join date is not set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			months, amount, reason := thrEntitlement(tt.join, tt.termination, holiday, tt.wage)
+			if months != tt.months || amount != tt.amount || reason != tt.reason {
+				t.Errorf("thrEntitlement() = %d, %d, %q, want %d, %d, %q", months, amount, reason, tt.months, tt.amount, tt.reason)
+			}
+		})
+	}
+}
+
+func TestThrWage(t *testing.T) {
+	tests := []struct {
+		name         string
+		compensation model.Compensation
+		wage         int
+	}{
+		{"basic salary only", model.Compensation{Basic_salary: 5000000}, 5000000},
+		{
+			"basic salary and fixed allowances",
+			model.Compensation{Basic_salary: 5000000, Allowances: model.CompensationItems{
+				{Component_code: "POSITION", Amount: 1000000},
+				{Component_code: "FAMILY", Amount: 500000},
+			}},
+			6500000,
+		},
+		{"no compensation", model.Compensation{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thrWage(tt.compensation); got != tt.wage {
+				t.Errorf("thrWage() = %d, want %d", got, tt.wage)
+			}
+		})
+	}
+}
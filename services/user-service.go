@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/dafiqarba/be-payroll/model"
@@ -19,6 +20,8 @@ type UserService interface {
 	//Read
 	GetUserList(ctx context.Context) ([]model.User, error)
 	GetUserDetail(ctx context.Context, id uuid.UUID) (model.UserDetailModel, error)
+	//Update
+	UpdateUserEmployment(ctx context.Context, id uuid.UUID, u model.UpdateUserEmploymentModel) (uuid.UUID, error)
 }
 
 type userService struct {
@@ -118,6 +121,43 @@ func (service *userService) CreateUser(ctx context.Context, u model.RegisterUser
 		Nik:         u.Nik,
 		Role_id:     u.Role_id,
 		Position_id: u.Position_id,
+		Ptkp_status: u.Ptkp_status,
+		BankAccount: u.BankAccount,
+	}
+
+	if registeredData.Ptkp_status == "" {
+		registeredData.Ptkp_status = model.PtkpTK0
+	}
+	if err = ValidatePtkpStatus(registeredData.Ptkp_status); err != nil {
+		utils.LogError("Service", "CreateUser validate ptkp status", err)
+		utils.CommitOrRollback(tx, "Services CreateUser", err)
+		return email, err
+	}
+	if u.Birth_date != "" {
+		birthDate, err := time.Parse("2006-01-02", u.Birth_date)
+		if err != nil {
+			err = errors.New("birth_date must be formatted as YYYY-MM-DD")
+			utils.CommitOrRollback(tx, "Services CreateUser", err)
+			return email, err
+		}
+		registeredData.Birth_date = &birthDate
+	}
+	if u.Join_date != "" {
+		joinDate, err := time.Parse("2006-01-02", u.Join_date)
+		if err != nil {
+			err = errors.New("join_date must be formatted as YYYY-MM-DD")
+			utils.CommitOrRollback(tx, "Services CreateUser", err)
+			return email, err
+		}
+		registeredData.Join_date = &joinDate
+	}
+	// Bank data is optional at registration but must be valid when given
+	if u.Bank_code != "" || u.Bank_account_number != "" || u.Bank_account_name != "" {
+		registeredData.Bank_code = strings.ToUpper(strings.TrimSpace(u.Bank_code))
+		if err = ValidateBankAccount(registeredData.BankAccount); err != nil {
+			utils.CommitOrRollback(tx, "Services CreateUser", err)
+			return email, err
+		}
 	}
 
 	email, err = service.userRepository.CreateUser(ctx, tx, registeredData)
@@ -128,3 +168,52 @@ func (service *userService) CreateUser(ctx context.Context, u model.RegisterUser
 	}
 	return email, nil
 }
+
+// Sets the employment window that prorates the first and last salary
+func (service *userService) UpdateUserEmployment(ctx context.Context, id uuid.UUID, u model.UpdateUserEmploymentModel) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, service.timeoutContext)
+	defer cancel()
+
+	var (
+		idResult uuid.UUID
+		user     model.User
+		err      error
+	)
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  **time.Time
+	}{
+		{"join_date", u.Join_date, &user.Join_date},
+		{"termination_date", u.Termination_date, &user.Termination_date},
+	} {
+		if field.value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", field.value)
+		if err != nil {
+			return idResult, errors.New(field.name + " must be formatted as YYYY-MM-DD")
+		}
+		*field.dest = &date
+	}
+	if user.Join_date != nil && user.Termination_date != nil && user.Termination_date.Before(*user.Join_date) {
+		return idResult, errors.New("termination_date must not be before join_date")
+	}
+
+	tx, err := service.db.Beginx()
+	if err != nil {
+		utils.LogError("Services", "UpdateUserEmployment open tx", err)
+		return idResult, err
+	}
+
+	idResult, err = service.userRepository.UpdateUserEmployment(ctx, tx, id, user)
+	if err != nil {
+		utils.LogError("Services", "UpdateUserEmployment", err)
+		utils.CommitOrRollback(tx, "Services UpdateUserEmployment", err)
+		return idResult, err
+	}
+
+	utils.CommitOrRollback(tx, "Services UpdateUserEmployment", err)
+	return idResult, err
+}
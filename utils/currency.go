@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"strconv"
+)
+
+// FormatRupiah formats an amount as Rp 1.234.567
+func FormatRupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.Itoa(amount)
+	grouped := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped = append(grouped, '.')
+		}
+		grouped = append(grouped, digits[i])
+	}
+	return sign + "Rp " + string(grouped)
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+type MailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+type MailMessage struct {
+	To          string
+	Subject     string
+	Body        string
+	Attachments []MailAttachment
+}
+
+// Mailer delivers a single message, SMTP in production and a local catch-all
+// such as MailHog during development
+type Mailer interface {
+	Send(msg MailMessage) error
+}
+
+type smtpMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer authenticates with PLAIN auth only when a username is given,
+// a local catch-all usually accepts anonymous mail
+func NewSMTPMailer(host string, port int, username string, password string, from string) Mailer {
+	return &smtpMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *smtpMailer) Send(msg MailMessage) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	addr := m.host + ":" + strconv.Itoa(m.port)
+	return smtp.SendMail(addr, auth, m.from, []string{msg.To}, buildMIMEMessage(m.from, msg))
+}
+
+func buildMIMEMessage(from string, msg MailMessage) []byte {
+	var b bytes.Buffer
+	boundary := fmt.Sprintf("be-payroll-%d", time.Now().UnixNano())
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64Lines(&b, []byte(msg.Body))
+
+	for _, attachment := range msg.Attachments {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; name=%q\r\n", attachment.ContentType, attachment.Filename)
+		b.WriteString("Content-Transfer-Encoding: base64\r\n")
+		fmt.Fprintf(&b, "Content-Disposition: attachment; filename=%q\r\n\r\n", attachment.Filename)
+		writeBase64Lines(&b, attachment.Content)
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+// Base64 body wrapped at 76 characters as required by RFC 2045
+func writeBase64Lines(b *bytes.Buffer, content []byte) {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+}
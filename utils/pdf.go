@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PDFPageWidth  = 595.0
+	PDFPageHeight = 842.0
+)
+
+// Widths of the Helvetica glyphs 32 to 126 in 1/1000 em, used to right
+// align text. Helvetica-Bold is slightly wider but close enough for figures.
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// PDFDocument is a minimal PDF 1.4 writer for text based documents such as
+// payslips. Coordinates are in points measured from the top left corner.
+type PDFDocument struct {
+	pages         []*bytes.Buffer
+	userPassword  string
+	ownerPassword string
+	protected     bool
+}
+
+func NewPDFDocument() *PDFDocument {
+	return &PDFDocument{}
+}
+
+// SetPassword protects the document with the standard security handler
+// (128 bit RC4). The user password is needed to open the document, the
+// owner password lifts the permission restrictions and is random when empty.
+func (d *PDFDocument) SetPassword(userPassword, ownerPassword string) {
+	if ownerPassword == "" {
+		random := make([]byte, 16)
+		rand.Read(random)
+		ownerPassword = hex.EncodeToString(random)
+	}
+	d.userPassword = userPassword
+	d.ownerPassword = ownerPassword
+	d.protected = true
+}
+
+// AddPage starts a new A4 page, every drawing call goes to the last page
+func (d *PDFDocument) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *PDFDocument) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws s with its baseline at y
+func (d *PDFDocument) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PDFPageHeight-y, pdfEscape(s))
+}
+
+// TextRight draws s so that it ends at x
+func (d *PDFDocument) TextRight(x, y, size float64, bold bool, s string) {
+	d.Text(x-PDFTextWidth(s, size), y, size, bold, s)
+}
+
+// Line draws a stroke from (x1, y1) to (x2, y2)
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PDFPageHeight-y1, x2, PDFPageHeight-y2)
+}
+
+// FillRect paints a rectangle in the given gray level, 0 is black and 1 white
+func (d *PDFDocument) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.page(), "%.2f g %.2f %.2f %.2f %.2f re f 0 g\n", gray, x, PDFPageHeight-y-h, w, h)
+}
+
+// Bytes serializes the document
+func (d *PDFDocument) Bytes() []byte {
+	d.page()
+
+	var (
+		out     bytes.Buffer
+		offsets []int
+		sec     *pdfSecurity
+	)
+	id := make([]byte, 16)
+	rand.Read(id)
+	if d.protected {
+		sec = newPDFSecurity(d.userPassword, d.ownerPassword, id)
+	}
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(content []byte) {
+		if sec != nil {
+			content = sec.encrypt(len(offsets)+1, content)
+		}
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3 and 4 fonts, then a page and its content
+	// stream for every page
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", PDFPageWidth, PDFPageHeight, 6+i*2))
+		stream(content.Bytes())
+	}
+	trailer := fmt.Sprintf("/Size %d /Root 1 0 R /ID [<%x> <%x>]", len(offsets)+1, id, id)
+	if sec != nil {
+		object(fmt.Sprintf("<< /Filter /Standard /V 2 /R 3 /Length 128 /O <%x> /U <%x> /P %d >>", sec.o, sec.u, sec.p))
+		trailer = fmt.Sprintf("/Size %d /Root 1 0 R /Encrypt %d 0 R /ID [<%x> <%x>]", len(offsets)+1, len(offsets), id, id)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< %s >>\nstartxref\n%d\n%%%%EOF\n", trailer, xref)
+	return out.Bytes()
+}
+
+// PDFTextWidth returns the width of s in points when set in Helvetica
+func PDFTextWidth(s string, size float64) float64 {
+	width := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			width += helveticaWidths[r-32]
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// Escapes a string literal and maps it to WinAnsi, which matches Latin-1
+// for the characters used in names
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Padding of the standard security handler, PDF 1.7 section 7.6.3.3
+var pdfPasswordPadding = []byte{
+	0x28, 0xbf, 0x4e, 0x5e, 0x4e, 0x75, 0x8a, 0x41, 0x64, 0x00, 0x4e, 0x56, 0xff, 0xfa, 0x01, 0x08,
+	0x2e, 0x2e, 0x00, 0xb6, 0xd0, 0x68, 0x3e, 0x80, 0x2f, 0x0c, 0xa9, 0xfe, 0x64, 0x53, 0x69, 0x7a,
+}
+
+// Allows printing, copying and accessibility but not modifying
+const pdfPermissions int32 = -1324
+
+// Revision 3 standard security handler with a 128 bit key
+type pdfSecurity struct {
+	key []byte
+	o   []byte
+	u   []byte
+	p   int32
+}
+
+func newPDFSecurity(userPassword, ownerPassword string, id []byte) *pdfSecurity {
+	sec := &pdfSecurity{p: pdfPermissions}
+
+	// Algorithm 3, the owner entry
+	hash := md5.Sum(padPDFPassword(ownerPassword))
+	for i := 0; i < 50; i++ {
+		hash = md5.Sum(hash[:])
+	}
+	sec.o = padPDFPassword(userPassword)
+	for i := 0; i < 20; i++ {
+		rc4Apply(xorKey(hash[:], byte(i)), sec.o)
+	}
+
+	// Algorithm 2, the file encryption key
+	permissions := make([]byte, 4)
+	binary.LittleEndian.PutUint32(permissions, uint32(sec.p))
+	h := md5.New()
+	h.Write(padPDFPassword(userPassword))
+	h.Write(sec.o)
+	h.Write(permissions)
+	h.Write(id)
+	key := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+	sec.key = key
+
+	// Algorithm 5, the user entry
+	h = md5.New()
+	h.Write(pdfPasswordPadding)
+	h.Write(id)
+	sec.u = h.Sum(nil)
+	for i := 0; i < 20; i++ {
+		rc4Apply(xorKey(sec.key, byte(i)), sec.u)
+	}
+	sec.u = append(sec.u, make([]byte, 16)...)
+	return sec
+}
+
+// Encrypts the data of object number num with its object key (algorithm 1)
+func (sec *pdfSecurity) encrypt(num int, data []byte) []byte {
+	seed := append([]byte{}, sec.key...)
+	seed = append(seed, byte(num), byte(num>>8), byte(num>>16), 0, 0)
+	objectKey := md5.Sum(seed)
+	out := append([]byte{}, data...)
+	rc4Apply(objectKey[:], out)
+	return out
+}
+
+func padPDFPassword(password string) []byte {
+	padded := append([]byte(password), pdfPasswordPadding...)
+	return padded[:32]
+}
+
+func xorKey(key []byte, n byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ n
+	}
+	return out
+}
+
+func rc4Apply(key, data []byte) {
+	cipher, _ := rc4.NewCipher(key)
+	cipher.XORKeyStream(data, data)
+}
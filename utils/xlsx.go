@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Upper bound of a single part once unzipped, guards against zip bombs
+const xlsxMaxPartSize = 32 << 20
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Id string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// Shared or inline string, rich text is split in runs
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var s strings.Builder
+	for _, run := range t.Runs {
+		s.WriteString(run.T)
+	}
+	return s.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string   `xml:"r,attr"`
+			T  string   `xml:"t,attr"`
+			V  string   `xml:"v"`
+			Is xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX returns the cell values of the first worksheet of an Office Open
+// XML workbook, row i of the result is spreadsheet row i+1. Numbers come as
+// stored, so dates are serial numbers and no number format is applied.
+func ReadXLSX(content []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.New("file is not a valid xlsx workbook")
+	}
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err = decodeXLSXPart(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := parts[firstSheetPath(parts)]
+	if !ok {
+		return nil, errors.New("workbook has no worksheet")
+	}
+	var sheet xlsxWorksheet
+	if err = decodeXLSXPart(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		// Empty rows are left out of the file, keep the numbering
+		if row.R > len(rows)+1 {
+			rows = append(rows, make([][]string, row.R-len(rows)-1)...)
+		}
+		var values []string
+		for _, cell := range row.Cells {
+			col := len(values)
+			if cell.R != "" {
+				if col, err = xlsxColumn(cell.R); err != nil {
+					return nil, err
+				}
+			}
+			var value string
+			switch cell.T {
+			case "s":
+				i, err := strconv.Atoi(cell.V)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, errors.New("cell " + cell.R + " refers to a missing shared string")
+				}
+				value = shared.Items[i].String()
+			case "inlineStr":
+				value = cell.Is.String()
+			case "b":
+				value = "FALSE"
+				if cell.V == "1" {
+					value = "TRUE"
+				}
+			default:
+				value = cell.V
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			values[col] = value
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// Path of the first sheet in workbook order, which is not necessarily
+// sheet1.xml once sheets were moved or deleted
+func firstSheetPath(parts map[string]*zip.File) string {
+	fallback := "xl/worksheets/sheet1.xml"
+	var (
+		workbook xlsxWorkbook
+		rels     xlsxRelationships
+	)
+	wb, ok := parts["xl/workbook.xml"]
+	if !ok || decodeXLSXPart(wb, &workbook) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	r, ok := parts["xl/_rels/workbook.xml.rels"]
+	if !ok || decodeXLSXPart(r, &rels) != nil {
+		return fallback
+	}
+	for _, rel := range rels.Relationships {
+		if rel.Id != workbook.Sheets[0].Id {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+func decodeXLSXPart(f *zip.File, v interface{}) error {
+	if f.UncompressedSize64 > xlsxMaxPartSize {
+		return errors.New(f.Name + " is too large")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err = xml.NewDecoder(io.LimitReader(rc, xlsxMaxPartSize)).Decode(v); err != nil {
+		return errors.New(f.Name + " cannot be read: " + err.Error())
+	}
+	return nil
+}
+
+// Zero based column of a cell reference such as "AB12"
+func xlsxColumn(ref string) (int, error) {
+	col := 0
+	for i, r := range ref {
+		if r >= '0' && r <= '9' {
+			if i == 0 {
+				break
+			}
+			return col - 1, nil
+		}
+		if r < 'A' || r > 'Z' || col > 16384 {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return 0, errors.New("invalid cell reference " + ref)
+}